@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -21,7 +23,7 @@ type App struct {
 	logger    providers.Logger
 }
 
-func NewApp(apiController *controllers.ApiController, healthController *controllers.HealthController, scheduler interfaces.SchedulerInterface, conf *structures.Config, logger providers.Logger, router providers.RouterProviderInterface, metrics providers.MetricsProviderInterface) (*App, error) {
+func NewApp(apiController *controllers.ApiController, healthController *controllers.HealthController, socketController *controllers.SocketController, scheduler interfaces.SchedulerInterface, conf *structures.Config, logger providers.Logger, router providers.RouterProviderInterface, grpcServer *grpc.Server, udp providers.UDPListenerInterface, agents providers.UserAgentProviderInterface, geo providers.GeoIPProviderInterface, metrics providers.MetricsProviderInterface) (*App, error) {
 	// Inner mux: API routes
 	apiMux := http.NewServeMux()
 	for _, route := range router.GetRoutes() {
@@ -55,25 +57,71 @@ func NewApp(apiController *controllers.ApiController, healthController *controll
 		},
 		logger: logger,
 	}
+	app.WebServer.RegisterOnShutdown(socketController.Close)
+
+	var unixListener net.Listener
+	if conf.WebServer.UnixSocket.Path != "" {
+		unixListener, err = listenUnix(conf.WebServer.UnixSocket)
+		if err != nil {
+			return nil, fmt.Errorf("unix socket: %w", err)
+		}
+	} else if conf.WebServer.UnixSocket.Only {
+		return nil, fmt.Errorf("webServer.unixSocket.only is set without a path")
+	}
+	var grpcListener net.Listener
+	if grpcServer != nil {
+		grpcListener, err = net.Listen("tcp", grpcAddr(conf))
+		if err != nil {
+			closeListeners(unixListener)
+			return nil, fmt.Errorf("grpc listen: %w", err)
+		}
+	}
+	if err = udp.Listen(); err != nil {
+		closeListeners(unixListener, grpcListener)
+		return nil, fmt.Errorf("udp listen: %w", err)
+	}
 
 	scheduler.Init()
 
-	serverErr := make(chan error, 1)
-	go func() {
-		logger.Infof(providers.TypeApp, "Listening HTTP clients on %s:%d", conf.WebServer.Host, conf.WebServer.Port)
-		if err := app.WebServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			serverErr <- err
-		}
-	}()
+	serverErr := make(chan error, 3)
+	if !conf.WebServer.UnixSocket.Only {
+		go func() {
+			logger.Infof(providers.TypeApp, "Listening HTTP clients on %s:%d", conf.WebServer.Host, conf.WebServer.Port)
+			if err := app.WebServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				serverErr <- err
+			}
+		}()
+	}
+	if unixListener != nil {
+		go func() {
+			logger.Infof(providers.TypeApp, "Listening HTTP clients on unix:%s", conf.WebServer.UnixSocket.Path)
+			if err := app.WebServer.Serve(unixListener); err != nil && err != http.ErrServerClosed {
+				serverErr <- err
+			}
+		}()
+	}
+
+	if grpcServer != nil {
+		go func() {
+			logger.Infof(providers.TypeApp, "Listening gRPC clients on %s", grpcListener.Addr())
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				serverErr <- fmt.Errorf("grpc: %w", err)
+			}
+		}()
+	}
 
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
+	// A failed server shuts down like a signal, so the other listeners are
+	// closed and the statistics persisted before the error is returned.
+	var failed error
 	select {
 	case <-stop:
 		logger.Infof(providers.TypeApp, "Shutdown signal received")
 	case err := <-serverErr:
-		return nil, fmt.Errorf("server error: %w", err)
+		failed = fmt.Errorf("server error: %w", err)
+		logger.Errorf(providers.TypeApp, "%s, shutting down", failed)
 	}
 
 	signal.Stop(stop)
@@ -82,17 +130,40 @@ func NewApp(apiController *controllers.ApiController, healthController *controll
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if grpcServer != nil {
+		stopGrpc(ctx, grpcServer)
+	}
+	udp.Close()
 	if err = app.WebServer.Shutdown(ctx); err != nil {
 		return nil, err
 	}
+	// Serve may not have tracked the Unix listener yet when a server failed
+	// right after startup; closing it again is harmless.
+	closeListeners(unixListener)
+	agents.Close()
+	geo.Close()
 	err = scheduler.Persist()
 	if err != nil {
 		return nil, err
 	}
 
 	scheduler.Close()
+	if failed != nil {
+		logger.Close()
+		return nil, failed
+	}
 	logger.Infof(providers.TypeApp, "gracefully stopped")
 	logger.Close()
 
 	return app, nil
 }
+
+// closeListeners closes the listeners opened before a later startup step
+// failed, which also removes the Unix socket.
+func closeListeners(listeners ...net.Listener) {
+	for _, lis := range listeners {
+		if lis != nil {
+			_ = lis.Close()
+		}
+	}
+}
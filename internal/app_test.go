@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"ssd/internal/controllers"
+	"ssd/internal/providers"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"ssd/internal/testutil"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type appTestScheduler struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (s *appTestScheduler) record(call string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+}
+
+func (s *appTestScheduler) Init()          { s.record("init") }
+func (s *appTestScheduler) Stop()          { s.record("stop") }
+func (s *appTestScheduler) Close()         { s.record("close") }
+func (s *appTestScheduler) Restore() error { s.record("restore"); return nil }
+func (s *appTestScheduler) Persist() error { s.record("persist"); return nil }
+
+func TestNewApp_ServerErrorShutsDown(t *testing.T) {
+	// Hold the HTTP port so ListenAndServe fails after startup.
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer busy.Close()
+
+	path := filepath.Join(t.TempDir(), "ssd.sock")
+	conf := &structures.Config{}
+	conf.WebServer.Host = "127.0.0.1"
+	conf.WebServer.Port = busy.Addr().(*net.TCPAddr).Port
+	conf.WebServer.UnixSocket = structures.UnixSocketConfig{Path: path, Mode: 0600}
+
+	svc := &routeTestMockService{}
+	cors := providers.NewCORSPolicy(conf)
+	ac := controllers.NewApiController(&routeTestLogger{}, svc, &routeTestCache{}, services.NewIngestService(svc, nil), cors)
+	wsc := controllers.NewSocketController(conf, ac, &testutil.MockStream{})
+	scheduler := &appTestScheduler{}
+	agents, err := providers.NewUserAgentProvider(conf, &routeTestLogger{}, nil)
+	require.NoError(t, err)
+	geo, err := providers.NewGeoIPProvider(conf, &routeTestLogger{})
+	require.NoError(t, err)
+
+	app, err := NewApp(ac, controllers.NewHealthController(svc), wsc, scheduler, conf, &routeTestLogger{},
+		providers.NewRouterProvider(cors), nil, providers.NewUDPListener(conf, nil, nil, nil),
+		agents, geo, providers.NewMetricsProvider(conf, svc))
+	require.Error(t, err)
+	assert.Nil(t, app)
+	assert.Contains(t, err.Error(), "server error")
+
+	assert.Equal(t, []string{"restore", "init", "stop", "persist", "close"}, scheduler.calls)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "the unix socket is removed")
+}
@@ -1,26 +1,53 @@
 package controllers
 
 import (
+	"errors"
 	json "github.com/goccy/go-json"
+	"mime"
 	"net/http"
+	"net/url"
 	"ssd/internal/models"
 	"ssd/internal/providers"
 	"ssd/internal/services"
+	"strconv"
+	"strings"
+	"time"
 )
 
 const maxRequestBodySize = 1 << 20 // 1 MB
 
+const (
+	defaultResultLimit = 10
+	maxResultLimit     = 100
+)
+
+// transparentGIF is a 1x1 transparent GIF served by /px.gif.
+var transparentGIF = []byte{
+	'G', 'I', 'F', '8', '9', 'a', 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0xff, 0xff, 0xff,
+	0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00,
+	0x2c, 0x00, 0x00, 0x00, 0x00, 0x01, 0x00, 0x01, 0x00, 0x00,
+	0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// errNotFound makes serveFromCacheOrCompute answer 404 without caching.
+var errNotFound = errors.New("not found")
+
 type ApiController struct {
 	logger  providers.Logger
 	service services.StatisticServiceInterface
 	cache   providers.CacheProviderInterface
+	ingest  services.IngestServiceInterface
+	cors    providers.CORSPolicyInterface
 }
 
-func NewApiController(logger providers.Logger, service services.StatisticServiceInterface, cache providers.CacheProviderInterface) *ApiController {
+func NewApiController(logger providers.Logger, service services.StatisticServiceInterface, cache providers.CacheProviderInterface, ingest services.IngestServiceInterface, cors providers.CORSPolicyInterface) *ApiController {
 	return &ApiController{
 		logger:  logger,
 		service: service,
 		cache:   cache,
+		ingest:  ingest,
+		cors:    cors,
 	}
 }
 
@@ -34,13 +61,15 @@ func getChannel(r *http.Request) string {
 
 func (ac *ApiController) serveFromCacheOrCompute(w http.ResponseWriter, cacheKey string, compute func() (any, error)) {
 	if data, ok := ac.cache.Get(cacheKey); ok {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write(data)
+		writeJSON(w, data)
 		return
 	}
 
 	result, err := compute()
+	if errors.Is(err, errNotFound) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
@@ -53,39 +82,190 @@ func (ac *ApiController) serveFromCacheOrCompute(w http.ResponseWriter, cacheKey
 	}
 
 	ac.cache.Set(cacheKey, gson)
+	writeJSON(w, gson)
+}
+
+// getLimit parses the n query parameter, defaulting to defaultResultLimit and
+// capping at maxResultLimit. It reports false for a malformed or non-positive n.
+func getLimit(r *http.Request) (int, bool) {
+	raw := r.URL.Query().Get("n")
+	if raw == "" {
+		return defaultResultLimit, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return min(n, maxResultLimit), true
+}
 
+// getCatalogFilter parses the tag, category and publishedAfter query
+// parameters. publishedAfter accepts RFC 3339 or Unix seconds.
+func getCatalogFilter(r *http.Request) (models.CatalogFilter, bool) {
+	q := r.URL.Query()
+	filter := models.CatalogFilter{Tag: q.Get("tag"), Category: q.Get("category")}
+	if raw := q.Get("publishedAfter"); raw != "" {
+		if sec, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			filter.PublishedAfter = time.Unix(sec, 0).UTC()
+		} else if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			filter.PublishedAfter = t
+		} else {
+			return filter, false
+		}
+	}
+	return filter, true
+}
+
+// filterCacheKey encodes a non-empty filter for response cache keys.
+func filterCacheKey(filter models.CatalogFilter) string {
+	if filter.IsEmpty() {
+		return ""
+	}
+	key := ":" + filter.Tag + ":" + filter.Category + ":"
+	if !filter.PublishedAfter.IsZero() {
+		key += strconv.FormatInt(filter.PublishedAfter.Unix(), 10)
+	}
+	return key
+}
+
+// writeJSON sends an already serialized JSON body.
+func writeJSON(w http.ResponseWriter, gson []byte) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(gson)
 }
 
+// ReceiveStats records an event sent as JSON, or as form fields like
+// /px.gif. navigator.sendBeacon posts JSON strings as text/plain, which is
+// decoded like any body that is not a form. Such simple requests skip the
+// preflight, so the origin is checked against the event's channel here.
 func (ac *ApiController) ReceiveStats(w http.ResponseWriter, r *http.Request) {
 	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBodySize)
 	var payload models.InputStats
-	err := json.NewDecoder(r.Body).Decode(&payload)
-	if err != nil {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		payload = statsFromValues(r.PostForm)
+	} else if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		http.Error(w, "Bad Request", http.StatusBadRequest)
 		return
 	}
-	if payload.Channel == "" {
-		payload.Channel = services.DefaultChannel
+	if !ac.originAllowed(r, &payload) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
 	}
-	ac.service.AddStats(&payload)
+	// Dropped bot traffic is still acknowledged so clients do not retry it.
+	ac.ingest.Ingest(services.RequestSource(r), &payload)
 	w.WriteHeader(http.StatusCreated)
 }
 
+// TrackPixel records the event in the query and answers with a transparent
+// GIF for image tags in pages and emails. The image is served whatever the
+// query holds, since an image has no way to report errors; events from
+// origins not allowed for their channel are skipped.
+func (ac *ApiController) TrackPixel(w http.ResponseWriter, r *http.Request) {
+	payload := statsFromValues(r.URL.Query())
+	if (len(payload.Views) > 0 || len(payload.Clicks) > 0) && ac.originAllowed(r, &payload) {
+		ac.ingest.Ingest(services.RequestSource(r), &payload)
+	}
+	header := w.Header()
+	header.Set("Content-Type", "image/gif")
+	header.Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	header.Set("Pragma", "no-cache")
+	header.Set("Expires", "0")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(transparentGIF)
+}
+
+// statsFromValues maps the v, c, f and ch fields of a query or form onto an
+// event. v and c take comma-separated or repeated IDs.
+func statsFromValues(values url.Values) models.InputStats {
+	return models.InputStats{
+		Views:       splitIDs(values["v"]),
+		Clicks:      splitIDs(values["c"]),
+		Fingerprint: values.Get("f"),
+		Channel:     values.Get("ch"),
+	}
+}
+
+func splitIDs(raw []string) []string {
+	var ids []string
+	for _, value := range raw {
+		for _, id := range strings.Split(value, ",") {
+			if id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// originAllowed checks the Origin of a browser request against the CORS
+// policy of the event's channel. Requests without one are not sent by a
+// page and are not restricted.
+func (ac *ApiController) originAllowed(r *http.Request, payload *models.InputStats) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || ac.cors.Allowed(origin, payload.Channel)
+}
+
+// GetStats serves the trend JSON pre-serialized at aggregation time, bypassing
+// the response cache. Lists narrowed by dim=name:value or catalog filters are
+// computed and cached. With sort=views|clicks|sum|avg|ctr the list becomes an
+// array of the n highest ranked items.
 func (ac *ApiController) GetStats(w http.ResponseWriter, r *http.Request) {
 	ch := getChannel(r)
-	ac.serveFromCacheOrCompute(w, "list:"+ch, func() (any, error) {
-		return ac.service.GetStatistic(ch), nil
+	filter, ok := getCatalogFilter(r)
+	if !ok {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	by := r.URL.Query().Get("sort")
+	n, limitOk := getLimit(r)
+	if by != "" && (!services.ValidSortKey(by) || !limitOk) {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	key := "list:" + ch
+	records := func() map[int]*models.StatRecord {
+		if filter.IsEmpty() {
+			return ac.service.GetStatistic(ch)
+		}
+		return ac.service.GetFilteredStatistic(ch, filter)
+	}
+	if raw := r.URL.Query().Get("dim"); raw != "" {
+		name, value, found := strings.Cut(raw, ":")
+		if !found || name == "" || value == "" {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		dim := models.DimKey{Name: name, Value: value}
+		key += ":dim:" + raw
+		records = func() map[int]*models.StatRecord {
+			return ac.service.GetDimensionStatistic(ch, dim, filter)
+		}
+	} else if filter.IsEmpty() && by == "" {
+		writeJSON(w, ac.service.GetStatisticJSON(ch))
+		return
+	}
+	key += filterCacheKey(filter)
+	if by == "" {
+		ac.serveFromCacheOrCompute(w, key, func() (any, error) {
+			return records(), nil
+		})
+		return
+	}
+	ac.serveFromCacheOrCompute(w, key+":sort:"+by+":"+strconv.Itoa(n), func() (any, error) {
+		return services.TopRecords(records(), by, n), nil
 	})
 }
 
+// GetPersonalStats serves the fingerprint JSON of the published read view,
+// encoded at most once per aggregation.
 func (ac *ApiController) GetPersonalStats(w http.ResponseWriter, r *http.Request) {
-	ch := getChannel(r)
-	ac.serveFromCacheOrCompute(w, "fps:"+ch, func() (any, error) {
-		return ac.service.GetPersonalStatistic(ch), nil
-	})
+	writeJSON(w, ac.service.GetPersonalStatisticJSON(getChannel(r)))
 }
 
 func (ac *ApiController) GetByFingerprint(w http.ResponseWriter, r *http.Request) {
@@ -101,3 +281,114 @@ func (ac *ApiController) GetChannels(w http.ResponseWriter, r *http.Request) {
 		return ac.service.GetChannels(), nil
 	})
 }
+
+// GetRelated serves the items most often seen by the same fingerprints as id.
+func (ac *ApiController) GetRelated(w http.ResponseWriter, r *http.Request) {
+	ch := getChannel(r)
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	n, ok := getLimit(r)
+	if err != nil || !ok {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	ac.serveFromCacheOrCompute(w, "related:"+ch+":"+strconv.Itoa(id)+":"+strconv.Itoa(n), func() (any, error) {
+		return ac.service.GetRelated(ch, id, n), nil
+	})
+}
+
+// GetFeed serves the channel's trending items ranked for the fingerprint f.
+func (ac *ApiController) GetFeed(w http.ResponseWriter, r *http.Request) {
+	ch := getChannel(r)
+	fp := r.URL.Query().Get("f")
+	n, ok := getLimit(r)
+	filter, filterOk := getCatalogFilter(r)
+	if !ok || !filterOk {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	ac.serveFromCacheOrCompute(w, "feed:"+ch+":"+fp+":"+strconv.Itoa(n)+filterCacheKey(filter), func() (any, error) {
+		return ac.service.GetFeed(ch, fp, n, filter), nil
+	})
+}
+
+// GetBreakdown serves an item's statistics split by the values of one dimension.
+func (ac *ApiController) GetBreakdown(w http.ResponseWriter, r *http.Request) {
+	ch := getChannel(r)
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	dim := r.URL.Query().Get("dim")
+	if err != nil || dim == "" {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	ac.serveFromCacheOrCompute(w, "breakdown:"+ch+":"+strconv.Itoa(id)+":"+dim, func() (any, error) {
+		return ac.service.GetBreakdown(ch, id, dim), nil
+	})
+}
+
+// getItemIDs parses the comma-separated item query parameter. It reports
+// false if any ID is malformed.
+func getItemIDs(r *http.Request) ([]int, bool) {
+	raw := r.URL.Query().Get("item")
+	if raw == "" {
+		return nil, true
+	}
+	var items []int
+	for _, part := range strings.Split(raw, ",") {
+		item, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, false
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+// GetExperiment reports the variants of the experiment named in the path,
+// over all items or over the comma-separated item IDs in item.
+func (ac *ApiController) GetExperiment(w http.ResponseWriter, r *http.Request) {
+	ch := getChannel(r)
+	id := r.PathValue("id")
+	items, ok := getItemIDs(r)
+	if !ok || id == "" {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	ac.serveFromCacheOrCompute(w, "experiment:"+ch+":"+id+":"+r.URL.Query().Get("item"), func() (any, error) {
+		if report := ac.service.GetExperiment(ch, id, items); report != nil {
+			return report, nil
+		}
+		return nil, errNotFound
+	})
+}
+
+// GetRising serves the n items of a channel gaining views fastest over the
+// last aggregation ticks.
+func (ac *ApiController) GetRising(w http.ResponseWriter, r *http.Request) {
+	ch := getChannel(r)
+	n, ok := getLimit(r)
+	if !ok {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	ac.serveFromCacheOrCompute(w, "rising:"+ch+":"+strconv.Itoa(n), func() (any, error) {
+		return ac.service.GetRising(ch, n), nil
+	})
+}
+
+// GetFunnel reports the step conversion of the funnel named in the path over
+// all items, adding a per-item breakdown for the item IDs in item.
+func (ac *ApiController) GetFunnel(w http.ResponseWriter, r *http.Request) {
+	ch := getChannel(r)
+	name := r.PathValue("name")
+	items, ok := getItemIDs(r)
+	if !ok || name == "" {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	ac.serveFromCacheOrCompute(w, "funnel:"+ch+":"+name+":"+r.URL.Query().Get("item"), func() (any, error) {
+		if report := ac.service.GetFunnel(ch, name, items); report != nil {
+			return report, nil
+		}
+		return nil, errNotFound
+	})
+}
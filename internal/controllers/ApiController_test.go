@@ -1,11 +1,15 @@
 package controllers
 
 import (
+	"bytes"
 	"encoding/json"
+	"image/gif"
 	"net/http"
 	"net/http/httptest"
 	"ssd/internal/models"
 	"ssd/internal/providers"
+	"ssd/internal/services"
+	"ssd/internal/structures"
 	"strings"
 	"testing"
 
@@ -30,21 +34,92 @@ type mockService struct {
 	personalData  map[string]*models.Statistic
 	fpData        map[int]*models.StatRecord
 	channelsList  []string
+	memoryStats   services.MemoryStats
+	related       []models.RelatedItem
+	relatedN      int
+	feed          []services.FeedItem
+	feedFp        string
+	feedN         int
+	feedFilter    models.CatalogFilter
+	listFilter    models.CatalogFilter
+	catalog       map[int]*models.CatalogItem
+	catalogUpsert map[int]*models.CatalogItem
+	catalogRemove []int
+	dimKey        models.DimKey
+	breakdown     map[string]*models.StatRecord
+	breakdownDim  string
+	experiment    *services.ExperimentReport
+	experimentID  string
+	expItems      []int
+	funnel        *services.FunnelReport
+	funnelName    string
+	funnelItems   []int
+	rising        []services.RisingItem
+	risingN       int
 }
 
 func (m *mockService) AddStats(data *models.InputStats)                 { m.addCalls = append(m.addCalls, data) }
-func (m *mockService) AggregateStats()                                  {}
+func (m *mockService) AggregateStats() int                              { return 0 }
 func (m *mockService) GetStatistic(_ string) map[int]*models.StatRecord { return m.statisticData }
+func (m *mockService) GetStatisticJSON(_ string) []byte {
+	gson, _ := json.Marshal(m.statisticData)
+	return gson
+}
 func (m *mockService) GetPersonalStatistic(_ string) map[string]*models.Statistic {
 	return m.personalData
 }
+func (m *mockService) GetPersonalStatisticJSON(_ string) []byte {
+	gson, _ := json.Marshal(m.personalData)
+	return gson
+}
 func (m *mockService) GetByFingerprint(_, _ string) map[int]*models.StatRecord { return m.fpData }
-func (m *mockService) PutChannelData(_ string, _ map[int]*models.StatRecord, _ map[string]*models.Statistic) {
+func (m *mockService) GetRelated(_ string, _, n int) []models.RelatedItem {
+	m.relatedN = n
+	return m.related
+}
+func (m *mockService) GetFeed(_, fp string, n int, filter models.CatalogFilter) []services.FeedItem {
+	m.feedFp, m.feedN, m.feedFilter = fp, n, filter
+	return m.feed
+}
+func (m *mockService) GetFilteredStatistic(_ string, filter models.CatalogFilter) map[int]*models.StatRecord {
+	m.listFilter = filter
+	return m.statisticData
+}
+func (m *mockService) GetDimensionStatistic(_ string, dim models.DimKey, filter models.CatalogFilter) map[int]*models.StatRecord {
+	m.dimKey, m.listFilter = dim, filter
+	return m.statisticData
+}
+func (m *mockService) GetBreakdown(_ string, _ int, dim string) map[string]*models.StatRecord {
+	m.breakdownDim = dim
+	return m.breakdown
+}
+func (m *mockService) GetExperiment(_, id string, items []int) *services.ExperimentReport {
+	m.experimentID, m.expItems = id, items
+	return m.experiment
+}
+func (m *mockService) GetFunnel(_, name string, items []int) *services.FunnelReport {
+	m.funnelName, m.funnelItems = name, items
+	return m.funnel
+}
+func (m *mockService) GetRising(_ string, n int) []services.RisingItem {
+	m.risingN = n
+	return m.rising
 }
-func (m *mockService) GetChannels() []string        { return m.channelsList }
-func (m *mockService) GetSnapshot() *models.Storage { return nil }
-func (m *mockService) GetBufferSize() int           { return 0 }
-func (m *mockService) GetRecordCount(_ string) int  { return 0 }
+func (m *mockService) GetLastBatch() map[string]services.BatchStats { return nil }
+func (m *mockService) GetExperimentRejects() int64                  { return 0 }
+func (m *mockService) GetCatalogItem(_ string, id int) (*models.CatalogItem, bool) {
+	item, ok := m.catalog[id]
+	return item, ok
+}
+func (m *mockService) UpdateCatalog(_ string, upsert map[int]*models.CatalogItem, remove []int) {
+	m.catalogUpsert, m.catalogRemove = upsert, remove
+}
+func (m *mockService) PutChannelData(_ string, _ *models.ChannelData) {}
+func (m *mockService) GetChannels() []string                          { return m.channelsList }
+func (m *mockService) GetSnapshot() *models.Storage                   { return nil }
+func (m *mockService) GetBufferSize() int                             { return 0 }
+func (m *mockService) GetRecordCount(_ string) int                    { return 0 }
+func (m *mockService) GetMemoryStats() services.MemoryStats           { return m.memoryStats }
 
 type mockCache struct {
 	data map[string][]byte
@@ -54,10 +129,42 @@ func newMockCache() *mockCache                     { return &mockCache{data: mak
 func (m *mockCache) Get(key string) ([]byte, bool) { v, ok := m.data[key]; return v, ok }
 func (m *mockCache) Set(key string, value []byte)  { m.data[key] = value }
 
+// mockUserAgent drops events whose User-Agent equals drop and tags the rest.
+type mockUserAgent struct {
+	drop string
+}
+
+func (m *mockUserAgent) Enrich(src services.Source, payload *models.InputStats) bool {
+	if m.drop != "" && src.UserAgent == m.drop {
+		return false
+	}
+	if ua := src.UserAgent; ua != "" {
+		payload.Dims = map[string]string{"ua": ua}
+	}
+	return true
+}
+
+// mockGeoIP locates clients in 81.0.0.0/8 in DE.
+type mockGeoIP struct{}
+
+func (m *mockGeoIP) Enrich(src services.Source, payload *models.InputStats) bool {
+	if strings.HasPrefix(src.RemoteAddr, "81.") {
+		if payload.Dims == nil {
+			payload.Dims = make(map[string]string)
+		}
+		payload.Dims["country"] = "DE"
+	}
+	return true
+}
+
 // --- helpers ---
 
+func newTestIngest(svc services.StatisticServiceInterface, agents *mockUserAgent) services.IngestServiceInterface {
+	return services.NewIngestService(svc, []services.Enricher{agents, &mockGeoIP{}})
+}
+
 func newTestController(svc *mockService, cache *mockCache) *ApiController {
-	return NewApiController(&mockLogger{}, svc, cache)
+	return NewApiController(&mockLogger{}, svc, cache, newTestIngest(svc, &mockUserAgent{}), providers.NewCORSPolicy(&structures.Config{}))
 }
 
 // --- ReceiveStats tests ---
@@ -79,6 +186,37 @@ func TestReceiveStats_ValidPayload(t *testing.T) {
 	assert.Equal(t, []string{"1", "2"}, svc.addCalls[0].Views)
 }
 
+func TestReceiveStats_Enrichment(t *testing.T) {
+	svc := &mockService{}
+	ac := NewApiController(&mockLogger{}, svc, newMockCache(), newTestIngest(svc, &mockUserAgent{drop: "bot"}), providers.NewCORSPolicy(&structures.Config{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"v":["1"]}`))
+	req.Header.Set("User-Agent", "browser")
+	rr := httptest.NewRecorder()
+	ac.ReceiveStats(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	require.Len(t, svc.addCalls, 1)
+	assert.Equal(t, "browser", svc.addCalls[0].Dims["ua"])
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"v":["1"]}`))
+	req.Header.Set("User-Agent", "browser")
+	req.RemoteAddr = "81.2.3.4:5555"
+	rr = httptest.NewRecorder()
+	ac.ReceiveStats(rr, req)
+
+	require.Len(t, svc.addCalls, 2)
+	assert.Equal(t, map[string]string{"ua": "browser", "country": "DE"}, svc.addCalls[1].Dims)
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"v":["1"]}`))
+	req.Header.Set("User-Agent", "bot")
+	rr = httptest.NewRecorder()
+	ac.ReceiveStats(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code, "dropped events are acknowledged")
+	assert.Len(t, svc.addCalls, 2)
+}
+
 func TestReceiveStats_InvalidJSON(t *testing.T) {
 	svc := &mockService{}
 	ac := newTestController(svc, newMockCache())
@@ -132,6 +270,112 @@ func TestReceiveStats_DefaultChannel(t *testing.T) {
 	assert.Equal(t, "default", svc.addCalls[0].Channel)
 }
 
+func TestReceiveStats_TextPlainBody(t *testing.T) {
+	svc := &mockService{}
+	ac := newTestController(svc, newMockCache())
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"v":["1"],"ch":"news"}`))
+	req.Header.Set("Content-Type", "text/plain;charset=UTF-8")
+	rr := httptest.NewRecorder()
+
+	ac.ReceiveStats(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	require.Len(t, svc.addCalls, 1)
+	assert.Equal(t, []string{"1"}, svc.addCalls[0].Views)
+	assert.Equal(t, "news", svc.addCalls[0].Channel)
+}
+
+func TestReceiveStats_FormBody(t *testing.T) {
+	svc := &mockService{}
+	ac := newTestController(svc, newMockCache())
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("v=1,2&c=2&f=abc&ch=news"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr := httptest.NewRecorder()
+
+	ac.ReceiveStats(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	require.Len(t, svc.addCalls, 1)
+	assert.Equal(t, []string{"1", "2"}, svc.addCalls[0].Views)
+	assert.Equal(t, []string{"2"}, svc.addCalls[0].Clicks)
+	assert.Equal(t, "abc", svc.addCalls[0].Fingerprint)
+	assert.Equal(t, "news", svc.addCalls[0].Channel)
+}
+
+// --- TrackPixel tests ---
+
+func TestTrackPixel_RecordsEvent(t *testing.T) {
+	svc := &mockService{}
+	ac := newTestController(svc, newMockCache())
+
+	req := httptest.NewRequest(http.MethodGet, "/px.gif?v=1,2&v=3&f=abc", nil)
+	rr := httptest.NewRecorder()
+
+	ac.TrackPixel(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "image/gif", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "no-cache, no-store, must-revalidate", rr.Header().Get("Cache-Control"))
+	img, err := gif.Decode(bytes.NewReader(rr.Body.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 1, img.Bounds().Dx())
+	assert.Equal(t, 1, img.Bounds().Dy())
+
+	require.Len(t, svc.addCalls, 1)
+	assert.Equal(t, []string{"1", "2", "3"}, svc.addCalls[0].Views)
+	assert.Equal(t, "abc", svc.addCalls[0].Fingerprint)
+	assert.Equal(t, "default", svc.addCalls[0].Channel)
+}
+
+func TestTrackPixel_EmptyQuery(t *testing.T) {
+	svc := &mockService{}
+	ac := newTestController(svc, newMockCache())
+
+	req := httptest.NewRequest(http.MethodGet, "/px.gif?f=abc", nil)
+	rr := httptest.NewRecorder()
+
+	ac.TrackPixel(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, transparentGIF, rr.Body.Bytes())
+	assert.Empty(t, svc.addCalls)
+}
+
+func TestIngest_OriginCheckedAgainstEventChannel(t *testing.T) {
+	svc := &mockService{}
+	cors := providers.NewCORSPolicy(&structures.Config{CORS: structures.CORSConfig{
+		Enabled:        true,
+		AllowedOrigins: []string{"https://www.example.com"},
+		Channels:       map[string][]string{"shop": {"https://shop.example.com"}},
+	}})
+	ac := NewApiController(&mockLogger{}, svc, newMockCache(), newTestIngest(svc, &mockUserAgent{}), cors)
+
+	post := func(body, origin string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/plain")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rr := httptest.NewRecorder()
+		ac.ReceiveStats(rr, req)
+		return rr.Code
+	}
+	assert.Equal(t, http.StatusCreated, post(`{"v":["1"]}`, "https://www.example.com"))
+	assert.Equal(t, http.StatusForbidden, post(`{"v":["1"],"ch":"shop"}`, "https://www.example.com"), "the channel in the body is checked without a preflight")
+	assert.Equal(t, http.StatusCreated, post(`{"v":["1"],"ch":"shop"}`, "https://shop.example.com"))
+	assert.Equal(t, http.StatusCreated, post(`{"v":["1"],"ch":"shop"}`, ""), "requests without an origin are not from a page")
+	assert.Len(t, svc.addCalls, 3)
+
+	req := httptest.NewRequest(http.MethodGet, "/px.gif?v=1&ch=shop", nil)
+	req.Header.Set("Origin", "https://www.example.com")
+	rr := httptest.NewRecorder()
+	ac.TrackPixel(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Len(t, svc.addCalls, 3, "the pixel is served but the event skipped")
+}
+
 // --- GetStats tests ---
 
 func TestGetStats_ReturnsJSON(t *testing.T) {
@@ -240,23 +484,41 @@ func TestGetChannels_ReturnsJSON(t *testing.T) {
 func TestCacheHit_ServiceNotCalled(t *testing.T) {
 	cache := newMockCache()
 	cachedData, _ := json.Marshal(map[string]int{"1": 10})
-	cache.Set("list:default", cachedData)
+	cache.Set("fp:default:fp1", cachedData)
 
 	svc := &mockService{
-		statisticData: map[int]*models.StatRecord{99: {Views: 999}},
+		fpData: map[int]*models.StatRecord{99: {Views: 999}},
 	}
 	ac := newTestController(svc, cache)
 
-	req := httptest.NewRequest(http.MethodGet, "/list", nil)
+	req := httptest.NewRequest(http.MethodGet, "/fingerprint?f=fp1", nil)
 	rr := httptest.NewRecorder()
 
-	ac.GetStats(rr, req)
+	ac.GetByFingerprint(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
 	assert.Equal(t, string(cachedData), rr.Body.String())
 }
 
 func TestCacheMiss_SavesResult(t *testing.T) {
+	cache := newMockCache()
+	svc := &mockService{
+		fpData: map[int]*models.StatRecord{1: {Views: 10}},
+	}
+	ac := newTestController(svc, cache)
+
+	req := httptest.NewRequest(http.MethodGet, "/fingerprint?f=fp1", nil)
+	rr := httptest.NewRecorder()
+
+	ac.GetByFingerprint(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	val, ok := cache.Get("fp:default:fp1")
+	assert.True(t, ok)
+	assert.NotEmpty(t, val)
+}
+
+func TestGetStats_BypassesCache(t *testing.T) {
 	cache := newMockCache()
 	svc := &mockService{
 		statisticData: map[int]*models.StatRecord{1: {Views: 10}},
@@ -269,9 +531,7 @@ func TestCacheMiss_SavesResult(t *testing.T) {
 	ac.GetStats(rr, req)
 
 	assert.Equal(t, http.StatusOK, rr.Code)
-	val, ok := cache.Get("list:default")
-	assert.True(t, ok)
-	assert.NotEmpty(t, val)
+	assert.Empty(t, cache.data)
 }
 
 func TestCacheKey_Channels(t *testing.T) {
@@ -302,6 +562,321 @@ func TestCacheKey_FingerprintIncludesFP(t *testing.T) {
 	assert.True(t, ok)
 }
 
+// --- Catalog filter tests ---
+
+func TestGetStats_CatalogFilter(t *testing.T) {
+	svc := &mockService{statisticData: map[int]*models.StatRecord{1: {Views: 3}}}
+	cache := newMockCache()
+	ac := newTestController(svc, cache)
+
+	req := httptest.NewRequest(http.MethodGet, "/list?ch=news&tag=euro&category=sports&publishedAfter=1700000000", nil)
+	rr := httptest.NewRecorder()
+	ac.GetStats(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "euro", svc.listFilter.Tag)
+	assert.Equal(t, "sports", svc.listFilter.Category)
+	assert.Equal(t, int64(1700000000), svc.listFilter.PublishedAfter.Unix())
+	_, ok := cache.Get("list:news:euro:sports:1700000000")
+	assert.True(t, ok)
+}
+
+func TestGetStats_PublishedAfterRFC3339(t *testing.T) {
+	svc := &mockService{}
+	ac := newTestController(svc, newMockCache())
+
+	rr := httptest.NewRecorder()
+	ac.GetStats(rr, httptest.NewRequest(http.MethodGet, "/list?publishedAfter=2026-05-01T00:00:00Z", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 2026, svc.listFilter.PublishedAfter.Year())
+}
+
+func TestGetStats_BadPublishedAfter(t *testing.T) {
+	ac := newTestController(&mockService{}, newMockCache())
+
+	rr := httptest.NewRecorder()
+	ac.GetStats(rr, httptest.NewRequest(http.MethodGet, "/list?publishedAfter=yesterday", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetFeed_CatalogFilter(t *testing.T) {
+	svc := &mockService{}
+	cache := newMockCache()
+	ac := newTestController(svc, cache)
+
+	ac.GetFeed(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/feed?f=abc&category=sports", nil))
+
+	assert.Equal(t, "sports", svc.feedFilter.Category)
+	_, ok := cache.Get("feed:default:abc:10::sports:")
+	assert.True(t, ok)
+}
+
+func TestGetStats_SortByValue(t *testing.T) {
+	svc := &mockService{statisticData: map[int]*models.StatRecord{
+		1: {Views: 9, Value: &models.ValueSummary{Count: 1, Sum: 5}},
+		2: {Views: 1, Value: &models.ValueSummary{Count: 2, Sum: 30}},
+		3: {Views: 50},
+	}}
+	cache := newMockCache()
+	ac := newTestController(svc, cache)
+
+	rr := httptest.NewRecorder()
+	ac.GetStats(rr, httptest.NewRequest(http.MethodGet, "/list?ch=shop&sort=sum&n=1", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"id":2,"Views":1,"Clicks":0,"Ftr":0,"Value":{"Count":2,"Sum":30,"Min":0,"Max":0}}]`, rr.Body.String())
+	_, ok := cache.Get("list:shop:sort:sum:1")
+	assert.True(t, ok)
+}
+
+func TestGetStats_BadSort(t *testing.T) {
+	ac := newTestController(&mockService{}, newMockCache())
+
+	for _, query := range []string{"sort=revenue", "sort=sum&n=0"} {
+		rr := httptest.NewRecorder()
+		ac.GetStats(rr, httptest.NewRequest(http.MethodGet, "/list?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+	}
+}
+
+// --- Dimension tests ---
+
+func TestGetStats_Dimension(t *testing.T) {
+	svc := &mockService{statisticData: map[int]*models.StatRecord{1: {Views: 3}}}
+	cache := newMockCache()
+	ac := newTestController(svc, cache)
+
+	rr := httptest.NewRecorder()
+	ac.GetStats(rr, httptest.NewRequest(http.MethodGet, "/list?ch=news&dim=device:mobile&category=sports", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, models.DimKey{Name: "device", Value: "mobile"}, svc.dimKey)
+	assert.Equal(t, "sports", svc.listFilter.Category)
+	_, ok := cache.Get("list:news:dim:device:mobile::sports:")
+	assert.True(t, ok)
+}
+
+func TestGetStats_BadDimension(t *testing.T) {
+	ac := newTestController(&mockService{}, newMockCache())
+
+	for _, dim := range []string{"device", ":mobile", "device:"} {
+		t.Run(dim, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ac.GetStats(rr, httptest.NewRequest(http.MethodGet, "/list?dim="+dim, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestGetBreakdown_ReturnsJSON(t *testing.T) {
+	svc := &mockService{breakdown: map[string]*models.StatRecord{"mobile": {Views: 4}}}
+	ac := newTestController(svc, newMockCache())
+
+	rr := httptest.NewRecorder()
+	ac.GetBreakdown(rr, httptest.NewRequest(http.MethodGet, "/breakdown?id=1&dim=device", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "device", svc.breakdownDim)
+	assert.JSONEq(t, `{"mobile":{"Views":4,"Clicks":0,"Ftr":0}}`, rr.Body.String())
+}
+
+func TestGetBreakdown_BadRequest(t *testing.T) {
+	ac := newTestController(&mockService{}, newMockCache())
+
+	for _, path := range []string{"/breakdown?dim=device", "/breakdown?id=1", "/breakdown?id=x&dim=device"} {
+		t.Run(path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ac.GetBreakdown(rr, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+// --- GetExperiment tests ---
+
+func experimentRequest(id, query string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/experiments/"+id+query, nil)
+	req.SetPathValue("id", id)
+	return req
+}
+
+func TestGetExperiment_ReturnsJSON(t *testing.T) {
+	svc := &mockService{experiment: &services.ExperimentReport{
+		ID: "headline", Control: "a", Confidence: 0.95,
+		Variants: []services.VariantReport{{Variant: "a", Impressions: 10, Clicks: 1, CTR: 0.1}},
+	}}
+	cache := newMockCache()
+	ac := newTestController(svc, cache)
+
+	rr := httptest.NewRecorder()
+	ac.GetExperiment(rr, experimentRequest("headline", "?ch=news&item=1,2"))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "headline", svc.experimentID)
+	assert.Equal(t, []int{1, 2}, svc.expItems)
+	assert.JSONEq(t, `{"id":"headline","control":"a","confidence":0.95,"variants":[
+		{"variant":"a","impressions":10,"clicks":1,"ctr":0.1,"ci_low":0,"ci_high":0,"significant":false}]}`, rr.Body.String())
+	assert.Contains(t, cache.data, "experiment:news:headline:1,2")
+}
+
+func TestGetExperiment_NotFoundIsNotCached(t *testing.T) {
+	cache := newMockCache()
+	ac := newTestController(&mockService{}, cache)
+
+	rr := httptest.NewRecorder()
+	ac.GetExperiment(rr, experimentRequest("missing", ""))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Empty(t, cache.data)
+}
+
+func TestGetExperiment_BadItem(t *testing.T) {
+	ac := newTestController(&mockService{}, newMockCache())
+
+	rr := httptest.NewRecorder()
+	ac.GetExperiment(rr, experimentRequest("headline", "?item=1,x"))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+// --- GetFunnel tests ---
+
+func TestGetFunnel_ReturnsJSON(t *testing.T) {
+	svc := &mockService{funnel: &services.FunnelReport{
+		Name: "subscribe", Window: "30m0s",
+		Steps: []services.FunnelStep{{Step: "view", Count: 4, Conversion: 1, Overall: 1}, {Step: "click", Count: 1, Conversion: 0.25, Overall: 0.25}},
+	}}
+	ac := newTestController(svc, newMockCache())
+
+	req := httptest.NewRequest(http.MethodGet, "/funnels/subscribe?item=7", nil)
+	req.SetPathValue("name", "subscribe")
+	rr := httptest.NewRecorder()
+	ac.GetFunnel(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "subscribe", svc.funnelName)
+	assert.Equal(t, []int{7}, svc.funnelItems)
+	assert.JSONEq(t, `{"name":"subscribe","window":"30m0s","steps":[
+		{"step":"view","count":4,"conversion":1,"overall":1},
+		{"step":"click","count":1,"conversion":0.25,"overall":0.25}]}`, rr.Body.String())
+}
+
+func TestGetFunnel_NotFound(t *testing.T) {
+	ac := newTestController(&mockService{}, newMockCache())
+
+	req := httptest.NewRequest(http.MethodGet, "/funnels/missing", nil)
+	req.SetPathValue("name", "missing")
+	rr := httptest.NewRecorder()
+	ac.GetFunnel(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+// --- GetRelated tests ---
+
+func TestGetRising_ReturnsJSON(t *testing.T) {
+	svc := &mockService{rising: []services.RisingItem{{ID: 7, Velocity: 40, Acceleration: 30.5, Rank: 1, RankDelta: 4}}}
+	cache := newMockCache()
+	ac := newTestController(svc, cache)
+
+	rr := httptest.NewRecorder()
+	ac.GetRising(rr, httptest.NewRequest(http.MethodGet, "/rising?ch=news&n=5", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"id":7,"velocity":40,"acceleration":30.5,"rank":1,"rankDelta":4}]`, rr.Body.String())
+	assert.Equal(t, 5, svc.risingN)
+	_, ok := cache.Get("rising:news:5")
+	assert.True(t, ok)
+}
+
+func TestGetRising_BadLimit(t *testing.T) {
+	ac := newTestController(&mockService{}, newMockCache())
+
+	rr := httptest.NewRecorder()
+	ac.GetRising(rr, httptest.NewRequest(http.MethodGet, "/rising?n=-1", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetRelated_ReturnsJSON(t *testing.T) {
+	svc := &mockService{related: []models.RelatedItem{{ID: 2, Weight: 5}, {ID: 3, Weight: 1}}}
+	ac := newTestController(svc, newMockCache())
+
+	req := httptest.NewRequest(http.MethodGet, "/related?ch=news&id=1&n=2", nil)
+	rr := httptest.NewRecorder()
+	ac.GetRelated(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"id":2,"weight":5},{"id":3,"weight":1}]`, rr.Body.String())
+	assert.Equal(t, 2, svc.relatedN)
+}
+
+func TestGetRelated_DefaultAndCappedN(t *testing.T) {
+	svc := &mockService{}
+	ac := newTestController(svc, newMockCache())
+
+	ac.GetRelated(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/related?id=1", nil))
+	assert.Equal(t, defaultResultLimit, svc.relatedN)
+
+	ac.GetRelated(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/related?id=1&n=100000", nil))
+	assert.Equal(t, maxResultLimit, svc.relatedN)
+}
+
+func TestGetRelated_BadRequest(t *testing.T) {
+	ac := newTestController(&mockService{}, newMockCache())
+
+	for _, path := range []string{"/related", "/related?id=abc", "/related?id=1&n=0", "/related?id=1&n=x"} {
+		t.Run(path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ac.GetRelated(rr, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusBadRequest, rr.Code)
+		})
+	}
+}
+
+func TestGetRelated_CachesResult(t *testing.T) {
+	cache := newMockCache()
+	ac := newTestController(&mockService{related: []models.RelatedItem{{ID: 2, Weight: 1}}}, cache)
+
+	ac.GetRelated(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/related?ch=news&id=1&n=5", nil))
+
+	_, ok := cache.Get("related:news:1:5")
+	assert.True(t, ok)
+}
+
+// --- GetFeed tests ---
+
+func TestGetFeed_ReturnsJSON(t *testing.T) {
+	svc := &mockService{feed: []services.FeedItem{{ID: 7, Score: 12.5}}}
+	ac := newTestController(svc, newMockCache())
+
+	req := httptest.NewRequest(http.MethodGet, "/feed?ch=news&f=abc&n=3", nil)
+	rr := httptest.NewRecorder()
+	ac.GetFeed(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"id":7,"score":12.5}]`, rr.Body.String())
+	assert.Equal(t, "abc", svc.feedFp)
+	assert.Equal(t, 3, svc.feedN)
+}
+
+func TestGetFeed_BadLimit(t *testing.T) {
+	ac := newTestController(&mockService{}, newMockCache())
+
+	rr := httptest.NewRecorder()
+	ac.GetFeed(rr, httptest.NewRequest(http.MethodGet, "/feed?f=abc&n=-1", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGetFeed_CachedPerFingerprint(t *testing.T) {
+	cache := newMockCache()
+	ac := newTestController(&mockService{}, cache)
+
+	ac.GetFeed(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/feed?ch=news&f=abc", nil))
+
+	_, ok := cache.Get("feed:news:abc:10")
+	assert.True(t, ok)
+}
+
 // --- Content-Type tests ---
 
 func TestContentType_AllGetEndpoints(t *testing.T) {
@@ -322,6 +897,10 @@ func TestContentType_AllGetEndpoints(t *testing.T) {
 		{"/fingerprints", ac.GetPersonalStats},
 		{"/fingerprint?f=x", ac.GetByFingerprint},
 		{"/channels", ac.GetChannels},
+		{"/related?id=1", ac.GetRelated},
+		{"/feed?f=x", ac.GetFeed},
+		{"/breakdown?id=1&dim=device", ac.GetBreakdown},
+		{"/rising", ac.GetRising},
 	}
 
 	for _, ep := range endpoints {
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	json "github.com/goccy/go-json"
+	"net/http"
+	"ssd/internal/services"
+)
+
+// AlertsController serves the recent anomaly alerts.
+type AlertsController struct {
+	detector services.AnomalyDetectorInterface
+}
+
+func NewAlertsController(detector services.AnomalyDetectorInterface) *AlertsController {
+	return &AlertsController{detector: detector}
+}
+
+// GetAlerts lists the recent alerts of the channel in ch, or of all channels
+// without ch, newest first.
+func (ac *AlertsController) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	alerts := ac.detector.Alerts(r.URL.Query().Get("ch"))
+	if alerts == nil {
+		alerts = []services.Alert{}
+	}
+	gson, err := json.Marshal(alerts)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, gson)
+}
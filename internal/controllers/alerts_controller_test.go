@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"ssd/internal/services"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubDetector struct {
+	alerts  []services.Alert
+	channel string
+}
+
+func (s *stubDetector) Detect(map[string]services.BatchStats, time.Duration) []services.Alert {
+	return nil
+}
+
+func (s *stubDetector) Alerts(channel string) []services.Alert {
+	s.channel = channel
+	return s.alerts
+}
+
+func TestGetAlerts(t *testing.T) {
+	item := 7
+	d := &stubDetector{alerts: []services.Alert{
+		{ID: "news:7:spike:1", Channel: "news", Item: &item, Kind: services.AlertSpike, Value: 500, Baseline: 50, ZScore: 12},
+	}}
+	ac := NewAlertsController(d)
+
+	rr := httptest.NewRecorder()
+	ac.GetAlerts(rr, httptest.NewRequest(http.MethodGet, "/alerts?ch=news", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "news", d.channel)
+	var resp []map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	require.Len(t, resp, 1)
+	assert.Equal(t, "spike", resp[0]["kind"])
+	assert.Equal(t, float64(7), resp[0]["item"])
+}
+
+func TestGetAlerts_AllChannelsEmpty(t *testing.T) {
+	d := &stubDetector{}
+	ac := NewAlertsController(d)
+
+	rr := httptest.NewRecorder()
+	ac.GetAlerts(rr, httptest.NewRequest(http.MethodGet, "/alerts", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "", d.channel)
+	assert.JSONEq(t, "[]", rr.Body.String())
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	json "github.com/goccy/go-json"
+	"net/http"
+	"ssd/internal/models"
+	"ssd/internal/services"
+	"strconv"
+)
+
+const maxCatalogBodySize = 8 << 20 // 8 MB
+
+// CatalogController is the admin API for per-channel item metadata.
+type CatalogController struct {
+	service services.StatisticServiceInterface
+}
+
+// catalogUpdate is the body of POST /catalog/update.
+type catalogUpdate struct {
+	Items  map[int]*models.CatalogItem `json:"items"`
+	Delete []int                       `json:"delete"`
+}
+
+func NewCatalogController(service services.StatisticServiceInterface) *CatalogController {
+	return &CatalogController{service: service}
+}
+
+// GetItem serves the catalog entry of one item.
+func (cc *CatalogController) GetItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	item, ok := cc.service.GetCatalogItem(getChannel(r), id)
+	if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	gson, err := json.Marshal(item)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, gson)
+}
+
+// Update upserts and deletes catalog entries of a channel.
+func (cc *CatalogController) Update(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxCatalogBodySize)
+	var payload catalogUpdate
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	cc.service.UpdateCatalog(getChannel(r), payload.Items, payload.Delete)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"ssd/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogGetItem_ReturnsJSON(t *testing.T) {
+	svc := &mockService{catalog: map[int]*models.CatalogItem{1: {Category: "sports", Tags: []string{"a"}}}}
+	cc := NewCatalogController(svc)
+
+	rr := httptest.NewRecorder()
+	cc.GetItem(rr, httptest.NewRequest(http.MethodGet, "/catalog?id=1", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"category":"sports","tags":["a"]}`, rr.Body.String())
+}
+
+func TestCatalogGetItem_NotFound(t *testing.T) {
+	cc := NewCatalogController(&mockService{})
+
+	rr := httptest.NewRecorder()
+	cc.GetItem(rr, httptest.NewRequest(http.MethodGet, "/catalog?id=1", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestCatalogGetItem_BadID(t *testing.T) {
+	cc := NewCatalogController(&mockService{})
+
+	rr := httptest.NewRecorder()
+	cc.GetItem(rr, httptest.NewRequest(http.MethodGet, "/catalog?id=x", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestCatalogUpdate_UpsertsAndDeletes(t *testing.T) {
+	svc := &mockService{}
+	cc := NewCatalogController(svc)
+
+	body := `{"items":{"1":{"category":"sports","published_at":"2026-05-01T10:00:00Z"}},"delete":[2,3]}`
+	rr := httptest.NewRecorder()
+	cc.Update(rr, httptest.NewRequest(http.MethodPost, "/catalog/update?ch=news", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	require.Contains(t, svc.catalogUpsert, 1)
+	assert.Equal(t, "sports", svc.catalogUpsert[1].Category)
+	assert.Equal(t, 2026, svc.catalogUpsert[1].PublishedAt.Year())
+	assert.Equal(t, []int{2, 3}, svc.catalogRemove)
+}
+
+func TestCatalogUpdate_BadJSON(t *testing.T) {
+	cc := NewCatalogController(&mockService{})
+
+	rr := httptest.NewRecorder()
+	cc.Update(rr, httptest.NewRequest(http.MethodPost, "/catalog/update", strings.NewReader(`{"items":`)))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
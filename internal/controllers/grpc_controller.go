@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"ssd/internal/models"
+	"ssd/internal/pb"
+	"ssd/internal/services"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GrpcController serves the Stats gRPC service from the same statistic
+// service as the HTTP API.
+type GrpcController struct {
+	pb.UnimplementedStatsServer
+	service services.StatisticServiceInterface
+	ingest  services.IngestServiceInterface
+}
+
+func NewGrpcController(service services.StatisticServiceInterface, ingest services.IngestServiceInterface) *GrpcController {
+	return &GrpcController{service: service, ingest: ingest}
+}
+
+// Ingest records one event. Like dropped HTTP events, events dropped as bot
+// traffic are still counted as accepted.
+func (gc *GrpcController) Ingest(ctx context.Context, event *pb.Event) (*pb.IngestResponse, error) {
+	gc.ingest.Ingest(grpcSource(ctx), eventStats(event))
+	return &pb.IngestResponse{Accepted: 1}, nil
+}
+
+// IngestStream records events as they arrive and answers with their count
+// once the client closes the stream.
+func (gc *GrpcController) IngestStream(stream grpc.ClientStreamingServer[pb.Event, pb.IngestResponse]) error {
+	src := grpcSource(stream.Context())
+	var accepted int64
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&pb.IngestResponse{Accepted: accepted})
+		}
+		if err != nil {
+			return err
+		}
+		gc.ingest.Ingest(src, eventStats(event))
+		accepted++
+	}
+}
+
+// grpcSource describes the client of a call by its peer address and the
+// user-agent and x-forwarded-for metadata.
+func grpcSource(ctx context.Context) services.Source {
+	var src services.Source
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		src.RemoteAddr = p.Addr.String()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	src.UserAgent = strings.Join(md.Get("user-agent"), " ")
+	src.ForwardedFor = md.Get("x-forwarded-for")
+	return src
+}
+
+func eventStats(event *pb.Event) *models.InputStats {
+	payload := &models.InputStats{
+		Fingerprint: event.GetFingerprint(),
+		Views:       event.GetViews(),
+		Clicks:      event.GetClicks(),
+		Channel:     event.GetChannel(),
+		Dims:        event.GetDims(),
+		Experiments: event.GetExperiments(),
+		Values:      event.GetValues(),
+	}
+	if len(event.GetEvents()) > 0 {
+		payload.Events = make(map[string][]string, len(event.GetEvents()))
+		for kind, ids := range event.GetEvents() {
+			payload.Events[kind] = ids.GetIds()
+		}
+	}
+	if len(event.GetPositions()) > 0 {
+		payload.Positions = make(map[string]int, len(event.GetPositions()))
+		for id, pos := range event.GetPositions() {
+			payload.Positions[id] = int(pos)
+		}
+	}
+	return payload
+}
+
+func (gc *GrpcController) List(_ context.Context, req *pb.ListRequest) (*pb.Records, error) {
+	return &pb.Records{Records: sortedRecords(gc.records(grpcChannel(req.GetChannel()), req.GetFilter()))}, nil
+}
+
+// Top ranks like /list with sort; n defaults to 10 and is capped at 100.
+func (gc *GrpcController) Top(_ context.Context, req *pb.TopRequest) (*pb.Records, error) {
+	by := req.GetSort()
+	if by == "" {
+		by = "views"
+	}
+	if !services.ValidSortKey(by) || req.GetN() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid sort or n")
+	}
+	n := defaultResultLimit
+	if req.GetN() > 0 {
+		n = min(int(req.GetN()), maxResultLimit)
+	}
+	top := services.TopRecords(gc.records(grpcChannel(req.GetChannel()), req.GetFilter()), by, n)
+	out := make([]*pb.Record, len(top))
+	for i, r := range top {
+		out[i] = toRecord(r.ID, r.StatRecord)
+	}
+	return &pb.Records{Records: out}, nil
+}
+
+func (gc *GrpcController) Fingerprint(_ context.Context, req *pb.FingerprintRequest) (*pb.Records, error) {
+	return &pb.Records{Records: sortedRecords(gc.service.GetByFingerprint(grpcChannel(req.GetChannel()), req.GetFingerprint()))}, nil
+}
+
+func (gc *GrpcController) Channels(context.Context, *pb.ChannelsRequest) (*pb.ChannelsResponse, error) {
+	return &pb.ChannelsResponse{Channels: gc.service.GetChannels()}, nil
+}
+
+func (gc *GrpcController) records(channel string, filter *pb.CatalogFilter) map[int]*models.StatRecord {
+	catalog := models.CatalogFilter{Tag: filter.GetTag(), Category: filter.GetCategory()}
+	if sec := filter.GetPublishedAfter(); sec != 0 {
+		catalog.PublishedAfter = time.Unix(sec, 0).UTC()
+	}
+	if catalog.IsEmpty() {
+		return gc.service.GetStatistic(channel)
+	}
+	return gc.service.GetFilteredStatistic(channel, catalog)
+}
+
+func grpcChannel(channel string) string {
+	if channel == "" {
+		return services.DefaultChannel
+	}
+	return channel
+}
+
+// sortedRecords converts a record map into a list ordered by ID.
+func sortedRecords(records map[int]*models.StatRecord) []*pb.Record {
+	ids := make([]int, 0, len(records))
+	for id := range records {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	out := make([]*pb.Record, len(ids))
+	for i, id := range ids {
+		out[i] = toRecord(id, records[id])
+	}
+	return out
+}
+
+func toRecord(id int, r *models.StatRecord) *pb.Record {
+	rec := &pb.Record{
+		Id:           int64(id),
+		Views:        int64(r.Views),
+		Clicks:       int64(r.Clicks),
+		Ftr:          int64(r.Ftr),
+		Exposure:     r.Exposure,
+		CorrectedCtr: r.CorrectedCtr,
+	}
+	if r.Value != nil {
+		rec.Value = &pb.ValueSummary{Count: int64(r.Value.Count), Sum: r.Value.Sum, Min: r.Value.Min, Max: r.Value.Max}
+	}
+	return rec
+}
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+	"net"
+	"ssd/internal/models"
+	"ssd/internal/pb"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrpcIngest_MapsEvent(t *testing.T) {
+	svc := &mockService{}
+	gc := NewGrpcController(svc, newTestIngest(svc, &mockUserAgent{}))
+
+	resp, err := gc.Ingest(context.Background(), &pb.Event{
+		Views:     []string{"1", "2"},
+		Clicks:    []string{"2"},
+		Events:    map[string]*pb.ItemIDs{"subscribe": {Ids: []string{"2"}}},
+		Positions: map[string]int32{"1": 1, "2": 3},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.GetAccepted())
+	require.Len(t, svc.addCalls, 1)
+	got := svc.addCalls[0]
+	assert.Equal(t, "default", got.Channel)
+	assert.Equal(t, []string{"1", "2"}, got.Views)
+	assert.Equal(t, map[string][]string{"subscribe": {"2"}}, got.Events)
+	assert.Equal(t, map[string]int{"1": 1, "2": 3}, got.Positions)
+}
+
+func TestGrpcIngest_Enrichment(t *testing.T) {
+	svc := &mockService{}
+	gc := NewGrpcController(svc, newTestIngest(svc, &mockUserAgent{drop: "bot"}))
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(81, 2, 3, 4), Port: 5555}})
+
+	_, err := gc.Ingest(metadata.NewIncomingContext(ctx, metadata.Pairs("user-agent", "browser")), &pb.Event{Views: []string{"1"}})
+	require.NoError(t, err)
+	require.Len(t, svc.addCalls, 1)
+	assert.Equal(t, map[string]string{"ua": "browser", "country": "DE"}, svc.addCalls[0].Dims)
+
+	resp, err := gc.Ingest(metadata.NewIncomingContext(ctx, metadata.Pairs("user-agent", "bot")), &pb.Event{Views: []string{"1"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), resp.GetAccepted(), "dropped events are acknowledged")
+	assert.Len(t, svc.addCalls, 1)
+}
+
+func TestGrpcList_SortedByID(t *testing.T) {
+	svc := &mockService{statisticData: map[int]*models.StatRecord{
+		9: {Views: 1},
+		3: {Views: 5, Clicks: 2, Value: &models.ValueSummary{Count: 1, Sum: 4.5, Min: 4.5, Max: 4.5}},
+	}}
+	gc := NewGrpcController(svc, newTestIngest(svc, &mockUserAgent{}))
+
+	resp, err := gc.List(context.Background(), &pb.ListRequest{Channel: "news"})
+
+	require.NoError(t, err)
+	require.Len(t, resp.GetRecords(), 2)
+	assert.Equal(t, int64(3), resp.GetRecords()[0].GetId())
+	assert.Equal(t, 4.5, resp.GetRecords()[0].GetValue().GetSum())
+	assert.Equal(t, int64(9), resp.GetRecords()[1].GetId())
+	assert.Nil(t, resp.GetRecords()[1].GetValue())
+}
+
+func TestGrpcTop(t *testing.T) {
+	svc := &mockService{statisticData: map[int]*models.StatRecord{1: {Views: 5, Clicks: 1}, 2: {Views: 9}, 3: {Views: 2, Clicks: 2}}}
+	gc := NewGrpcController(svc, newTestIngest(svc, &mockUserAgent{}))
+
+	resp, err := gc.Top(context.Background(), &pb.TopRequest{N: 2})
+	require.NoError(t, err)
+	require.Len(t, resp.GetRecords(), 2)
+	assert.Equal(t, int64(2), resp.GetRecords()[0].GetId())
+	assert.Equal(t, int64(1), resp.GetRecords()[1].GetId())
+
+	resp, err = gc.Top(context.Background(), &pb.TopRequest{Sort: "clicks", N: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), resp.GetRecords()[0].GetId())
+
+	_, err = gc.Top(context.Background(), &pb.TopRequest{Sort: "bogus"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = gc.Top(context.Background(), &pb.TopRequest{N: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGrpcFingerprintAndChannels(t *testing.T) {
+	svc := &mockService{
+		fpData:       map[int]*models.StatRecord{7: {Views: 3}},
+		channelsList: []string{"default", "news"},
+	}
+	gc := NewGrpcController(svc, newTestIngest(svc, &mockUserAgent{}))
+
+	records, err := gc.Fingerprint(context.Background(), &pb.FingerprintRequest{Fingerprint: "fp1"})
+	require.NoError(t, err)
+	require.Len(t, records.GetRecords(), 1)
+	assert.Equal(t, int64(3), records.GetRecords()[0].GetViews())
+
+	channels, err := gc.Channels(context.Background(), &pb.ChannelsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "news"}, channels.GetChannels())
+}
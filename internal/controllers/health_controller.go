@@ -14,11 +14,17 @@ type HealthController struct {
 }
 
 type healthResponse struct {
-	Status        string  `json:"status"`
-	Uptime        string  `json:"uptime"`
-	UptimeSeconds float64 `json:"uptime_seconds"`
-	BufferSize    int     `json:"buffer_size"`
-	Channels      int     `json:"channels"`
+	Status              string  `json:"status"`
+	Uptime              string  `json:"uptime"`
+	UptimeSeconds       float64 `json:"uptime_seconds"`
+	BufferSize          int     `json:"buffer_size"`
+	Channels            int     `json:"channels"`
+	MemoryBytes         int64   `json:"memory_bytes"`
+	MemoryFixedBytes    int64   `json:"memory_fixed_bytes"`
+	MemoryViewBytes     int64   `json:"memory_view_bytes"`
+	MemoryMaxBytes      int64   `json:"memory_max_bytes"`
+	EvictedItems        int64   `json:"evicted_items"`
+	EvictedFingerprints int64   `json:"evicted_fingerprints"`
 }
 
 func (hc *HealthController) Health(w http.ResponseWriter, r *http.Request) {
@@ -28,12 +34,19 @@ func (hc *HealthController) Health(w http.ResponseWriter, r *http.Request) {
 	}
 
 	uptime := time.Since(hc.startTime)
+	mem := hc.service.GetMemoryStats()
 	resp := healthResponse{
-		Status:        "ok",
-		Uptime:        formatDuration(uptime),
-		UptimeSeconds: uptime.Seconds(),
-		BufferSize:    hc.service.GetBufferSize(),
-		Channels:      len(hc.service.GetChannels()),
+		Status:              "ok",
+		Uptime:              formatDuration(uptime),
+		UptimeSeconds:       uptime.Seconds(),
+		BufferSize:          hc.service.GetBufferSize(),
+		Channels:            len(hc.service.GetChannels()),
+		MemoryBytes:         mem.UsedBytes,
+		MemoryFixedBytes:    mem.FixedBytes,
+		MemoryViewBytes:     mem.ViewBytes,
+		MemoryMaxBytes:      mem.MaxBytes,
+		EvictedItems:        mem.EvictedItems,
+		EvictedFingerprints: mem.EvictedFingerprints,
 	}
 
 	gson, err := json.Marshal(resp)
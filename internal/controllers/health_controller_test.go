@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"ssd/internal/services"
 	"testing"
 	"time"
 
@@ -73,3 +74,28 @@ func TestFormatDuration(t *testing.T) {
 		})
 	}
 }
+
+func TestHealth_MemoryReported(t *testing.T) {
+	svc := &mockService{memoryStats: services.MemoryStats{
+		UsedBytes:           2048,
+		FixedBytes:          512,
+		ViewBytes:           1024,
+		MaxBytes:            4096,
+		EvictedItems:        3,
+		EvictedFingerprints: 7,
+	}}
+	hc := NewHealthController(svc)
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rr := httptest.NewRecorder()
+	hc.Health(rr, req)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+	assert.Equal(t, float64(2048), resp["memory_bytes"])
+	assert.Equal(t, float64(512), resp["memory_fixed_bytes"])
+	assert.Equal(t, float64(1024), resp["memory_view_bytes"])
+	assert.Equal(t, float64(4096), resp["memory_max_bytes"])
+	assert.Equal(t, float64(3), resp["evicted_items"])
+	assert.Equal(t, float64(7), resp["evicted_fingerprints"])
+}
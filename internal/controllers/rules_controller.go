@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	json "github.com/goccy/go-json"
+	"net/http"
+	"ssd/internal/services"
+)
+
+const maxRulesBodySize = 1 << 20 // 1 MB
+
+// RulesController is the admin API for threshold rules.
+type RulesController struct {
+	engine services.RuleEngineInterface
+}
+
+// rulesUpdate is the body of POST /rules/update.
+type rulesUpdate struct {
+	Rules  []services.Rule `json:"rules"`
+	Delete []string        `json:"delete"`
+}
+
+func NewRulesController(engine services.RuleEngineInterface) *RulesController {
+	return &RulesController{engine: engine}
+}
+
+// GetRules lists all rules sorted by name.
+func (rc *RulesController) GetRules(w http.ResponseWriter, r *http.Request) {
+	gson, err := json.Marshal(rc.engine.Rules())
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, gson)
+}
+
+// Update deletes and then upserts rules in one step. An invalid rule, or
+// more rules than the engine keeps, rejects the whole update.
+func (rc *RulesController) Update(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRulesBodySize)
+	var payload rulesUpdate
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	if err := rc.engine.Update(payload.Rules, payload.Delete); err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRulesController() (*RulesController, services.RuleEngineInterface) {
+	engine := services.NewRuleEngine(&structures.Config{Rules: []structures.RuleConfig{
+		{Name: "disk", Metric: services.RulePersistFailures, Threshold: 2},
+	}}, &mockService{})
+	return NewRulesController(engine), engine
+}
+
+func TestGetRules(t *testing.T) {
+	rc, _ := newTestRulesController()
+
+	rr := httptest.NewRecorder()
+	rc.GetRules(rr, httptest.NewRequest(http.MethodGet, "/rules", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `[{"name":"disk","metric":"persist_failures","threshold":2}]`, rr.Body.String())
+}
+
+func TestRulesUpdate_UpsertsAndDeletes(t *testing.T) {
+	rc, engine := newTestRulesController()
+
+	body := `{"rules":[{"name":"hit","metric":"item_views","channel":"news","item":7,"threshold":10000}],"delete":["disk"]}`
+	rr := httptest.NewRecorder()
+	rc.Update(rr, httptest.NewRequest(http.MethodPost, "/rules/update", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rules := engine.Rules()
+	require.Len(t, rules, 1)
+	item := 7
+	assert.Equal(t, services.Rule{Name: "hit", Metric: services.RuleItemViews, Channel: "news", Item: &item, Threshold: 10000}, rules[0])
+}
+
+func TestRulesUpdate_InvalidRuleChangesNothing(t *testing.T) {
+	rc, engine := newTestRulesController()
+
+	body := `{"rules":[{"name":"ok","metric":"batch_events","threshold":5},{"name":"bad","metric":"cpu","threshold":1}],"delete":["disk"]}`
+	rr := httptest.NewRecorder()
+	rc.Update(rr, httptest.NewRequest(http.MethodPost, "/rules/update", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Len(t, engine.Rules(), 1)
+	assert.Equal(t, "disk", engine.Rules()[0].Name)
+}
+
+func TestRulesUpdate_OverLimitChangesNothing(t *testing.T) {
+	rc, engine := newTestRulesController()
+
+	var rules []string
+	for i := 0; i < 1000; i++ {
+		rules = append(rules, `{"name":"r`+strconv.Itoa(i)+`","metric":"batch_events","threshold":5}`)
+	}
+	body := `{"rules":[` + strings.Join(rules, ",") + `]}`
+	rr := httptest.NewRecorder()
+	rc.Update(rr, httptest.NewRequest(http.MethodPost, "/rules/update", strings.NewReader(body)))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Len(t, engine.Rules(), 1)
+	assert.Equal(t, "disk", engine.Rules()[0].Name)
+}
+
+func TestRulesUpdate_BadJSON(t *testing.T) {
+	rc, _ := newTestRulesController()
+
+	rr := httptest.NewRecorder()
+	rc.Update(rr, httptest.NewRequest(http.MethodPost, "/rules/update", strings.NewReader("{")))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"errors"
+	json "github.com/goccy/go-json"
+	"net/http"
+	"ssd/internal/models"
+	"ssd/internal/providers"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultSocketConnections  = 1000
+	defaultSocketPingInterval = 30 * time.Second
+	socketWriteWait           = 10 * time.Second
+)
+
+// socketFrame is a trending update sent to a WebSocket client.
+type socketFrame struct {
+	Event string          `json:"event"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// SocketController lets a client send events and receive its channel's
+// trending updates over one WebSocket connection.
+type SocketController struct {
+	api      *ApiController
+	stream   providers.StreamProviderInterface
+	conf     structures.WebSocketConfig
+	upgrader websocket.Upgrader
+	conns    atomic.Int64
+	closing  chan struct{}
+	once     sync.Once
+}
+
+func NewSocketController(conf *structures.Config, api *ApiController, stream providers.StreamProviderInterface) *SocketController {
+	wsConf := conf.WebSocket
+	if wsConf.MaxConnections <= 0 {
+		wsConf.MaxConnections = defaultSocketConnections
+	}
+	if wsConf.PingInterval <= 0 {
+		wsConf.PingInterval = defaultSocketPingInterval
+	}
+	sc := &SocketController{api: api, stream: stream, conf: wsConf, closing: make(chan struct{})}
+	// Without cors.enabled gorilla's same-origin check applies; with it the
+	// connection's channel must allow the origin like a preflight.
+	if conf.CORS.Enabled {
+		sc.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || api.cors.Allowed(origin, getChannel(r))
+		}
+	}
+	return sc
+}
+
+// Socket upgrades the request to a WebSocket. Every text message is an event
+// in the body format of POST /, recorded like one; events without a channel
+// go to the ch of the connection. With subscribe=top|changes the client also
+// receives the stream events of that channel, narrowed by n, item and the
+// catalog filters as on /stream.
+func (sc *SocketController) Socket(w http.ResponseWriter, r *http.Request) {
+	if !sc.conf.Enabled {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	channel := getChannel(r)
+
+	var events <-chan providers.StreamEvent
+	if mode := r.URL.Query().Get("subscribe"); mode != "" {
+		filter, ok := getStreamFilter(r, mode)
+		if !ok {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		sub, err := sc.stream.Subscribe(channel, filter)
+		if errors.Is(err, providers.ErrStreamFull) {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		}
+		defer sc.stream.Unsubscribe(sub)
+		events = sub.C
+	}
+
+	if sc.conns.Add(1) > int64(sc.conf.MaxConnections) {
+		sc.conns.Add(-1)
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	defer sc.conns.Add(-1)
+
+	conn, err := sc.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return // the upgrader has answered the request
+	}
+	defer conn.Close()
+
+	done := make(chan struct{})
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		sc.write(conn, events, done)
+	}()
+	sc.read(conn, r, channel)
+	close(done)
+	<-written
+}
+
+// read records incoming events until the connection fails, a message is not
+// a valid event or its channel does not allow the page's origin. A client
+// that stops answering pings times out.
+func (sc *SocketController) read(conn *websocket.Conn, r *http.Request, channel string) {
+	timeout := 2 * sc.conf.PingInterval
+	conn.SetReadLimit(maxRequestBodySize)
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(timeout))
+	})
+	for {
+		kind, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(timeout))
+		if kind != websocket.TextMessage {
+			closeSocket(conn, websocket.CloseUnsupportedData, "text messages only")
+			return
+		}
+		var payload models.InputStats
+		if json.Unmarshal(data, &payload) != nil {
+			closeSocket(conn, websocket.CloseInvalidFramePayloadData, "invalid event")
+			return
+		}
+		if payload.Channel == "" {
+			payload.Channel = channel
+		}
+		if !sc.api.originAllowed(r, &payload) {
+			closeSocket(conn, websocket.ClosePolicyViolation, "origin not allowed for channel")
+			return
+		}
+		sc.api.ingest.Ingest(services.RequestSource(r), &payload)
+	}
+}
+
+// write sends stream events and pings until done is closed. When the
+// subscription ends, a write fails or the server shuts down it closes the
+// connection, which also ends read.
+func (sc *SocketController) write(conn *websocket.Conn, events <-chan providers.StreamEvent, done <-chan struct{}) {
+	ping := time.NewTicker(sc.conf.PingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-sc.closing:
+			closeSocket(conn, websocket.CloseGoingAway, "server shutting down")
+			conn.Close()
+			return
+		case ev, open := <-events:
+			if !open {
+				closeSocket(conn, websocket.CloseGoingAway, "stream closed")
+				conn.Close()
+				return
+			}
+			frame, err := json.Marshal(socketFrame{Event: ev.Event, Data: ev.Data})
+			if err != nil {
+				continue
+			}
+			_ = conn.SetWriteDeadline(time.Now().Add(socketWriteWait))
+			if conn.WriteMessage(websocket.TextMessage, frame) != nil {
+				conn.Close()
+				return
+			}
+		case <-ping.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(socketWriteWait)) != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// Close disconnects every client. The HTTP server does not track hijacked
+// connections, so it is called when the server shuts down.
+func (sc *SocketController) Close() {
+	sc.once.Do(func() { close(sc.closing) })
+}
+
+func closeSocket(conn *websocket.Conn, code int, text string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(socketWriteWait))
+}
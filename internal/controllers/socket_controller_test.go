@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	json "github.com/goccy/go-json"
+	"net/http"
+	"net/http/httptest"
+	"ssd/internal/providers"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSocketServer(t *testing.T, conf structures.WebSocketConfig) (string, *SocketController, services.StatisticServiceInterface, providers.StreamProviderInterface) {
+	return newCORSSocketServer(t, conf, structures.CORSConfig{})
+}
+
+func newCORSSocketServer(t *testing.T, conf structures.WebSocketConfig, cors structures.CORSConfig) (string, *SocketController, services.StatisticServiceInterface, providers.StreamProviderInterface) {
+	cfg := &structures.Config{WebSocket: conf, CORS: cors, Stream: structures.StreamConfig{Enabled: true}}
+	svc := services.NewStatisticService(cfg)
+	metrics := providers.NewMetricsProvider(cfg, svc)
+	stream := providers.NewStreamProvider(cfg, svc, metrics, &mockLogger{})
+	ac := NewApiController(&mockLogger{}, svc, newMockCache(), newTestIngest(svc, &mockUserAgent{}), providers.NewCORSPolicy(cfg))
+	sc := NewSocketController(cfg, ac, stream)
+	// The metrics middleware wraps the writer like in the app, so upgrades
+	// must get through it.
+	srv := httptest.NewServer(providers.MetricsMiddleware(metrics, http.HandlerFunc(sc.Socket)))
+	t.Cleanup(func() {
+		sc.Close()
+		stream.Close()
+		srv.Close()
+	})
+	return "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws", sc, svc, stream
+}
+
+func readFrame(t *testing.T, conn *websocket.Conn) socketFrame {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	var frame socketFrame
+	require.NoError(t, conn.ReadJSON(&frame))
+	return frame
+}
+
+func TestSocket_IngestsAndSubscribes(t *testing.T) {
+	url, _, svc, stream := newTestSocketServer(t, structures.WebSocketConfig{Enabled: true})
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?subscribe=changes", nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	frame := readFrame(t, conn)
+	assert.Equal(t, providers.StreamTop, frame.Event)
+	assert.JSONEq(t, `[]`, string(frame.Data))
+
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"v":["7","7"]}`)))
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"v":["8"],"ch":"blog"}`)))
+	require.Eventually(t, func() bool { return svc.GetBufferSize() == 2 }, time.Second, 5*time.Millisecond)
+	svc.AggregateStats()
+	stream.Publish()
+
+	frame = readFrame(t, conn)
+	assert.Equal(t, providers.StreamChanges, frame.Event)
+	var records []services.RankedRecord
+	require.NoError(t, json.Unmarshal(frame.Data, &records))
+	require.Len(t, records, 1)
+	assert.Equal(t, 7, records[0].ID)
+	assert.Equal(t, 2, records[0].Views)
+	assert.Len(t, svc.GetStatistic("blog"), 1, "the event channel overrides the connection's")
+}
+
+func TestSocket_InvalidEventClosesConnection(t *testing.T) {
+	url, _, svc, _ := newTestSocketServer(t, structures.WebSocketConfig{Enabled: true})
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"v":`)))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseInvalidFramePayloadData), err)
+	assert.Equal(t, 0, svc.GetBufferSize())
+}
+
+func TestSocket_CloseDisconnectsClients(t *testing.T) {
+	url, sc, _, _ := newTestSocketServer(t, structures.WebSocketConfig{Enabled: true})
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+	sc.Close()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), err)
+}
+
+func TestSocket_Refused(t *testing.T) {
+	url, _, _, _ := newCORSSocketServer(t, structures.WebSocketConfig{Enabled: true, MaxConnections: 1}, structures.CORSConfig{Enabled: true, AllowedOrigins: []string{"https://app.example"}})
+
+	origin := http.Header{"Origin": {"https://app.example"}}
+	conn, _, err := websocket.DefaultDialer.Dial(url, origin)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, origin)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "over maxConnections")
+
+	_, resp, err = websocket.DefaultDialer.Dial(url+"?subscribe=all", origin)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	conn.Close()
+	require.Eventually(t, func() bool {
+		_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"https://evil.example"}})
+		return err != nil && resp != nil && resp.StatusCode == http.StatusForbidden
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSocket_OriginCheckedPerChannel(t *testing.T) {
+	url, _, svc, _ := newCORSSocketServer(t, structures.WebSocketConfig{Enabled: true}, structures.CORSConfig{
+		Enabled:        true,
+		AllowedOrigins: []string{"https://app.example"},
+		Channels:       map[string][]string{"shop": {"https://shop.example"}},
+	})
+
+	_, resp, err := websocket.DefaultDialer.Dial(url+"?ch=shop", http.Header{"Origin": {"https://app.example"}})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "the connection's channel is checked at the upgrade")
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?ch=news", http.Header{"Origin": {"https://app.example"}})
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"v":["1"]}`)))
+	require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"v":["2"],"ch":"shop"}`)))
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.ClosePolicyViolation), err)
+	assert.Equal(t, 1, svc.GetBufferSize(), "events for channels not allowing the origin are not recorded")
+}
+
+func TestSocket_Disabled(t *testing.T) {
+	url, _, _, _ := newTestSocketServer(t, structures.WebSocketConfig{})
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"ssd/internal/providers"
+	"time"
+)
+
+var heartbeatFrame = []byte(": ping\n\n")
+
+// StreamController serves the live trending stream over Server-Sent Events.
+type StreamController struct {
+	stream providers.StreamProviderInterface
+}
+
+func NewStreamController(stream providers.StreamProviderInterface) *StreamController {
+	return &StreamController{stream: stream}
+}
+
+// Stream pushes the channel's top records, or with mode=changes the records
+// changed by the last aggregation, after every aggregation. n, item and the
+// catalog filters narrow the records as on /list. A comment line is sent
+// every heartbeat so proxies keep the connection open.
+func (sc *StreamController) Stream(w http.ResponseWriter, r *http.Request) {
+	filter, ok := getStreamFilter(r, r.URL.Query().Get("mode"))
+	if !ok {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	sub, err := sc.stream.Subscribe(getChannel(r), filter)
+	if errors.Is(err, providers.ErrStreamFull) {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+	defer sc.stream.Unsubscribe(sub)
+
+	// The server's WriteTimeout would cut the stream off; the deadline is
+	// lifted for this response only.
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if rc.Flush() != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(sc.stream.Heartbeat())
+	defer heartbeat.Stop()
+	for {
+		var frame []byte
+		select {
+		case <-r.Context().Done():
+			return
+		case ev, open := <-sub.C:
+			if !open {
+				return
+			}
+			frame = sseFrame(ev)
+		case <-heartbeat.C:
+			frame = heartbeatFrame
+		}
+		if _, err := w.Write(frame); err != nil {
+			return
+		}
+		if rc.Flush() != nil {
+			return
+		}
+	}
+}
+
+// getStreamFilter parses the n, item and catalog query parameters of a
+// stream subscription in the given mode, which defaults to top.
+func getStreamFilter(r *http.Request, mode string) (providers.StreamFilter, bool) {
+	if mode == "" {
+		mode = providers.StreamTop
+	}
+	if mode != providers.StreamTop && mode != providers.StreamChanges {
+		return providers.StreamFilter{}, false
+	}
+	n, ok := getLimit(r)
+	if !ok {
+		return providers.StreamFilter{}, false
+	}
+	items, ok := getItemIDs(r)
+	if !ok {
+		return providers.StreamFilter{}, false
+	}
+	catalog, ok := getCatalogFilter(r)
+	if !ok {
+		return providers.StreamFilter{}, false
+	}
+	return providers.StreamFilter{Mode: mode, N: n, Items: items, Catalog: catalog}, true
+}
+
+func sseFrame(ev providers.StreamEvent) []byte {
+	frame := make([]byte, 0, len(ev.Event)+len(ev.Data)+16)
+	frame = append(frame, "event: "...)
+	frame = append(frame, ev.Event...)
+	frame = append(frame, "\ndata: "...)
+	frame = append(frame, ev.Data...)
+	return append(frame, "\n\n"...)
+}
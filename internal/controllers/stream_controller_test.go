@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"ssd/internal/models"
+	"ssd/internal/providers"
+	"ssd/internal/structures"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStreamServer(t *testing.T, conf structures.StreamConfig) (*httptest.Server, providers.StreamProviderInterface) {
+	svc := &mockService{statisticData: map[int]*models.StatRecord{1: {Views: 5}, 2: {Views: 9}}, channelsList: []string{"news"}}
+	cfg := &structures.Config{Stream: conf}
+	stream := providers.NewStreamProvider(cfg, svc, providers.NewMetricsProvider(cfg, svc), &mockLogger{})
+	srv := httptest.NewServer(http.HandlerFunc(NewStreamController(stream).Stream))
+	t.Cleanup(func() {
+		stream.Close()
+		srv.Close()
+	})
+	return srv, stream
+}
+
+// readLine returns the next non-empty line of the stream.
+func readLine(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			return line
+		}
+	}
+}
+
+func TestStream_SendsEventsAndHeartbeats(t *testing.T) {
+	srv, stream := newTestStreamServer(t, structures.StreamConfig{Enabled: true, Heartbeat: 20 * time.Millisecond})
+
+	resp, err := http.Get(srv.URL + "/stream?ch=news&n=1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	r := bufio.NewReader(resp.Body)
+	assert.Equal(t, "event: top", readLine(t, r))
+	assert.Equal(t, `data: [{"id":2,"Views":9,"Clicks":0,"Ftr":0}]`, readLine(t, r))
+	assert.Equal(t, ": ping", readLine(t, r))
+
+	stream.Close()
+	for {
+		if _, err := r.ReadString('\n'); err != nil {
+			break
+		}
+	}
+}
+
+func TestStream_BadRequest(t *testing.T) {
+	srv, _ := newTestStreamServer(t, structures.StreamConfig{Enabled: true})
+
+	for _, query := range []string{"mode=all", "n=0", "item=x", "publishedAfter=yesterday"} {
+		resp, err := http.Get(srv.URL + "/stream?" + query)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
+
+func TestStream_Full(t *testing.T) {
+	srv, stream := newTestStreamServer(t, structures.StreamConfig{Enabled: true, MaxSubscribers: 1})
+	_, err := stream.Subscribe("news", providers.StreamFilter{Mode: providers.StreamTop, N: 10})
+	require.NoError(t, err)
+
+	resp, err := http.Get(srv.URL + "/stream")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestStream_Disabled(t *testing.T) {
+	srv, _ := newTestStreamServer(t, structures.StreamConfig{})
+
+	resp, err := http.Get(srv.URL + "/stream")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -20,14 +20,31 @@ func InitApp(cfg *structures.CliFlags) (*internal.App, error) {
 		providers.NewLogProvider,
 		providers.NewMetricsProvider,
 		providers.NewInstrumentedCacheProvider,
+		providers.NewUserAgentProvider,
+		providers.NewGeoIPProvider,
+		providers.NewCORSPolicy,
+		providers.NewEnrichers,
+		providers.NewWebhookProvider,
+		providers.NewStreamProvider,
+		providers.NewUDPListener,
 
 		statistic.NewZstdCompressor,
 		services.NewStatisticService,
+		services.NewIngestService,
+		services.NewAnomalyDetector,
+		services.NewRuleEngine,
 		statistic.NewFileManager,
 		statistic.NewScheduler,
 		controllers.NewApiController,
 		controllers.NewHealthController,
+		controllers.NewCatalogController,
+		controllers.NewAlertsController,
+		controllers.NewRulesController,
+		controllers.NewStreamController,
+		controllers.NewSocketController,
+		controllers.NewGrpcController,
 		internal.InitRoutes,
+		internal.InitGrpc,
 		internal.NewApp,
 	)
 
@@ -26,19 +26,42 @@ func InitApp(cfg *structures.CliFlags) (*internal.App, error) {
 	if err != nil {
 		return nil, err
 	}
-	statisticServiceInterface := services.NewStatisticService()
+	statisticServiceInterface := services.NewStatisticService(config)
 	metricsProviderInterface := providers.NewMetricsProvider(config, statisticServiceInterface)
 	cacheProviderInterface := providers.NewInstrumentedCacheProvider(config, logger, metricsProviderInterface)
-	apiController := controllers.NewApiController(logger, statisticServiceInterface, cacheProviderInterface)
+	userAgentProviderInterface, err := providers.NewUserAgentProvider(config, logger, metricsProviderInterface)
+	if err != nil {
+		return nil, err
+	}
+	geoIPProviderInterface, err := providers.NewGeoIPProvider(config, logger)
+	if err != nil {
+		return nil, err
+	}
+	v := providers.NewEnrichers(userAgentProviderInterface, geoIPProviderInterface)
+	ingestServiceInterface := services.NewIngestService(statisticServiceInterface, v)
+	corsPolicyInterface := providers.NewCORSPolicy(config)
+	apiController := controllers.NewApiController(logger, statisticServiceInterface, cacheProviderInterface, ingestServiceInterface, corsPolicyInterface)
 	healthController := controllers.NewHealthController(statisticServiceInterface)
+	streamProviderInterface := providers.NewStreamProvider(config, statisticServiceInterface, metricsProviderInterface, logger)
+	socketController := controllers.NewSocketController(config, apiController, streamProviderInterface)
 	compressorInterface, err := statistic.NewZstdCompressor()
 	if err != nil {
 		return nil, err
 	}
-	fileManager := statistic.NewFileManager(compressorInterface, statisticServiceInterface, logger)
-	schedulerInterface := statistic.NewScheduler(config, logger, statisticServiceInterface, fileManager, metricsProviderInterface)
-	routerProviderInterface := internal.InitRoutes(apiController, config)
-	app, err := internal.NewApp(apiController, healthController, schedulerInterface, config, logger, routerProviderInterface, metricsProviderInterface)
+	ruleEngineInterface := services.NewRuleEngine(config, statisticServiceInterface)
+	fileManager := statistic.NewFileManager(compressorInterface, statisticServiceInterface, ruleEngineInterface, logger)
+	anomalyDetectorInterface := services.NewAnomalyDetector(config)
+	webhookProviderInterface := providers.NewWebhookProvider(config, logger)
+	schedulerInterface := statistic.NewScheduler(config, logger, statisticServiceInterface, fileManager, metricsProviderInterface, anomalyDetectorInterface, ruleEngineInterface, webhookProviderInterface, streamProviderInterface)
+	catalogController := controllers.NewCatalogController(statisticServiceInterface)
+	alertsController := controllers.NewAlertsController(anomalyDetectorInterface)
+	rulesController := controllers.NewRulesController(ruleEngineInterface)
+	streamController := controllers.NewStreamController(streamProviderInterface)
+	routerProviderInterface := internal.InitRoutes(apiController, catalogController, alertsController, rulesController, streamController, socketController, corsPolicyInterface)
+	grpcController := controllers.NewGrpcController(statisticServiceInterface, ingestServiceInterface)
+	server := internal.InitGrpc(grpcController, metricsProviderInterface, config)
+	udpListenerInterface := providers.NewUDPListener(config, ingestServiceInterface, metricsProviderInterface, logger)
+	app, err := internal.NewApp(apiController, healthController, socketController, schedulerInterface, config, logger, routerProviderInterface, server, udpListenerInterface, userAgentProviderInterface, geoIPProviderInterface, metricsProviderInterface)
 	if err != nil {
 		return nil, err
 	}
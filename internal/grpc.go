@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"context"
+	"ssd/internal/controllers"
+	"ssd/internal/pb"
+	"ssd/internal/providers"
+	"ssd/internal/structures"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	defaultGrpcPort = 9090
+	maxGrpcMsgSize  = 1 << 20 // like the HTTP request body limit
+)
+
+// InitGrpc builds the gRPC server with the Stats and health services, or
+// returns nil when gRPC is disabled.
+func InitGrpc(grpcController *controllers.GrpcController, metrics providers.MetricsProviderInterface, conf *structures.Config) *grpc.Server {
+	if !conf.Grpc.Enabled {
+		return nil
+	}
+	server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxGrpcMsgSize),
+		grpc.ChainUnaryInterceptor(providers.MetricsUnaryInterceptor(metrics)),
+		grpc.ChainStreamInterceptor(providers.MetricsStreamInterceptor(metrics)),
+	)
+	pb.RegisterStatsServer(server, grpcController)
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(pb.Stats_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	return server
+}
+
+// grpcAddr is the listen address of the gRPC server, on the host of the
+// HTTP server.
+func grpcAddr(conf *structures.Config) string {
+	port := conf.Grpc.Port
+	if port == 0 {
+		port = defaultGrpcPort
+	}
+	return conf.WebServer.Host + ":" + strconv.Itoa(port)
+}
+
+// stopGrpc lets running calls finish until ctx is done, then closes the
+// remaining connections. Open ingest streams would otherwise hold up the
+// shutdown until their clients close them.
+func stopGrpc(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		server.Stop()
+	}
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"context"
+	"net"
+	"ssd/internal/controllers"
+	"ssd/internal/pb"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"ssd/internal/testutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestInitGrpc_Disabled(t *testing.T) {
+	assert.Nil(t, InitGrpc(controllers.NewGrpcController(&routeTestMockService{}, services.NewIngestService(&routeTestMockService{}, nil)), &testutil.MockMetrics{}, &structures.Config{}))
+}
+
+func TestInitGrpc_ServesStatsAndHealth(t *testing.T) {
+	svc := services.NewStatisticService(&structures.Config{})
+	metrics := &testutil.MockMetrics{}
+	server := InitGrpc(controllers.NewGrpcController(svc, services.NewIngestService(svc, nil)), metrics, &structures.Config{Grpc: structures.GrpcConfig{Enabled: true}})
+	require.NotNil(t, server)
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = server.Serve(lis) }()
+	defer server.Stop()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	ctx := context.Background()
+
+	health, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: "ssd.v1.Stats"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.GetStatus())
+
+	client := pb.NewStatsClient(conn)
+	stream, err := client.IngestStream(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.Event{Views: []string{"1", "2"}, Channel: "news"}))
+	require.NoError(t, stream.Send(&pb.Event{Views: []string{"2"}, Channel: "news"}))
+	resp, err := stream.CloseAndRecv()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), resp.GetAccepted())
+
+	svc.AggregateStats()
+	top, err := client.Top(ctx, &pb.TopRequest{Channel: "news", N: 1})
+	require.NoError(t, err)
+	require.Len(t, top.GetRecords(), 1)
+	assert.Equal(t, int64(2), top.GetRecords()[0].GetId())
+	assert.Equal(t, int64(2), top.GetRecords()[0].GetViews())
+
+	assert.Equal(t, 2, metrics.RequestsTotalCalls, "stream and unary calls are counted, health checks are not")
+	assert.Equal(t, 2, metrics.RequestDurationCalls)
+}
@@ -0,0 +1,247 @@
+package models
+
+import (
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+var catalogEntryBytes = int64(unsafe.Sizeof(int(0))+unsafe.Sizeof(&CatalogItem{})+unsafe.Sizeof(CatalogItem{})) + mapEntryOverhead
+
+// CatalogItem is the metadata SSD knows about an item ID.
+type CatalogItem struct {
+	Category    string            `json:"category,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	PublishedAt *time.Time        `json:"published_at,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+}
+
+// bytes is the approximate size of the item including its strings.
+func (ci *CatalogItem) bytes() int64 {
+	n := catalogEntryBytes + int64(len(ci.Category))
+	for _, t := range ci.Tags {
+		n += int64(unsafe.Sizeof(t)) + int64(len(t))
+	}
+	for k, v := range ci.Attributes {
+		n += mapEntryOverhead + int64(unsafe.Sizeof(k)+unsafe.Sizeof(v)) + int64(len(k)+len(v))
+	}
+	return n
+}
+
+// CatalogFilter selects items by their catalog metadata. Zero fields match
+// everything; items missing from the catalog match only the empty filter.
+type CatalogFilter struct {
+	Tag            string
+	Category       string
+	PublishedAfter time.Time
+}
+
+func (f CatalogFilter) IsEmpty() bool {
+	return f.Tag == "" && f.Category == "" && f.PublishedAfter.IsZero()
+}
+
+func (f CatalogFilter) Match(item *CatalogItem) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	if item == nil {
+		return false
+	}
+	if f.Category != "" && item.Category != f.Category {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(item.Tags, f.Tag) {
+		return false
+	}
+	if !f.PublishedAfter.IsZero() && (item.PublishedAt == nil || !item.PublishedAt.After(f.PublishedAfter)) {
+		return false
+	}
+	return true
+}
+
+// Catalog is a per-channel item metadata store with indexes by tag and
+// category, so updates cost only the changed items and filters need not scan
+// the whole catalog. Stored items are never modified in place, so items
+// handed out stay valid after later updates.
+type Catalog struct {
+	mu         sync.RWMutex
+	items      map[int]*CatalogItem
+	byTag      map[string]map[int]struct{}
+	byCategory map[string]map[int]struct{}
+	bytes      atomic.Int64
+}
+
+func NewCatalog() *Catalog {
+	c := &Catalog{}
+	c.reset(0)
+	return c
+}
+
+// Data returns a copy of the catalog.
+func (c *Catalog) Data() map[int]*CatalogItem {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return maps.Clone(c.items)
+}
+
+func (c *Catalog) Get(id int) (*CatalogItem, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	item, ok := c.items[id]
+	return item, ok
+}
+
+func (c *Catalog) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
+
+// MemoryUsage returns the approximate number of bytes held by the catalog.
+func (c *Catalog) MemoryUsage() int64 {
+	return c.bytes.Load()
+}
+
+// Matches reports whether the item id matches filter.
+func (c *Catalog) Matches(filter CatalogFilter, id int) bool {
+	if filter.IsEmpty() {
+		return true
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return filter.Match(c.items[id])
+}
+
+// FilterRecords returns the records whose items match filter. It walks the
+// smaller of records and the items indexed under the filter's tag or
+// category, so a narrow filter on a large catalog stays cheap and so does a
+// small record set.
+func (c *Catalog) FilterRecords(filter CatalogFilter, records map[int]*StatRecord) map[int]*StatRecord {
+	if filter.IsEmpty() {
+		return records
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	candidates, indexed := c.candidates(filter)
+	out := make(map[int]*StatRecord)
+	if indexed && len(candidates) < len(records) {
+		for id := range candidates {
+			if rec, ok := records[id]; ok && filter.Match(c.items[id]) {
+				out[id] = rec
+			}
+		}
+		return out
+	}
+	for id, rec := range records {
+		if filter.Match(c.items[id]) {
+			out[id] = rec
+		}
+	}
+	return out
+}
+
+// candidates returns the smallest index set holding every match of filter,
+// or false when the filter has no indexed field. Callers must hold the lock.
+func (c *Catalog) candidates(filter CatalogFilter) (map[int]struct{}, bool) {
+	switch {
+	case filter.Tag != "" && filter.Category != "":
+		tagged, inCategory := c.byTag[filter.Tag], c.byCategory[filter.Category]
+		if len(tagged) < len(inCategory) {
+			return tagged, true
+		}
+		return inCategory, true
+	case filter.Tag != "":
+		return c.byTag[filter.Tag], true
+	case filter.Category != "":
+		return c.byCategory[filter.Category], true
+	}
+	return nil, false
+}
+
+// Update upserts items and then removes the IDs in remove.
+func (c *Catalog) Update(upsert map[int]*CatalogItem, remove []int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for id, item := range upsert {
+		if item != nil {
+			c.put(id, item)
+		}
+	}
+	for _, id := range remove {
+		c.remove(id)
+	}
+}
+
+// PutData replaces the catalog.
+func (c *Catalog) PutData(data map[int]*CatalogItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reset(len(data))
+	for id, item := range data {
+		if item != nil {
+			c.put(id, item)
+		}
+	}
+}
+
+// reset empties the catalog. Callers must hold the lock.
+func (c *Catalog) reset(size int) {
+	c.items = make(map[int]*CatalogItem, size)
+	c.byTag = make(map[string]map[int]struct{})
+	c.byCategory = make(map[string]map[int]struct{})
+	c.bytes.Store(0)
+}
+
+// put stores item under id, replacing its previous version in the indexes.
+// Callers must hold the lock.
+func (c *Catalog) put(id int, item *CatalogItem) {
+	c.remove(id)
+	c.items[id] = item
+	for _, tag := range item.Tags {
+		addIndex(c.byTag, tag, id)
+	}
+	if item.Category != "" {
+		addIndex(c.byCategory, item.Category, id)
+	}
+	c.bytes.Add(item.bytes())
+}
+
+// remove drops id from the catalog and its indexes. Callers must hold the
+// lock.
+func (c *Catalog) remove(id int) {
+	old, ok := c.items[id]
+	if !ok {
+		return
+	}
+	delete(c.items, id)
+	for _, tag := range old.Tags {
+		removeIndex(c.byTag, tag, id)
+	}
+	if old.Category != "" {
+		removeIndex(c.byCategory, old.Category, id)
+	}
+	c.bytes.Add(-old.bytes())
+}
+
+func addIndex(index map[string]map[int]struct{}, key string, id int) {
+	ids := index[key]
+	if ids == nil {
+		ids = make(map[int]struct{})
+		index[key] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func removeIndex(index map[string]map[int]struct{}, key string, id int) {
+	ids := index[key]
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(index, key)
+	}
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	json "github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func publishedAt(s string) *time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return &t
+}
+
+func TestCatalog_UpdateAndGet(t *testing.T) {
+	c := NewCatalog()
+	c.Update(map[int]*CatalogItem{
+		1: {Category: "sports", Tags: []string{"football"}},
+		2: {Category: "politics"},
+	}, nil)
+
+	item, ok := c.Get(1)
+	require.True(t, ok)
+	assert.Equal(t, "sports", item.Category)
+	assert.Equal(t, 2, c.Len())
+	assert.Positive(t, c.MemoryUsage())
+
+	c.Update(map[int]*CatalogItem{3: {Category: "tech"}}, []int{2})
+	_, ok = c.Get(2)
+	assert.False(t, ok)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestCatalog_DataIsCopyOnWrite(t *testing.T) {
+	c := NewCatalog()
+	c.Update(map[int]*CatalogItem{1: {Category: "a"}}, nil)
+	before := c.Data()
+
+	c.Update(map[int]*CatalogItem{2: {Category: "b"}}, nil)
+
+	assert.Len(t, before, 1)
+	assert.Len(t, c.Data(), 2)
+}
+
+func TestCatalog_PutDataReplaces(t *testing.T) {
+	c := NewCatalog()
+	c.Update(map[int]*CatalogItem{1: {Category: "a"}}, nil)
+	c.PutData(map[int]*CatalogItem{2: {Category: "b"}, 3: nil})
+
+	assert.Equal(t, 1, c.Len())
+	_, ok := c.Get(2)
+	assert.True(t, ok)
+
+	c.PutData(nil)
+	assert.Zero(t, c.Len())
+	assert.Zero(t, c.MemoryUsage())
+}
+
+func TestCatalog_FilterRecordsFollowsUpdates(t *testing.T) {
+	c := NewCatalog()
+	c.Update(map[int]*CatalogItem{
+		1: {Category: "sports", Tags: []string{"football"}},
+		2: {Category: "sports", Tags: []string{"tennis"}},
+		3: {Category: "tech", Tags: []string{"football"}},
+	}, nil)
+	records := map[int]*StatRecord{1: {Views: 1}, 2: {Views: 2}, 3: {Views: 3}, 4: {Views: 4}}
+	ids := func(filter CatalogFilter) []int {
+		var out []int
+		for id := range c.FilterRecords(filter, records) {
+			out = append(out, id)
+		}
+		return out
+	}
+
+	assert.ElementsMatch(t, []int{1, 3}, ids(CatalogFilter{Tag: "football"}))
+	assert.ElementsMatch(t, []int{1}, ids(CatalogFilter{Tag: "football", Category: "sports"}))
+	assert.ElementsMatch(t, []int{1, 2, 3, 4}, ids(CatalogFilter{}))
+
+	// the replaced item leaves its old tag and category
+	c.Update(map[int]*CatalogItem{1: {Category: "tech", Tags: []string{"chess"}}}, []int{3})
+	assert.Empty(t, ids(CatalogFilter{Tag: "football"}))
+	assert.ElementsMatch(t, []int{1}, ids(CatalogFilter{Category: "tech"}))
+	assert.True(t, c.Matches(CatalogFilter{Tag: "chess"}, 1))
+	assert.False(t, c.Matches(CatalogFilter{Tag: "chess"}, 4))
+
+	// a record set smaller than the index is walked instead
+	assert.ElementsMatch(t, []int{2}, func() []int {
+		var out []int
+		for id := range c.FilterRecords(CatalogFilter{Category: "sports"}, map[int]*StatRecord{2: {}}) {
+			out = append(out, id)
+		}
+		return out
+	}())
+}
+
+func TestCatalog_MemoryUsageFollowsUpdates(t *testing.T) {
+	c := NewCatalog()
+	c.Update(map[int]*CatalogItem{1: {Category: "a"}, 2: {Category: "b"}}, nil)
+	two := c.MemoryUsage()
+	c.Update(map[int]*CatalogItem{1: {Category: "a"}}, []int{2})
+	c.Update(nil, []int{7})
+
+	fresh := NewCatalog()
+	fresh.PutData(map[int]*CatalogItem{1: {Category: "a"}})
+	assert.Equal(t, fresh.MemoryUsage(), c.MemoryUsage())
+	assert.Less(t, c.MemoryUsage(), two)
+}
+
+func TestCatalogFilter_Match(t *testing.T) {
+	item := &CatalogItem{
+		Category:    "sports",
+		Tags:        []string{"football", "euro"},
+		PublishedAt: publishedAt("2026-05-01T10:00:00Z"),
+	}
+	after, _ := time.Parse(time.RFC3339, "2026-04-01T00:00:00Z")
+	later, _ := time.Parse(time.RFC3339, "2026-06-01T00:00:00Z")
+
+	assert.True(t, CatalogFilter{}.Match(nil))
+	assert.True(t, CatalogFilter{Category: "sports", Tag: "euro", PublishedAfter: after}.Match(item))
+	assert.False(t, CatalogFilter{Category: "tech"}.Match(item))
+	assert.False(t, CatalogFilter{Tag: "tennis"}.Match(item))
+	assert.False(t, CatalogFilter{PublishedAfter: later}.Match(item))
+	assert.False(t, CatalogFilter{PublishedAfter: after}.Match(&CatalogItem{}))
+	assert.False(t, CatalogFilter{Category: "sports"}.Match(nil))
+}
+
+func TestCatalogItem_JSON(t *testing.T) {
+	raw := `{"category":"sports","tags":["a"],"published_at":"2026-05-01T10:00:00Z","attributes":{"author":"x"}}`
+	var item CatalogItem
+	require.NoError(t, json.Unmarshal([]byte(raw), &item))
+	assert.Equal(t, "x", item.Attributes["author"])
+	assert.Equal(t, 2026, item.PublishedAt.Year())
+
+	gson, err := json.Marshal(&CatalogItem{})
+	require.NoError(t, err)
+	assert.Equal(t, `{}`, string(gson))
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"sync"
+	"unsafe"
+)
+
+const (
+	// OtherDimValue collects values beyond a dimension's cardinality limit.
+	OtherDimValue = "_other"
+	// maxDimValueLen is the longest value stored as is; longer values are
+	// counted under OtherDimValue.
+	maxDimValueLen = 64
+)
+
+var dimValueBytes = int64(unsafe.Sizeof("")+unsafe.Sizeof(&TrendStats{})+unsafe.Sizeof(TrendStats{})) + mapEntryOverhead
+
+// DimKey identifies the trend store of one dimension value.
+type DimKey struct {
+	Name  string
+	Value string
+}
+
+// DimensionStats keeps a trend store per value of every whitelisted
+// dimension, e.g. device=mobile. Each dimension holds at most maxValues
+// distinct values; the rest are counted under OtherDimValue.
+type DimensionStats struct {
+	mu        sync.RWMutex
+	allowed   map[string]struct{}
+	maxValues int
+	dims      map[string]map[string]*TrendStats
+}
+
+func NewDimensionStats(allowed []string, maxValues int) *DimensionStats {
+	ds := &DimensionStats{
+		allowed:   make(map[string]struct{}, len(allowed)),
+		maxValues: maxValues,
+		dims:      make(map[string]map[string]*TrendStats, len(allowed)),
+	}
+	for _, name := range allowed {
+		ds.allowed[name] = struct{}{}
+	}
+	return ds
+}
+
+// IncStats counts the event's views and clicks under each of its whitelisted
+// dimension values and adds the updated keys to touched.
+func (ds *DimensionStats) IncStats(val *InputStats, touched map[DimKey]struct{}) {
+	if val == nil || len(val.Dims) == 0 {
+		return
+	}
+	for name, value := range val.Dims {
+		if _, ok := ds.allowed[name]; !ok || value == "" {
+			continue
+		}
+		key := ds.store(name, value)
+		key.ts.IncStats(val)
+		touched[key.DimKey] = struct{}{}
+	}
+}
+
+type dimStore struct {
+	DimKey
+	ts *TrendStats
+}
+
+// store returns the trend store of a value, creating it while the dimension
+// is below its cardinality limit.
+func (ds *DimensionStats) store(name, value string) dimStore {
+	if len(value) > maxDimValueLen {
+		value = OtherDimValue
+	}
+	ds.mu.RLock()
+	ts, ok := ds.dims[name][value]
+	ds.mu.RUnlock()
+	if ok {
+		return dimStore{DimKey{name, value}, ts}
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	values := ds.dims[name]
+	if values == nil {
+		values = make(map[string]*TrendStats)
+		ds.dims[name] = values
+	}
+	if ts, ok := values[value]; ok {
+		return dimStore{DimKey{name, value}, ts}
+	}
+	if len(values) >= ds.maxValues {
+		value = OtherDimValue
+		if ts, ok := values[value]; ok {
+			return dimStore{DimKey{name, value}, ts}
+		}
+	}
+	ts = NewTrendStats()
+	values[value] = ts
+	return dimStore{DimKey{name, value}, ts}
+}
+
+// Get returns the records of one dimension value.
+func (ds *DimensionStats) Get(key DimKey) (map[int]*StatRecord, bool) {
+	ds.mu.RLock()
+	ts, ok := ds.dims[key.Name][key.Value]
+	ds.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	return ts.GetData(), true
+}
+
+// MemoryUsage returns the approximate number of bytes held by all values.
+func (ds *DimensionStats) MemoryUsage() int64 {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	var n int64
+	for _, values := range ds.dims {
+		for value, ts := range values {
+			n += dimValueBytes + int64(len(value)) + ts.MemoryUsage()
+		}
+	}
+	return n
+}
+
+// GetData returns the records of every dimension value.
+func (ds *DimensionStats) GetData() map[string]map[string]map[int]*StatRecord {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	out := make(map[string]map[string]map[int]*StatRecord, len(ds.dims))
+	for name, values := range ds.dims {
+		copyValues := make(map[string]map[int]*StatRecord, len(values))
+		for value, ts := range values {
+			copyValues[value] = ts.GetData()
+		}
+		out[name] = copyValues
+	}
+	return out
+}
+
+// PutData replaces all values. Dimensions no longer whitelisted are dropped
+// and values beyond the cardinality limit are skipped.
+func (ds *DimensionStats) PutData(data map[string]map[string]map[int]*StatRecord) {
+	dims := make(map[string]map[string]*TrendStats, len(data))
+	for name, values := range data {
+		if _, ok := ds.allowed[name]; !ok {
+			continue
+		}
+		restored := make(map[string]*TrendStats, min(len(values), ds.maxValues))
+		for value, records := range values {
+			if len(restored) >= ds.maxValues {
+				break
+			}
+			ts := NewTrendStats()
+			ts.PutData(records)
+			restored[value] = ts
+		}
+		dims[name] = restored
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.dims = dims
+}
+
+// AgeHistogram returns the accounted item bytes per last-update stamp.
+func (ds *DimensionStats) AgeHistogram() map[uint32]int64 {
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	var hist map[uint32]int64
+	for _, values := range ds.dims {
+		for _, ts := range values {
+			for stamp, size := range ts.AgeHistogram() {
+				hist = addAge(hist, stamp, size)
+			}
+		}
+	}
+	return hist
+}
+
+// EvictBefore removes items last updated before stamp from every value until
+// at least limit bytes are freed. Values left empty are dropped, freeing
+// room under the cardinality limit.
+func (ds *DimensionStats) EvictBefore(stamp uint32, limit int64) (int, int64) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	evicted := 0
+	var freed int64
+	for _, values := range ds.dims {
+		for value, ts := range values {
+			if freed >= limit {
+				return evicted, freed
+			}
+			n, f := ts.EvictBefore(stamp, limit-freed)
+			evicted += n
+			freed += f
+			if ts.Len() == 0 {
+				delete(values, value)
+			}
+		}
+	}
+	return evicted, freed
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDimensionStats_CountsWhitelistedOnly(t *testing.T) {
+	ds := NewDimensionStats([]string{"device"}, 10)
+	touched := make(map[DimKey]struct{})
+
+	ds.IncStats(&InputStats{
+		Views: []string{"1"}, Clicks: []string{"1"},
+		Dims: map[string]string{"device": "mobile", "country": "de"},
+	}, touched)
+
+	records, ok := ds.Get(DimKey{"device", "mobile"})
+	require.True(t, ok)
+	assert.Equal(t, 1, records[1].Views)
+	assert.Equal(t, 1, records[1].Clicks)
+	_, ok = ds.Get(DimKey{"country", "de"})
+	assert.False(t, ok)
+	assert.Equal(t, map[DimKey]struct{}{{"device", "mobile"}: {}}, touched)
+}
+
+func TestDimensionStats_IgnoresEmptyValuesAndNil(t *testing.T) {
+	ds := NewDimensionStats([]string{"device"}, 10)
+	touched := make(map[DimKey]struct{})
+	ds.IncStats(nil, touched)
+	ds.IncStats(&InputStats{Views: []string{"1"}}, touched)
+	ds.IncStats(&InputStats{Views: []string{"1"}, Dims: map[string]string{"device": ""}}, touched)
+
+	assert.Empty(t, touched)
+	assert.Empty(t, ds.GetData())
+}
+
+func TestDimensionStats_CardinalityLimit(t *testing.T) {
+	ds := NewDimensionStats([]string{"country"}, 2)
+	touched := make(map[DimKey]struct{})
+	for _, c := range []string{"de", "fr", "us", "jp"} {
+		ds.IncStats(&InputStats{Views: []string{"1"}, Dims: map[string]string{"country": c}}, touched)
+	}
+	ds.IncStats(&InputStats{Views: []string{"1"}, Dims: map[string]string{"country": "de"}}, touched)
+
+	data := ds.GetData()["country"]
+	assert.Len(t, data, 3)
+	assert.Equal(t, 2, data["de"][1].Views)
+	assert.Equal(t, 1, data["fr"][1].Views)
+	assert.Equal(t, 2, data[OtherDimValue][1].Views)
+}
+
+func TestDimensionStats_LongValueIsOther(t *testing.T) {
+	ds := NewDimensionStats([]string{"ref"}, 10)
+	ds.IncStats(&InputStats{Views: []string{"1"}, Dims: map[string]string{"ref": strings.Repeat("x", maxDimValueLen+1)}}, make(map[DimKey]struct{}))
+
+	_, ok := ds.Get(DimKey{"ref", OtherDimValue})
+	assert.True(t, ok)
+}
+
+func TestDimensionStats_PutData(t *testing.T) {
+	ds := NewDimensionStats([]string{"device"}, 1)
+	ds.PutData(map[string]map[string]map[int]*StatRecord{
+		"device":  {"mobile": {1: {Views: 5}}, "desktop": {2: {Views: 1}}},
+		"country": {"de": {1: {Views: 1}}},
+	})
+
+	data := ds.GetData()
+	assert.Len(t, data, 1)
+	assert.Len(t, data["device"], 1)
+	assert.Positive(t, ds.MemoryUsage())
+}
+
+func TestDimensionStats_EvictBefore(t *testing.T) {
+	ds := NewDimensionStats([]string{"device"}, 10)
+	now := uint32(100)
+	withClock(t, &now)
+
+	ds.IncStats(&InputStats{Views: []string{"1", "2"}, Dims: map[string]string{"device": "mobile"}}, make(map[DimKey]struct{}))
+	now = 200
+	ds.IncStats(&InputStats{Views: []string{"3"}, Dims: map[string]string{"device": "desktop"}}, make(map[DimKey]struct{}))
+
+	hist := ds.AgeHistogram()
+	assert.Equal(t, 2*trendEntryBytes, hist[100])
+	assert.Equal(t, trendEntryBytes, hist[200])
+
+	n, freed := ds.EvictBefore(150, 1<<30)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2*trendEntryBytes, freed)
+	_, ok := ds.Get(DimKey{"device", "mobile"})
+	assert.False(t, ok, "emptied values are dropped")
+	_, ok = ds.Get(DimKey{"device", "desktop"})
+	assert.True(t, ok)
+}
+
+func BenchmarkDimensionStats_IncStats(b *testing.B) {
+	ds := NewDimensionStats([]string{"device", "country"}, 100)
+	inputs := make([]*InputStats, 64)
+	for i := range inputs {
+		inputs[i] = &InputStats{
+			Views: []string{fmt.Sprint(i)},
+			Dims:  map[string]string{"device": fmt.Sprint("d", i%3), "country": fmt.Sprint("c", i%20)},
+		}
+	}
+	touched := make(map[DimKey]struct{})
+	b.ReportAllocs()
+	for i := 0; b.Loop(); i++ {
+		ds.IncStats(inputs[i%len(inputs)], touched)
+	}
+}
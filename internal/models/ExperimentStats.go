@@ -0,0 +1,252 @@
+package models
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+const (
+	// maxVariantLen is the longest experiment or variant name that is
+	// counted; events with longer names are ignored for that experiment.
+	maxVariantLen = 64
+
+	// ExperimentOtherItem collects the counts of items beyond the per-variant
+	// item cap, so that totals over all items stay exact.
+	ExperimentOtherItem = math.MinInt
+)
+
+var (
+	variantCountsBytes = int64(unsafe.Sizeof(int(0))+unsafe.Sizeof(VariantCounts{})) + mapEntryOverhead
+	variantEntryBytes  = int64(unsafe.Sizeof("")+unsafe.Sizeof(map[int]VariantCounts{})) + mapEntryOverhead
+)
+
+// VariantCounts are the impressions and clicks of one item in one variant.
+type VariantCounts struct {
+	Views  int64 `json:"views"`
+	Clicks int64 `json:"clicks"`
+}
+
+// ExperimentStats counts views and clicks per experiment, variant and item.
+// Unlike trend records the counts are never halved: significance tests need
+// the real sample sizes. Experiments and variants per experiment are capped;
+// assignments to experiments or variants beyond the caps are not counted,
+// and items beyond the item cap are counted as ExperimentOtherItem. An
+// experiment without events for ttl is retired, freeing its slot.
+type ExperimentStats struct {
+	mu          sync.RWMutex
+	maxExps     int
+	maxVariants int
+	maxItems    int    // per variant, 0 = unlimited
+	ttl         uint32 // seconds, 0 = never retired
+	data        map[string]map[string]map[int]VariantCounts
+	seen        map[string]uint32 // experiment -> last event
+	nextSweep   uint32
+	bytes       int64
+	rejected    atomic.Int64
+}
+
+func NewExperimentStats(maxExperiments, maxVariants, maxItems int, ttl time.Duration) *ExperimentStats {
+	return &ExperimentStats{
+		maxExps:     maxExperiments,
+		maxVariants: maxVariants,
+		maxItems:    maxItems,
+		ttl:         uint32(max(ttl/time.Second, 0)),
+		data:        make(map[string]map[string]map[int]VariantCounts),
+		seen:        make(map[string]uint32),
+	}
+}
+
+// IncStats counts the event's views and clicks under each of its experiment
+// assignments and adds the updated experiments to touched.
+func (es *ExperimentStats) IncStats(val *InputStats, touched map[string]struct{}) {
+	if val == nil || len(val.Experiments) == 0 {
+		return
+	}
+	now := val.receivedAt()
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	for exp, variant := range val.Experiments {
+		items := es.variant(exp, variant)
+		if items == nil {
+			es.rejected.Add(1)
+			continue
+		}
+		es.seen[exp] = max(es.seen[exp], now)
+		parseIDs(val.Views, func(id int) { es.add(items, id, 1, 0) })
+		parseIDs(val.Clicks, func(id int) { es.add(items, id, 0, 1) })
+		touched[exp] = struct{}{}
+	}
+}
+
+// variant returns the item counts of a variant, creating it within the caps.
+// Callers must hold mu.
+func (es *ExperimentStats) variant(exp, variant string) map[int]VariantCounts {
+	if exp == "" || variant == "" || len(exp) > maxVariantLen || len(variant) > maxVariantLen {
+		return nil
+	}
+	variants, ok := es.data[exp]
+	if !ok {
+		if len(es.data) >= es.maxExps {
+			return nil
+		}
+		variants = make(map[string]map[int]VariantCounts)
+		es.data[exp] = variants
+		es.bytes += variantEntryBytes + int64(len(exp))
+	}
+	items, ok := variants[variant]
+	if !ok {
+		if len(variants) >= es.maxVariants {
+			return nil
+		}
+		items = make(map[int]VariantCounts)
+		variants[variant] = items
+		es.bytes += variantEntryBytes + int64(len(variant))
+	}
+	return items
+}
+
+func (es *ExperimentStats) add(items map[int]VariantCounts, id int, views, clicks int64) {
+	c, ok := items[id]
+	if !ok && es.maxItems > 0 && len(items) >= es.maxItems {
+		id = ExperimentOtherItem
+		c, ok = items[id]
+	}
+	if !ok {
+		es.bytes += variantCountsBytes
+	}
+	c.Views += views
+	c.Clicks += clicks
+	items[id] = c
+}
+
+// Expire retires experiments without events for ttl and returns their IDs.
+// The sweep walks every experiment, so it runs at most once per minute.
+func (es *ExperimentStats) Expire() []string {
+	if es.ttl == 0 {
+		return nil
+	}
+	now := clock()
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	if now < es.nextSweep {
+		return nil
+	}
+	es.nextSweep = now + 60
+	var retired []string
+	for exp, variants := range es.data {
+		if now > es.seen[exp] && now-es.seen[exp] > es.ttl {
+			es.remove(exp, variants)
+			retired = append(retired, exp)
+		}
+	}
+	return retired
+}
+
+// remove drops an experiment and its accounted bytes. Callers must hold mu.
+func (es *ExperimentStats) remove(exp string, variants map[string]map[int]VariantCounts) {
+	es.bytes -= variantEntryBytes + int64(len(exp))
+	for variant, items := range variants {
+		es.bytes -= variantEntryBytes + int64(len(variant)) + int64(len(items))*variantCountsBytes
+	}
+	delete(es.data, exp)
+	delete(es.seen, exp)
+}
+
+// Rejected returns the number of assignments not counted because of the
+// experiment or variant caps or an invalid name.
+func (es *ExperimentStats) Rejected() int64 {
+	return es.rejected.Load()
+}
+
+// Get returns a copy of one experiment's counts by variant and item.
+func (es *ExperimentStats) Get(exp string) (map[string]map[int]VariantCounts, bool) {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	variants, ok := es.data[exp]
+	if !ok {
+		return nil, false
+	}
+	return copyVariants(variants), true
+}
+
+// Len returns the number of experiments.
+func (es *ExperimentStats) Len() int {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	return len(es.data)
+}
+
+// MemoryUsage returns the approximate number of bytes held by all counts.
+func (es *ExperimentStats) MemoryUsage() int64 {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	return es.bytes
+}
+
+// GetData returns a copy of every experiment.
+func (es *ExperimentStats) GetData() map[string]map[string]map[int]VariantCounts {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	out := make(map[string]map[string]map[int]VariantCounts, len(es.data))
+	for exp, variants := range es.data {
+		out[exp] = copyVariants(variants)
+	}
+	return out
+}
+
+// Seen returns when each experiment last had an event (Unix seconds).
+func (es *ExperimentStats) Seen() map[string]uint32 {
+	es.mu.RLock()
+	defer es.mu.RUnlock()
+	out := make(map[string]uint32, len(es.seen))
+	for exp, stamp := range es.seen {
+		out[exp] = stamp
+	}
+	return out
+}
+
+// PutData replaces all experiments, skipping those beyond the caps. seen
+// holds when each last had an event; experiments missing from it count as
+// seen now.
+func (es *ExperimentStats) PutData(data map[string]map[string]map[int]VariantCounts, seen map[string]uint32) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	es.data = make(map[string]map[string]map[int]VariantCounts, min(len(data), es.maxExps))
+	es.seen = make(map[string]uint32, min(len(data), es.maxExps))
+	es.bytes = 0
+	now := clock()
+	for exp, variants := range data {
+		for variant, items := range variants {
+			dst := es.variant(exp, variant)
+			if dst == nil {
+				continue
+			}
+			for id, c := range items {
+				es.add(dst, id, c.Views, c.Clicks)
+			}
+		}
+		if _, ok := es.data[exp]; !ok {
+			continue
+		}
+		if stamp, ok := seen[exp]; ok {
+			es.seen[exp] = stamp
+		} else {
+			es.seen[exp] = now
+		}
+	}
+}
+
+func copyVariants(variants map[string]map[int]VariantCounts) map[string]map[int]VariantCounts {
+	out := make(map[string]map[int]VariantCounts, len(variants))
+	for variant, items := range variants {
+		copyItems := make(map[int]VariantCounts, len(items))
+		for id, c := range items {
+			copyItems[id] = c
+		}
+		out[variant] = copyItems
+	}
+	return out
+}
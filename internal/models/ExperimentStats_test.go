@@ -0,0 +1,117 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExperimentStats_CountsPerVariantAndItem(t *testing.T) {
+	es := NewExperimentStats(10, 10, 0, 0)
+	touched := make(map[string]struct{})
+
+	es.IncStats(&InputStats{Views: []string{"1", "2"}, Clicks: []string{"1"}, Experiments: map[string]string{"headline": "a"}}, touched)
+	es.IncStats(&InputStats{Views: []string{"1"}, Experiments: map[string]string{"headline": "b", "layout": "wide"}}, touched)
+	es.IncStats(&InputStats{Views: []string{"1"}}, touched)
+
+	variants, ok := es.Get("headline")
+	require.True(t, ok)
+	assert.Equal(t, VariantCounts{Views: 1, Clicks: 1}, variants["a"][1])
+	assert.Equal(t, VariantCounts{Views: 1}, variants["a"][2])
+	assert.Equal(t, VariantCounts{Views: 1}, variants["b"][1])
+	assert.Equal(t, map[string]struct{}{"headline": {}, "layout": {}}, touched)
+	assert.Equal(t, 2, es.Len())
+}
+
+func TestExperimentStats_CountsAreNotDecayed(t *testing.T) {
+	es := NewExperimentStats(10, 10, 0, 0)
+	touched := make(map[string]struct{})
+	ev := &InputStats{Views: []string{"1"}, Experiments: map[string]string{"e": "a"}}
+	for range trendThreshold * 3 {
+		es.IncStats(ev, touched)
+	}
+
+	variants, _ := es.Get("e")
+	assert.Equal(t, int64(trendThreshold*3), variants["a"][1].Views)
+}
+
+func TestExperimentStats_Caps(t *testing.T) {
+	es := NewExperimentStats(1, 2, 0, 0)
+	touched := make(map[string]struct{})
+	add := func(exp, variant string) {
+		es.IncStats(&InputStats{Views: []string{"1"}, Experiments: map[string]string{exp: variant}}, touched)
+	}
+	add("e", "a")
+	add("e", "b")
+	add("e", "c")
+	add("other", "a")
+	add("e", "")
+	add("e", strings.Repeat("x", maxVariantLen+1))
+
+	data := es.GetData()
+	require.Len(t, data, 1)
+	assert.Len(t, data["e"], 2)
+	assert.NotContains(t, data["e"], "c")
+	assert.Equal(t, int64(4), es.Rejected())
+}
+
+func TestExperimentStats_ItemCap(t *testing.T) {
+	es := NewExperimentStats(10, 10, 2, 0)
+	es.IncStats(&InputStats{Views: []string{"1", "2", "3", "4"}, Clicks: []string{"4"}, Experiments: map[string]string{"e": "a"}}, map[string]struct{}{})
+
+	variants, _ := es.Get("e")
+	assert.Len(t, variants["a"], 3)
+	assert.Equal(t, VariantCounts{Views: 2, Clicks: 1}, variants["a"][ExperimentOtherItem], "items past the cap keep the totals exact")
+}
+
+func TestExperimentStats_ExpireIdle(t *testing.T) {
+	now := uint32(100000)
+	withClock(t, &now)
+	es := NewExperimentStats(1, 10, 0, time.Hour)
+	touched := map[string]struct{}{}
+	es.IncStats(&InputStats{Views: []string{"1"}, Experiments: map[string]string{"old": "a"}}, touched)
+	empty := es.MemoryUsage()
+
+	now += 1800
+	assert.Empty(t, es.Expire())
+	es.IncStats(&InputStats{Views: []string{"1"}, Experiments: map[string]string{"new": "a"}}, touched)
+	assert.Equal(t, int64(1), es.Rejected(), "the slot is taken")
+
+	now += 3601
+	assert.Equal(t, []string{"old"}, es.Expire())
+	assert.Zero(t, es.MemoryUsage())
+	assert.Positive(t, empty)
+	es.IncStats(&InputStats{Views: []string{"1"}, Experiments: map[string]string{"new": "a"}}, touched)
+	_, ok := es.Get("new")
+	assert.True(t, ok, "a retired experiment frees its slot")
+
+	restored := NewExperimentStats(1, 10, 0, time.Hour)
+	restored.PutData(es.GetData(), es.Seen())
+	assert.Equal(t, es.Seen(), restored.Seen())
+}
+
+func TestExperimentStats_PutDataAndMemory(t *testing.T) {
+	es := NewExperimentStats(10, 10, 0, 0)
+	assert.Zero(t, es.MemoryUsage())
+
+	es.PutData(map[string]map[string]map[int]VariantCounts{
+		"e": {"a": {1: {Views: 10, Clicks: 2}}, "b": {1: {Views: 8, Clicks: 4}}},
+	}, nil)
+	used := es.MemoryUsage()
+	assert.Positive(t, used)
+
+	variants, ok := es.Get("e")
+	require.True(t, ok)
+	assert.Equal(t, VariantCounts{Views: 10, Clicks: 2}, variants["a"][1])
+
+	// Returned maps are copies.
+	variants["a"][1] = VariantCounts{}
+	again, _ := es.Get("e")
+	assert.Equal(t, int64(10), again["a"][1].Views)
+
+	es.IncStats(&InputStats{Views: []string{"2"}, Experiments: map[string]string{"e": "a"}}, map[string]struct{}{})
+	assert.Greater(t, es.MemoryUsage(), used)
+}
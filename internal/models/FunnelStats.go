@@ -0,0 +1,260 @@
+package models
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+)
+
+const (
+	// Built-in step types; any other step name matches InputStats.Events.
+	FunnelStepView  = "view"
+	FunnelStepClick = "click"
+
+	// maxFunnelJourneys caps the journeys in progress per funnel; first
+	// steps beyond it are not counted until older journeys expire.
+	maxFunnelJourneys = 1000000
+)
+
+var (
+	journeyEntryBytes = int64(unsafe.Sizeof(int(0))+unsafe.Sizeof(journey{})) + mapEntryOverhead
+	funnelFpBytes     = int64(unsafe.Sizeof("")+unsafe.Sizeof(map[int]journey{})) + mapEntryOverhead
+	funnelItemBytes   = int64(unsafe.Sizeof(int(0))+unsafe.Sizeof([]int64{})) + mapEntryOverhead
+)
+
+// FunnelData is the persisted form of a funnel: per item the number of
+// journeys that reached each step, and the journeys still in progress by
+// fingerprint and item.
+type FunnelData struct {
+	Counts   map[int][]int64                  `json:"counts"`
+	Journeys map[string]map[int]FunnelJourney `json:"journeys,omitempty"`
+}
+
+// FunnelJourney is the number of steps a fingerprint completed for an item
+// and when it took the first one (Unix seconds).
+type FunnelJourney struct {
+	Step    int    `json:"step"`
+	Started uint32 `json:"started"`
+}
+
+type journey struct {
+	step    uint8
+	started uint32
+}
+
+// FunnelStats counts how many fingerprints pass through an ordered list of
+// event types per item. A journey starts with the first step and advances
+// only on the next step in order; it expires window after its first step,
+// after which the fingerprint may start a new journey for the item.
+type FunnelStats struct {
+	mu        sync.RWMutex
+	steps     []string
+	window    uint32
+	counts    map[int][]int64
+	journeys  map[string]map[int]journey
+	active    int
+	nextSweep uint32
+	bytes     int64
+}
+
+func NewFunnelStats(steps []string, window time.Duration) *FunnelStats {
+	return &FunnelStats{
+		steps:    steps,
+		window:   uint32(max(window/time.Second, 1)),
+		counts:   make(map[int][]int64),
+		journeys: make(map[string]map[int]journey),
+	}
+}
+
+// Steps returns the step names in funnel order.
+func (fs *FunnelStats) Steps() []string {
+	return fs.steps
+}
+
+// Window returns the completion window.
+func (fs *FunnelStats) Window() time.Duration {
+	return time.Duration(fs.window) * time.Second
+}
+
+// stepIDs returns the item IDs an event carries for a step type.
+func stepIDs(val *InputStats, step string) []string {
+	switch step {
+	case FunnelStepView:
+		return val.Views
+	case FunnelStepClick:
+		return val.Clicks
+	}
+	return val.Events[step]
+}
+
+// Observe advances the journeys of the event's fingerprint and reports
+// whether any step count changed. Steps are applied in funnel order, so one
+// event may carry several consecutive steps. Events without a fingerprint
+// are ignored. The window is measured from the time the events were
+// received, not folded.
+func (fs *FunnelStats) Observe(val *InputStats) bool {
+	if val == nil || val.Fingerprint == "" {
+		return false
+	}
+	now := val.receivedAt()
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	changed := false
+	for i, step := range fs.steps {
+		parseIDs(stepIDs(val, step), func(id int) {
+			if fs.advance(val.Fingerprint, id, i, now) {
+				changed = true
+			}
+		})
+	}
+	return changed
+}
+
+// advance records step i of a journey. Callers must hold mu.
+func (fs *FunnelStats) advance(fp string, id, i int, now uint32) bool {
+	items := fs.journeys[fp]
+	j, ok := items[id]
+	if ok && now > j.started && now-j.started > fs.window {
+		fs.dropJourney(fp, items, id)
+		items, ok = fs.journeys[fp], false
+	}
+	switch {
+	case i == 0 && !ok:
+		if fs.active >= maxFunnelJourneys {
+			return false
+		}
+		if items == nil {
+			items = make(map[int]journey)
+			fs.journeys[fp] = items
+			fs.bytes += funnelFpBytes + int64(len(fp))
+		}
+		items[id] = journey{step: 1, started: now}
+		fs.active++
+		fs.bytes += journeyEntryBytes
+	case ok && int(j.step) == i:
+		j.step++
+		items[id] = j
+	default:
+		return false
+	}
+
+	counts, ok := fs.counts[id]
+	if !ok {
+		counts = make([]int64, len(fs.steps))
+		fs.counts[id] = counts
+		fs.bytes += funnelItemBytes + int64(len(fs.steps))*8
+	}
+	counts[i]++
+	return true
+}
+
+// dropJourney removes one journey. Callers must hold mu.
+func (fs *FunnelStats) dropJourney(fp string, items map[int]journey, id int) {
+	delete(items, id)
+	fs.active--
+	fs.bytes -= journeyEntryBytes
+	if len(items) == 0 {
+		delete(fs.journeys, fp)
+		fs.bytes -= funnelFpBytes + int64(len(fp))
+	}
+}
+
+// Expire drops journeys whose window has passed. The sweep walks every
+// journey, so it runs at most once per quarter window.
+func (fs *FunnelStats) Expire() {
+	now := clock()
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if now < fs.nextSweep {
+		return
+	}
+	fs.nextSweep = now + max(fs.window/4, 1)
+	for fp, items := range fs.journeys {
+		for id, j := range items {
+			if now > j.started && now-j.started > fs.window {
+				fs.dropJourney(fp, items, id)
+			}
+		}
+	}
+}
+
+// Counts returns a copy of the per-item step counts.
+func (fs *FunnelStats) Counts() map[int][]int64 {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	out := make(map[int][]int64, len(fs.counts))
+	for id, counts := range fs.counts {
+		out[id] = append([]int64(nil), counts...)
+	}
+	return out
+}
+
+// Journeys returns the number of journeys in progress or completed within
+// their window.
+func (fs *FunnelStats) Journeys() int {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.active
+}
+
+// MemoryUsage returns the approximate number of bytes held by counts and
+// journeys.
+func (fs *FunnelStats) MemoryUsage() int64 {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.bytes
+}
+
+// GetData returns the counts and journeys in their persisted form.
+func (fs *FunnelStats) GetData() *FunnelData {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	data := &FunnelData{
+		Counts:   make(map[int][]int64, len(fs.counts)),
+		Journeys: make(map[string]map[int]FunnelJourney, len(fs.journeys)),
+	}
+	for id, counts := range fs.counts {
+		data.Counts[id] = append([]int64(nil), counts...)
+	}
+	for fp, items := range fs.journeys {
+		out := make(map[int]FunnelJourney, len(items))
+		for id, j := range items {
+			out[id] = FunnelJourney{Step: int(j.step), Started: j.started}
+		}
+		data.Journeys[fp] = out
+	}
+	return data
+}
+
+// PutData replaces counts and journeys. Counts recorded for a different
+// number of steps are resized, and journeys beyond the last step dropped.
+func (fs *FunnelStats) PutData(data *FunnelData) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.counts = make(map[int][]int64, len(data.Counts))
+	fs.journeys = make(map[string]map[int]journey, len(data.Journeys))
+	fs.active = 0
+	fs.bytes = 0
+	for id, counts := range data.Counts {
+		resized := make([]int64, len(fs.steps))
+		copy(resized, counts)
+		fs.counts[id] = resized
+		fs.bytes += funnelItemBytes + int64(len(fs.steps))*8
+	}
+	for fp, items := range data.Journeys {
+		restored := make(map[int]journey, len(items))
+		for id, j := range items {
+			if j.Step < 1 || j.Step > len(fs.steps) || fs.active >= maxFunnelJourneys {
+				continue
+			}
+			restored[id] = journey{step: uint8(j.Step), started: j.Started}
+			fs.active++
+			fs.bytes += journeyEntryBytes
+		}
+		if len(restored) > 0 {
+			fs.journeys[fp] = restored
+			fs.bytes += funnelFpBytes + int64(len(fp))
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSubscribeFunnel() *FunnelStats {
+	return NewFunnelStats([]string{FunnelStepView, FunnelStepClick, "subscribe"}, time.Minute)
+}
+
+func TestFunnelStats_StepsInOrder(t *testing.T) {
+	now := uint32(1000)
+	withClock(t, &now)
+	fs := newSubscribeFunnel()
+
+	assert.True(t, fs.Observe(&InputStats{Fingerprint: "a", Views: []string{"1"}}))
+	assert.True(t, fs.Observe(&InputStats{Fingerprint: "a", Clicks: []string{"1"}}))
+	assert.True(t, fs.Observe(&InputStats{Fingerprint: "a", Events: map[string][]string{"subscribe": {"1"}}}))
+
+	// Out of order: b subscribes without a click, c clicks without a view.
+	fs.Observe(&InputStats{Fingerprint: "b", Views: []string{"1"}})
+	assert.False(t, fs.Observe(&InputStats{Fingerprint: "b", Events: map[string][]string{"subscribe": {"1"}}}))
+	assert.False(t, fs.Observe(&InputStats{Fingerprint: "c", Clicks: []string{"1"}}))
+
+	assert.Equal(t, map[int][]int64{1: {2, 1, 1}}, fs.Counts())
+}
+
+func TestFunnelStats_WindowUsesReceiveTime(t *testing.T) {
+	now := uint32(10000)
+	withClock(t, &now)
+	fs := newSubscribeFunnel()
+
+	// Both events are folded at the same time, but were received two
+	// minutes apart, past the one-minute window.
+	fs.Observe(&InputStats{Fingerprint: "a", Views: []string{"1"}, Received: now - 150})
+	assert.False(t, fs.Observe(&InputStats{Fingerprint: "a", Clicks: []string{"1"}, Received: now - 30}))
+	fs.Observe(&InputStats{Fingerprint: "b", Views: []string{"1"}, Received: now - 150})
+	assert.True(t, fs.Observe(&InputStats{Fingerprint: "b", Clicks: []string{"1"}, Received: now - 120}))
+
+	assert.Equal(t, map[int][]int64{1: {2, 1, 0}}, fs.Counts())
+}
+
+func TestFunnelStats_SeveralStepsInOneEvent(t *testing.T) {
+	fs := newSubscribeFunnel()
+	fs.Observe(&InputStats{Fingerprint: "a", Views: []string{"1", "2"}, Clicks: []string{"1"}})
+
+	assert.Equal(t, map[int][]int64{1: {1, 1, 0}, 2: {1, 0, 0}}, fs.Counts())
+}
+
+func TestFunnelStats_IgnoresRepeatsAndAnonymous(t *testing.T) {
+	fs := newSubscribeFunnel()
+	fs.Observe(&InputStats{Fingerprint: "a", Views: []string{"1"}})
+	assert.False(t, fs.Observe(&InputStats{Fingerprint: "a", Views: []string{"1"}}))
+	fs.Observe(&InputStats{Fingerprint: "a", Clicks: []string{"1"}})
+	assert.False(t, fs.Observe(&InputStats{Fingerprint: "a", Clicks: []string{"1"}}))
+	assert.False(t, fs.Observe(&InputStats{Views: []string{"1"}}))
+	assert.False(t, fs.Observe(nil))
+
+	assert.Equal(t, map[int][]int64{1: {1, 1, 0}}, fs.Counts())
+}
+
+func TestFunnelStats_CompletionWindow(t *testing.T) {
+	now := uint32(1000)
+	withClock(t, &now)
+	fs := newSubscribeFunnel()
+
+	fs.Observe(&InputStats{Fingerprint: "a", Views: []string{"1"}})
+	now += 61
+	assert.False(t, fs.Observe(&InputStats{Fingerprint: "a", Clicks: []string{"1"}}), "click after the window")
+
+	// The expired journey is replaced by a new one.
+	assert.True(t, fs.Observe(&InputStats{Fingerprint: "a", Views: []string{"1"}}))
+	assert.Equal(t, map[int][]int64{1: {2, 0, 0}}, fs.Counts())
+}
+
+func TestFunnelStats_ExpireFreesJourneys(t *testing.T) {
+	now := uint32(1000)
+	withClock(t, &now)
+	fs := newSubscribeFunnel()
+	fs.Observe(&InputStats{Fingerprint: "a", Views: []string{"1", "2"}})
+	fs.Observe(&InputStats{Fingerprint: "b", Views: []string{"1"}})
+	require.Equal(t, 3, fs.Journeys())
+	withJourneys := fs.MemoryUsage()
+
+	now += 30
+	fs.Observe(&InputStats{Fingerprint: "c", Views: []string{"1"}})
+	now += 40
+	fs.Expire()
+
+	assert.Equal(t, 1, fs.Journeys(), "only c is still within its window")
+	assert.Less(t, fs.MemoryUsage(), withJourneys)
+	assert.Equal(t, int64(3), fs.Counts()[1][0], "counts outlive their journeys")
+}
+
+func TestFunnelStats_ExpireKeepsLaterJourneys(t *testing.T) {
+	now := uint32(1000)
+	withClock(t, &now)
+	fs := newSubscribeFunnel()
+
+	// Received after the sweep's clock reading, e.g. restored from a host
+	// whose clock ran ahead; now-started must not wrap around.
+	fs.Observe(&InputStats{Fingerprint: "a", Views: []string{"1"}, Received: now + 5})
+	fs.Expire()
+
+	assert.Equal(t, 1, fs.Journeys())
+}
+
+func TestFunnelStats_PutData(t *testing.T) {
+	now := uint32(1000)
+	withClock(t, &now)
+	fs := newSubscribeFunnel()
+	fs.Observe(&InputStats{Fingerprint: "a", Views: []string{"1"}})
+	data := fs.GetData()
+	assert.Equal(t, FunnelJourney{Step: 1, Started: 1000}, data.Journeys["a"][1])
+
+	restored := newSubscribeFunnel()
+	restored.PutData(data)
+	assert.Equal(t, fs.MemoryUsage(), restored.MemoryUsage())
+	assert.True(t, restored.Observe(&InputStats{Fingerprint: "a", Clicks: []string{"1"}}), "restored journeys continue")
+	assert.Equal(t, map[int][]int64{1: {1, 1, 0}}, restored.Counts())
+
+	// Data from a funnel with different steps is resized.
+	short := NewFunnelStats([]string{FunnelStepView, FunnelStepClick}, time.Minute)
+	short.PutData(&FunnelData{
+		Counts:   map[int][]int64{1: {5, 3, 1}},
+		Journeys: map[string]map[int]FunnelJourney{"a": {1: {Step: 3, Started: 1000}, 2: {Step: 1, Started: 1000}}},
+	})
+	assert.Equal(t, map[int][]int64{1: {5, 3}}, short.Counts())
+	assert.Equal(t, 1, short.Journeys())
+}
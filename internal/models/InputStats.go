@@ -1,8 +1,49 @@
 package models
 
 type InputStats struct {
-	Fingerprint string   `json:"f"`
-	Clicks      []string `json:"c"`
-	Views       []string `json:"v"`
-	Channel     string   `json:"ch"`
+	Fingerprint string              `json:"f"`
+	Clicks      []string            `json:"c"`
+	Views       []string            `json:"v"`
+	Channel     string              `json:"ch"`
+	Dims        map[string]string   `json:"dims,omitempty"`
+	Experiments map[string]string   `json:"exp,omitempty"` // experiment ID -> variant
+	Events      map[string][]string `json:"e,omitempty"`   // custom event type -> item IDs
+	Values      map[string]float64  `json:"val,omitempty"` // item ID -> value, e.g. revenue
+	Positions   map[string]int      `json:"pos,omitempty"` // item ID -> 1-based slot it was shown in
+	Received    uint32              `json:"-"`             // Unix seconds the event was buffered, 0 if never
+}
+
+// MarkReceived stamps the event with the current time.
+func (s *InputStats) MarkReceived() {
+	s.Received = clock()
+}
+
+// receivedAt returns when the event was buffered, or now for events that
+// never were.
+func (s *InputStats) receivedAt() uint32 {
+	if s.Received != 0 {
+		return s.Received
+	}
+	return clock()
+}
+
+// ItemIDs returns the distinct valid IDs of the viewed and clicked items.
+func (s *InputStats) ItemIDs() []int {
+	var ids []int
+	seen := make(map[int]struct{}, len(s.Views)+len(s.Clicks))
+	add := func(id int) {
+		if _, ok := seen[id]; ok {
+			return
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+	parseIDs(s.Views, add)
+	parseIDs(s.Clicks, add)
+	return ids
+}
+
+// EachView calls fn for every valid viewed item ID, repeats included.
+func (s *InputStats) EachView(fn func(id int)) {
+	parseIDs(s.Views, fn)
 }
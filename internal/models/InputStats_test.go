@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -27,3 +28,19 @@ func TestInputStats_UnmarshalEmpty(t *testing.T) {
 	assert.Nil(t, is.Views)
 	assert.Empty(t, is.Channel)
 }
+
+func TestInputStats_ItemIDs(t *testing.T) {
+	is := InputStats{Views: []string{"1", "2", "x", ""}, Clicks: []string{"2", "3"}}
+	assert.Equal(t, []int{1, 2, 3}, is.ItemIDs())
+	assert.Nil(t, (&InputStats{}).ItemIDs())
+}
+
+func TestInputStats_ItemIDsLargeEvent(t *testing.T) {
+	views := make([]string, 0, 200000)
+	for i := 0; i < 100000; i++ {
+		views = append(views, strconv.Itoa(i), strconv.Itoa(i))
+	}
+	ids := (&InputStats{Views: views}).ItemIDs()
+	require.Len(t, ids, 100000)
+	assert.Equal(t, 99999, ids[len(ids)-1])
+}
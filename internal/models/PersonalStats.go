@@ -1,82 +1,214 @@
 package models
 
-import "sync"
+import (
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
 
 const maxFingerprints = 100000
 
+// PersonalStats holds per-fingerprint statistics. Each fingerprint is stored
+// as a sorted slice of records under the single store lock, instead of a
+// Statistic with its own mutex and map.
 type PersonalStats struct {
-	mu   sync.RWMutex          `json:"-"`
-	Data map[string]*Statistic `json:"data"`
+	mu    sync.RWMutex
+	fps   map[string]*fpRecords
+	bytes atomic.Int64
+	ids   []int // IncStats buffer, guarded by mu
+}
+
+func NewPersonalStats() *PersonalStats {
+	return &PersonalStats{fps: make(map[string]*fpRecords)}
 }
 
 func (ps *PersonalStats) IncStats(val *InputStats) {
 	if val == nil {
 		return
 	}
+	now := clock()
 
-	// Fast path: fingerprint already exists (read lock only)
-	ps.mu.RLock()
-	stat, ok := ps.Data[val.Fingerprint]
-	ps.mu.RUnlock()
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
 
+	recs, ok := ps.fps[val.Fingerprint]
+	var before int64
 	if ok {
-		stat.IncStats(val)
-		return
-	}
-
-	// Slow path: write lock with double-check for new fingerprint
-	ps.mu.Lock()
-	stat, ok = ps.Data[val.Fingerprint]
-	if !ok {
-		if len(ps.Data) >= maxFingerprints {
-			ps.mu.Unlock()
+		before = recs.bytes(val.Fingerprint)
+	} else {
+		if len(ps.fps) >= maxFingerprints {
 			return
 		}
-		stat = &Statistic{
-			Data: make(map[int]*StatRecord),
-		}
-		ps.Data[val.Fingerprint] = stat
+		recs = &fpRecords{}
+		ps.fps[val.Fingerprint] = recs
 	}
-	ps.mu.Unlock()
 
-	stat.IncStats(val)
+	recs.touched = now
+	// Views and clicks are each sorted and merged into the records in one
+	// pass; views go first, since halving also halves the clicks.
+	ps.ids = ps.parseSorted(val.Views)
+	recs.items.insert(ps.ids)
+	for _, id := range ps.ids {
+		r := recs.items.get(id)
+		r.addView()
+		r.touched = now
+	}
+	ps.ids = ps.parseSorted(val.Clicks)
+	recs.items.insert(ps.ids)
+	for _, id := range ps.ids {
+		r := recs.items.get(id)
+		r.addClick()
+		r.touched = now
+	}
+	ps.bytes.Add(recs.bytes(val.Fingerprint) - before)
 }
 
+// parseSorted parses ids into the reused ID buffer and sorts them. Callers
+// must hold mu for writing.
+func (ps *PersonalStats) parseSorted(ids []string) []int {
+	out := ps.ids[:0]
+	parseIDs(ids, func(id int) {
+		out = append(out, id)
+	})
+	slices.Sort(out)
+	return out
+}
+
+// Get returns a copy of the fingerprint's statistics.
 func (ps *PersonalStats) Get(key string) (*Statistic, bool) {
 	ps.mu.RLock()
 	defer ps.mu.RUnlock()
-	val, ok := ps.Data[key]
-	return val, ok
+	recs, ok := ps.fps[key]
+	if !ok {
+		return nil, false
+	}
+	return &Statistic{Data: recs.items.toMap()}, true
+}
+
+// Partition splits ids into those the fingerprint has not touched yet and
+// returns them along with up to historySize of its most recently touched
+// items. At most historySize fresh IDs are returned, in event order, so that
+// pairing them stays bounded however many IDs one event carries. It must be
+// called before IncStats records the same event.
+func (ps *PersonalStats) Partition(key string, ids []int, historySize int) (fresh, history []int) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	recs, ok := ps.fps[key]
+	if !ok {
+		return ids[:min(len(ids), historySize)], nil
+	}
+	for _, id := range ids {
+		if len(fresh) == historySize {
+			break
+		}
+		if _, seen := recs.items.find(id); !seen {
+			fresh = append(fresh, id)
+		}
+	}
+	if len(fresh) == 0 {
+		return nil, nil
+	}
+
+	items := recs.items
+	if len(items) > historySize {
+		items = make(itemRecords, len(recs.items))
+		copy(items, recs.items)
+		sort.Slice(items, func(i, j int) bool { return items[i].touched > items[j].touched })
+		items = items[:historySize]
+	}
+	history = make([]int, len(items))
+	for i, v := range items {
+		history[i] = v.id
+	}
+	return fresh, history
 }
 
 func (ps *PersonalStats) Set(key string, val *Statistic) {
+	recs := &fpRecords{items: itemRecordsFromMap(val.GetData()), touched: clock()}
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
-	ps.Data[key] = val
+	if old, ok := ps.fps[key]; ok {
+		ps.bytes.Add(-old.bytes(key))
+	}
+	ps.fps[key] = recs
+	ps.bytes.Add(recs.bytes(key))
 }
 
 func (ps *PersonalStats) Len() int {
 	ps.mu.RLock()
 	defer ps.mu.RUnlock()
-	return len(ps.Data)
+	return len(ps.fps)
+}
+
+// MemoryUsage returns the approximate number of bytes held by the store.
+func (ps *PersonalStats) MemoryUsage() int64 {
+	return ps.bytes.Load()
 }
 
 func (ps *PersonalStats) GetData() map[string]*Statistic {
 	ps.mu.RLock()
 	defer ps.mu.RUnlock()
 
-	copyMap := make(map[string]*Statistic)
-	for k, v := range ps.Data {
+	copyMap := make(map[string]*Statistic, len(ps.fps))
+	for k, v := range ps.fps {
 		copyMap[k] = &Statistic{
-			Data: v.GetData(),
+			Data: v.items.toMap(),
 		}
 	}
 	return copyMap
 }
 
 func (ps *PersonalStats) PutData(stats map[string]*Statistic) {
+	now := clock()
+	compact := make(map[string]*fpRecords, len(stats))
+	var total int64
+	for k, v := range stats {
+		if v == nil {
+			continue
+		}
+		recs := &fpRecords{items: itemRecordsFromMap(v.GetData()), touched: now}
+		compact[k] = recs
+		total += recs.bytes(k)
+	}
+
 	ps.mu.Lock()
 	defer ps.mu.Unlock()
+	ps.fps = compact
+	ps.bytes.Store(total)
+}
 
-	ps.Data = stats
+// AgeHistogram returns the accounted bytes per fingerprint last-update stamp.
+func (ps *PersonalStats) AgeHistogram() map[uint32]int64 {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	var hist map[uint32]int64
+	for k, v := range ps.fps {
+		hist = addAge(hist, v.touched, v.bytes(k))
+	}
+	return hist
+}
+
+// EvictBefore removes fingerprints last updated before stamp until at least
+// limit bytes are freed, and returns the number removed and bytes freed.
+func (ps *PersonalStats) EvictBefore(stamp uint32, limit int64) (int, int64) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	evicted := 0
+	var freed int64
+	for k, v := range ps.fps {
+		if freed >= limit {
+			break
+		}
+		if v.touched < stamp {
+			freed += v.bytes(k)
+			delete(ps.fps, k)
+			evicted++
+		}
+	}
+	ps.bytes.Add(-freed)
+	return evicted, freed
 }
@@ -2,15 +2,17 @@ package models
 
 import (
 	"fmt"
+	"runtime"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func newPersonalStats() *PersonalStats {
-	return &PersonalStats{Data: make(map[string]*Statistic)}
+	return NewPersonalStats()
 }
 
 func TestPersonalStats_SetAndGet(t *testing.T) {
@@ -87,6 +89,22 @@ func TestPersonalStats_IncStats_ExistingFingerprint(t *testing.T) {
 	assert.Equal(t, 2, rec.Views)
 }
 
+func TestPersonalStats_IncStats_UnsortedRepeats(t *testing.T) {
+	ps := newPersonalStats()
+	ps.IncStats(&InputStats{Fingerprint: "fp1", Views: []string{"9", "1", "9", "x", "5"}, Clicks: []string{"5", "7", "5"}})
+	ps.IncStats(&InputStats{Fingerprint: "fp1", Views: []string{"3", "1"}})
+
+	val, ok := ps.Get("fp1")
+	require.True(t, ok)
+	assert.Equal(t, map[int]*StatRecord{
+		1: {Views: 2},
+		3: {Views: 1},
+		5: {Views: 1, Clicks: 2},
+		7: {Clicks: 1},
+		9: {Views: 2},
+	}, val.Data)
+}
+
 func TestPersonalStats_IncStats_Nil(t *testing.T) {
 	ps := newPersonalStats()
 	ps.IncStats(nil) // should not panic
@@ -98,7 +116,7 @@ func TestPersonalStats_MaxFingerprints(t *testing.T) {
 
 	// Fill to max
 	for i := 0; i < maxFingerprints; i++ {
-		ps.Data[fmt.Sprintf("fp%d", i)] = &Statistic{Data: make(map[int]*StatRecord)}
+		ps.Set(fmt.Sprintf("fp%d", i), &Statistic{Data: make(map[int]*StatRecord)})
 	}
 	assert.Equal(t, maxFingerprints, ps.Len())
 
@@ -112,7 +130,7 @@ func TestPersonalStats_MaxFingerprints(t *testing.T) {
 func TestPersonalStats_MaxFingerprints_ExistingStillWorks(t *testing.T) {
 	ps := newPersonalStats()
 	for i := 0; i < maxFingerprints; i++ {
-		ps.Data[fmt.Sprintf("fp%d", i)] = &Statistic{Data: make(map[int]*StatRecord)}
+		ps.Set(fmt.Sprintf("fp%d", i), &Statistic{Data: make(map[int]*StatRecord)})
 	}
 
 	// Existing fingerprint should still get updates
@@ -148,3 +166,167 @@ func TestPersonalStats_ConcurrentAccess(t *testing.T) {
 	assert.Greater(t, ps.Len(), 0)
 	assert.LessOrEqual(t, ps.Len(), 10)
 }
+
+func TestPersonalStats_SetStoresCopy(t *testing.T) {
+	ps := newPersonalStats()
+	s := &Statistic{Data: map[int]*StatRecord{1: {Views: 5}}}
+	ps.Set("fp1", s)
+	s.Data[1].Views = 999
+
+	val, _ := ps.Get("fp1")
+	assert.Equal(t, 5, val.Data[1].Views)
+}
+
+func TestPersonalStats_PutDataSkipsNil(t *testing.T) {
+	ps := newPersonalStats()
+	ps.PutData(map[string]*Statistic{"fp1": nil, "fp2": {Data: map[int]*StatRecord{1: {Views: 1}}}})
+	assert.Equal(t, 1, ps.Len())
+}
+
+const (
+	layoutFingerprints = 20000
+	layoutItemsPerFP   = 10
+)
+
+func layoutInputs() []*InputStats {
+	inputs := make([]*InputStats, 0, layoutFingerprints)
+	for i := 0; i < layoutFingerprints; i++ {
+		views := make([]string, layoutItemsPerFP)
+		for j := range views {
+			views[j] = fmt.Sprint((i*7 + j*13) % 5000)
+		}
+		inputs = append(inputs, &InputStats{Fingerprint: fmt.Sprintf("fp%d", i), Views: views, Clicks: views[:2]})
+	}
+	return inputs
+}
+
+// benchmarkLayout builds a store and reports the heap objects and bytes it
+// keeps alive plus the duration of a full GC cycle with the store live.
+func benchmarkLayout(b *testing.B, build func([]*InputStats) any) {
+	inputs := layoutInputs()
+	var before, after runtime.MemStats
+	var live any
+	for i := 0; i < b.N; i++ {
+		live = nil
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+
+		live = build(inputs)
+
+		runtime.GC()
+		runtime.ReadMemStats(&after)
+		start := time.Now()
+		runtime.GC()
+		b.ReportMetric(float64(time.Since(start).Microseconds()), "gc-µs")
+		b.ReportMetric(float64(int64(after.HeapObjects)-int64(before.HeapObjects)), "heap-objects")
+		b.ReportMetric(float64(int64(after.HeapAlloc)-int64(before.HeapAlloc))/(1<<20), "heap-MB")
+	}
+	runtime.KeepAlive(live)
+}
+
+// BenchmarkPersonalStats_Layout measures the compact per-fingerprint layout.
+// Run with: go test -run '^$' -bench Layout ./internal/models/
+func BenchmarkPersonalStats_Layout(b *testing.B) {
+	benchmarkLayout(b, func(inputs []*InputStats) any {
+		ps := NewPersonalStats()
+		for _, in := range inputs {
+			ps.IncStats(in)
+		}
+		return ps
+	})
+}
+
+// BenchmarkPersonalStats_LegacyLayout is the previous layout for comparison:
+// one Statistic (mutex + map + a pointer per record) per fingerprint.
+func BenchmarkPersonalStats_LegacyLayout(b *testing.B) {
+	benchmarkLayout(b, func(inputs []*InputStats) any {
+		data := make(map[string]*Statistic)
+		for _, in := range inputs {
+			stat, ok := data[in.Fingerprint]
+			if !ok {
+				stat = &Statistic{Data: make(map[int]*StatRecord)}
+				data[in.Fingerprint] = stat
+			}
+			stat.IncStats(in)
+		}
+		return data
+	})
+}
+
+func TestPersonalStats_MemoryUsage(t *testing.T) {
+	ps := newPersonalStats()
+	ps.IncStats(&InputStats{Fingerprint: "fp1", Views: []string{"1", "2"}})
+	ps.IncStats(&InputStats{Fingerprint: "fp1", Views: []string{"3"}})
+
+	recs := ps.fps["fp1"]
+	assert.Equal(t, recs.bytes("fp1"), ps.MemoryUsage())
+
+	ps.Set("fp1", &Statistic{Data: map[int]*StatRecord{1: {Views: 1}}})
+	assert.Equal(t, ps.fps["fp1"].bytes("fp1"), ps.MemoryUsage())
+
+	ps.PutData(map[string]*Statistic{})
+	assert.Equal(t, int64(0), ps.MemoryUsage())
+}
+
+func TestPersonalStats_EvictBefore(t *testing.T) {
+	stamp := uint32(100)
+	withClock(t, &stamp)
+
+	ps := newPersonalStats()
+	ps.IncStats(&InputStats{Fingerprint: "old", Views: []string{"1"}})
+	stamp = 200
+	ps.IncStats(&InputStats{Fingerprint: "new", Views: []string{"1"}})
+	oldBytes := ps.fps["old"].bytes("old")
+
+	hist := ps.AgeHistogram()
+	assert.Equal(t, oldBytes, hist[100])
+
+	n, freed := ps.EvictBefore(200, 1<<40)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, oldBytes, freed)
+	_, ok := ps.Get("old")
+	assert.False(t, ok)
+	_, ok = ps.Get("new")
+	assert.True(t, ok)
+	assert.Equal(t, ps.fps["new"].bytes("new"), ps.MemoryUsage())
+}
+
+func TestPersonalStats_Partition(t *testing.T) {
+	ps := NewPersonalStats()
+	now := uint32(100)
+	withClock(t, &now)
+
+	ps.IncStats(&InputStats{Fingerprint: "fp1", Views: []string{"1"}})
+	now++
+	ps.IncStats(&InputStats{Fingerprint: "fp1", Views: []string{"2"}})
+	now++
+	ps.IncStats(&InputStats{Fingerprint: "fp1", Views: []string{"3"}})
+
+	fresh, history := ps.Partition("fp1", []int{2, 4}, 2)
+	assert.Equal(t, []int{4}, fresh)
+	assert.ElementsMatch(t, []int{2, 3}, history)
+
+	fresh, history = ps.Partition("fp1", []int{1, 2}, 2)
+	assert.Nil(t, fresh)
+	assert.Nil(t, history)
+
+	fresh, history = ps.Partition("unknown", []int{1, 2}, 2)
+	assert.Equal(t, []int{1, 2}, fresh)
+	assert.Nil(t, history)
+}
+
+func TestPersonalStats_PartitionCapsFresh(t *testing.T) {
+	ps := NewPersonalStats()
+	ps.IncStats(&InputStats{Fingerprint: "fp1", Views: []string{"1"}})
+
+	ids := make([]int, 10000)
+	for i := range ids {
+		ids[i] = i
+	}
+	fresh, history := ps.Partition("fp1", ids, 3)
+	assert.Equal(t, []int{0, 2, 3}, fresh, "at most historySize fresh IDs, in event order")
+	assert.Equal(t, []int{1}, history)
+
+	fresh, _ = ps.Partition("unknown", ids, 3)
+	assert.Equal(t, []int{0, 1, 2}, fresh)
+}
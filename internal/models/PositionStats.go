@@ -0,0 +1,132 @@
+package models
+
+import "sync"
+
+const (
+	// positionThreshold is the slot 1 view count above which every slot
+	// counter is halved, so propensities follow changes in page layout.
+	positionThreshold = 1 << 20
+	// minPropensity bounds the correction for rarely clicked deep slots.
+	minPropensity = 0.01
+)
+
+// PositionData is the persisted form of PositionStats: decayed views and
+// clicks per slot, index 0 being slot 1.
+type PositionData struct {
+	Views  []int64 `json:"views"`
+	Clicks []int64 `json:"clicks"`
+}
+
+// PositionStats learns how likely an item in each slot is to be looked at,
+// relative to slot 1, from the click-through rate of every slot over all
+// items of a channel. Slots past maxPositions are counted in the last one.
+type PositionStats struct {
+	mu         sync.RWMutex
+	views      []int64
+	clicks     []int64
+	minViews   int64
+	propensity []float64
+}
+
+func NewPositionStats(maxPositions, minViews int) *PositionStats {
+	ps := &PositionStats{
+		views:      make([]int64, maxPositions),
+		clicks:     make([]int64, maxPositions),
+		minViews:   int64(minViews),
+		propensity: make([]float64, maxPositions),
+	}
+	for i := range ps.propensity {
+		ps.propensity[i] = 1
+	}
+	return ps
+}
+
+// slot maps a 1-based position to a counter index, or -1 if it is invalid.
+func (ps *PositionStats) slot(pos int) int {
+	if pos < 1 {
+		return -1
+	}
+	return min(pos, len(ps.views)) - 1
+}
+
+// Observe counts the event's positioned views and clicks and reports whether
+// it had any.
+func (ps *PositionStats) Observe(val *InputStats) bool {
+	if val == nil || len(val.Positions) == 0 {
+		return false
+	}
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	observed := false
+	count := func(ids []string, counters []int64) {
+		for _, id := range ids {
+			if i := ps.slot(val.Positions[id]); i >= 0 {
+				counters[i]++
+				observed = true
+			}
+		}
+	}
+	count(val.Views, ps.views)
+	count(val.Clicks, ps.clicks)
+	if ps.views[0] > positionThreshold {
+		for i := range ps.views {
+			ps.views[i] = (ps.views[i] + 1) >> 1
+			ps.clicks[i] = (ps.clicks[i] + 1) >> 1
+		}
+	}
+	return observed
+}
+
+// Learn recomputes the propensities from the counters. A slot's propensity
+// is its click-through rate divided by that of slot 1, bounded to
+// [minPropensity, 1]; slots with fewer than minViews views inherit the
+// propensity of the slot above. All propensities are 1 until slot 1 has
+// minViews views and a click.
+func (ps *PositionStats) Learn() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	prev := 1.0
+	for i := range ps.propensity {
+		p := prev
+		if ps.views[0] < ps.minViews || ps.clicks[0] == 0 {
+			p = 1
+		} else if ps.views[i] >= ps.minViews {
+			top := float64(ps.clicks[0]) / float64(ps.views[0])
+			p = min(max(float64(ps.clicks[i])/float64(ps.views[i])/top, minPropensity), 1)
+		}
+		ps.propensity[i] = p
+		prev = p
+	}
+}
+
+// Propensity returns the learned propensity of a 1-based slot, or 1 for an
+// invalid one.
+func (ps *PositionStats) Propensity(pos int) float64 {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	if i := ps.slot(pos); i >= 0 {
+		return ps.propensity[i]
+	}
+	return 1
+}
+
+func (ps *PositionStats) GetData() *PositionData {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+	return &PositionData{
+		Views:  append([]int64(nil), ps.views...),
+		Clicks: append([]int64(nil), ps.clicks...),
+	}
+}
+
+// PutData restores the counters, truncated or padded to the configured
+// number of slots, and relearns the propensities.
+func (ps *PositionStats) PutData(data *PositionData) {
+	ps.mu.Lock()
+	clear(ps.views)
+	clear(ps.clicks)
+	copy(ps.views, data.Views)
+	copy(ps.clicks, data.Clicks)
+	ps.mu.Unlock()
+	ps.Learn()
+}
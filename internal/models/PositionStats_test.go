@@ -0,0 +1,89 @@
+package models
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// observeSlot records views impressions in slot pos, clicks of them clicked.
+func observeSlot(ps *PositionStats, pos, views, clicks int) {
+	positions := map[string]int{"1": pos}
+	for i := 0; i < views; i++ {
+		val := &InputStats{Views: []string{"1"}, Positions: positions}
+		if i < clicks {
+			val.Clicks = []string{"1"}
+		}
+		ps.Observe(val)
+	}
+}
+
+func TestPositionStats_Learn(t *testing.T) {
+	ps := NewPositionStats(3, 10)
+	observeSlot(ps, 1, 100, 20)
+	observeSlot(ps, 2, 100, 5)
+	observeSlot(ps, 3, 5, 1)
+	observeSlot(ps, 7, 5, 0)
+
+	assert.Equal(t, 1.0, ps.Propensity(2), "nothing learned before Learn")
+	ps.Learn()
+	assert.Equal(t, 1.0, ps.Propensity(1))
+	assert.InDelta(t, 0.25, ps.Propensity(2), 1e-9)
+	assert.InDelta(t, 0.5, ps.Propensity(3), 1e-9)
+	assert.InDelta(t, 0.5, ps.Propensity(9), 1e-9, "slots past the last are counted in it")
+	assert.Equal(t, 1.0, ps.Propensity(0))
+}
+
+func TestPositionStats_LearnBounds(t *testing.T) {
+	ps := NewPositionStats(4, 10)
+	observeSlot(ps, 2, 100, 50)
+	ps.Learn()
+	assert.Equal(t, 1.0, ps.Propensity(2), "no correction until slot 1 has enough views")
+
+	observeSlot(ps, 1, 100, 10)
+	observeSlot(ps, 3, 100, 0)
+	ps.Learn()
+	assert.Equal(t, 1.0, ps.Propensity(2), "capped at slot 1")
+	assert.Equal(t, minPropensity, ps.Propensity(3))
+	assert.Equal(t, minPropensity, ps.Propensity(4), "too few views, inherited from the slot above")
+}
+
+func TestPositionStats_ObserveIgnoresUnpositioned(t *testing.T) {
+	ps := NewPositionStats(2, 1)
+	assert.False(t, ps.Observe(&InputStats{Views: []string{"1"}}))
+	assert.False(t, ps.Observe(&InputStats{Views: []string{"1"}, Positions: map[string]int{"2": 1, "1": 0}}))
+	assert.True(t, ps.Observe(&InputStats{Views: []string{"1", "2"}, Positions: map[string]int{"2": 5}}))
+	assert.Equal(t, &PositionData{Views: []int64{0, 1}, Clicks: []int64{0, 0}}, ps.GetData())
+}
+
+func TestPositionStats_Halving(t *testing.T) {
+	ps := NewPositionStats(2, 1)
+	ps.PutData(&PositionData{Views: []int64{positionThreshold, 9}, Clicks: []int64{7, 3}})
+	ps.Observe(&InputStats{Views: []string{"1"}, Positions: map[string]int{"1": 1}})
+	assert.Equal(t, &PositionData{Views: []int64{positionThreshold/2 + 1, 5}, Clicks: []int64{4, 2}}, ps.GetData())
+}
+
+func TestPositionStats_PutDataResizes(t *testing.T) {
+	ps := NewPositionStats(2, 10)
+	ps.PutData(&PositionData{Views: []int64{100, 100, 100}, Clicks: []int64{10, 5, 1}})
+
+	assert.Equal(t, &PositionData{Views: []int64{100, 100}, Clicks: []int64{10, 5}}, ps.GetData())
+	assert.InDelta(t, 0.5, ps.Propensity(2), 1e-9, "relearned on restore")
+
+	ps.PutData(&PositionData{Views: []int64{1}})
+	assert.Equal(t, &PositionData{Views: []int64{1, 0}, Clicks: []int64{0, 0}}, ps.GetData())
+}
+
+func BenchmarkPositionStats_Observe(b *testing.B) {
+	ps := NewPositionStats(20, 1000)
+	val := &InputStats{Positions: map[string]int{}}
+	for i := 1; i <= 10; i++ {
+		id := strconv.Itoa(i)
+		val.Views = append(val.Views, id)
+		val.Positions[id] = i
+	}
+	for b.Loop() {
+		ps.Observe(val)
+	}
+}
@@ -0,0 +1,197 @@
+package models
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// relatedThreshold is the total neighbor weight of an item above which its
+// weights are halved, mirroring the trending decay of StatRecord.
+const relatedThreshold = 512
+
+var (
+	relatedItemBytes  = int64(unsafe.Sizeof(RelatedItem{}))
+	relatedEntryBytes = int64(unsafe.Sizeof(int(0))+unsafe.Sizeof(&neighborList{})+unsafe.Sizeof(neighborList{})) + mapEntryOverhead
+)
+
+// RelatedItem is a co-occurring item and the number of fingerprints (after
+// decay) that touched both items.
+type RelatedItem struct {
+	ID     int `json:"id"`
+	Weight int `json:"weight"`
+}
+
+type neighborList struct {
+	items []RelatedItem
+	total int
+}
+
+// add counts one more shared fingerprint for id. A full list replaces its
+// lightest neighbor, inheriting that weight (the Space-Saving scheme), so
+// frequent neighbors survive without tracking every pair.
+func (nl *neighborList) add(id, maxNeighbors int) {
+	nl.total++
+	for i := range nl.items {
+		if nl.items[i].ID == id {
+			nl.items[i].Weight++
+			nl.decay()
+			return
+		}
+	}
+	if len(nl.items) < maxNeighbors {
+		nl.items = append(nl.items, RelatedItem{ID: id, Weight: 1})
+		nl.decay()
+		return
+	}
+	lightest := 0
+	for i := range nl.items {
+		if nl.items[i].Weight < nl.items[lightest].Weight {
+			lightest = i
+		}
+	}
+	nl.items[lightest] = RelatedItem{ID: id, Weight: nl.items[lightest].Weight + 1}
+	nl.decay()
+}
+
+// decay halves all weights once the total exceeds relatedThreshold and
+// drops neighbors whose weight reaches zero.
+func (nl *neighborList) decay() {
+	if nl.total <= relatedThreshold {
+		return
+	}
+	kept := nl.items[:0]
+	nl.total = 0
+	for _, v := range nl.items {
+		v.Weight >>= 1
+		if v.Weight > 0 {
+			kept = append(kept, v)
+			nl.total += v.Weight
+		}
+	}
+	nl.items = kept
+}
+
+// sorted returns a copy of the neighbors ordered by weight, heaviest first.
+func (nl *neighborList) sorted() []RelatedItem {
+	out := make([]RelatedItem, len(nl.items))
+	copy(out, nl.items)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Weight != out[j].Weight {
+			return out[i].Weight > out[j].Weight
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// RelatedStats is a per-channel item co-occurrence index: for every item a
+// bounded list of items touched by the same fingerprints.
+type RelatedStats struct {
+	mu           sync.RWMutex
+	maxNeighbors int
+	data         map[int]*neighborList
+	bytes        atomic.Int64
+}
+
+func NewRelatedStats(maxNeighbors int) *RelatedStats {
+	return &RelatedStats{maxNeighbors: maxNeighbors, data: make(map[int]*neighborList)}
+}
+
+// Observe records that a fingerprint with the given history touched newIDs
+// for the first time. Every new item is paired with the history and with the
+// other new items. IDs whose neighbor lists changed are added to touched.
+func (rs *RelatedStats) Observe(newIDs, history []int, touched map[int]struct{}) {
+	if len(newIDs) == 0 || len(newIDs)+len(history) < 2 {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	for i, a := range newIDs {
+		for _, b := range history {
+			rs.pair(a, b, touched)
+		}
+		for _, b := range newIDs[i+1:] {
+			rs.pair(a, b, touched)
+		}
+	}
+}
+
+func (rs *RelatedStats) pair(a, b int, touched map[int]struct{}) {
+	if a == b {
+		return
+	}
+	rs.list(a).add(b, rs.maxNeighbors)
+	rs.list(b).add(a, rs.maxNeighbors)
+	touched[a] = struct{}{}
+	touched[b] = struct{}{}
+}
+
+func (rs *RelatedStats) list(id int) *neighborList {
+	nl, ok := rs.data[id]
+	if !ok {
+		nl = &neighborList{items: make([]RelatedItem, 0, min(rs.maxNeighbors, 4))}
+		rs.data[id] = nl
+		rs.bytes.Add(relatedEntryBytes + int64(rs.maxNeighbors)*relatedItemBytes)
+	}
+	return nl
+}
+
+// Get returns the neighbors of id ordered by weight, or nil.
+func (rs *RelatedStats) Get(id int) []RelatedItem {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	if nl, ok := rs.data[id]; ok && len(nl.items) > 0 {
+		return nl.sorted()
+	}
+	return nil
+}
+
+func (rs *RelatedStats) Len() int {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return len(rs.data)
+}
+
+// MemoryUsage returns the approximate number of bytes held by the index,
+// charging every list at its full capacity.
+func (rs *RelatedStats) MemoryUsage() int64 {
+	return rs.bytes.Load()
+}
+
+// GetData returns every non-empty neighbor list ordered by weight.
+func (rs *RelatedStats) GetData() map[int][]RelatedItem {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+
+	out := make(map[int][]RelatedItem, len(rs.data))
+	for id, nl := range rs.data {
+		if len(nl.items) > 0 {
+			out[id] = nl.sorted()
+		}
+	}
+	return out
+}
+
+// PutData replaces the index, truncating lists longer than maxNeighbors.
+func (rs *RelatedStats) PutData(data map[int][]RelatedItem) {
+	compact := make(map[int]*neighborList, len(data))
+	for id, items := range data {
+		nl := &neighborList{items: make([]RelatedItem, 0, rs.maxNeighbors)}
+		for _, v := range items {
+			if v.Weight <= 0 || len(nl.items) == rs.maxNeighbors {
+				continue
+			}
+			nl.items = append(nl.items, v)
+			nl.total += v.Weight
+		}
+		compact[id] = nl
+	}
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.data = compact
+	rs.bytes.Store(int64(len(compact)) * (relatedEntryBytes + int64(rs.maxNeighbors)*relatedItemBytes))
+}
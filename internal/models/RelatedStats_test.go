@@ -0,0 +1,93 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRelatedStats_ObservePairsBothWays(t *testing.T) {
+	rs := NewRelatedStats(10)
+	touched := make(map[int]struct{})
+
+	rs.Observe([]int{3}, []int{1, 2}, touched)
+
+	assert.Equal(t, []RelatedItem{{ID: 1, Weight: 1}, {ID: 2, Weight: 1}}, rs.Get(3))
+	assert.Equal(t, []RelatedItem{{ID: 3, Weight: 1}}, rs.Get(1))
+	assert.Equal(t, []RelatedItem{{ID: 3, Weight: 1}}, rs.Get(2))
+	assert.Len(t, touched, 3)
+}
+
+func TestRelatedStats_ObservePairsNewItemsTogether(t *testing.T) {
+	rs := NewRelatedStats(10)
+	rs.Observe([]int{1, 2}, nil, make(map[int]struct{}))
+
+	assert.Equal(t, []RelatedItem{{ID: 2, Weight: 1}}, rs.Get(1))
+	assert.Equal(t, []RelatedItem{{ID: 1, Weight: 1}}, rs.Get(2))
+}
+
+func TestRelatedStats_ObserveSingleItemNoop(t *testing.T) {
+	rs := NewRelatedStats(10)
+	touched := make(map[int]struct{})
+	rs.Observe([]int{1}, nil, touched)
+
+	assert.Equal(t, 0, rs.Len())
+	assert.Empty(t, touched)
+	assert.Zero(t, rs.MemoryUsage())
+}
+
+func TestRelatedStats_WeightsCountSharedFingerprints(t *testing.T) {
+	rs := NewRelatedStats(10)
+	for i := 0; i < 3; i++ {
+		rs.Observe([]int{2}, []int{1}, make(map[int]struct{}))
+	}
+	rs.Observe([]int{3}, []int{1}, make(map[int]struct{}))
+
+	assert.Equal(t, []RelatedItem{{ID: 2, Weight: 3}, {ID: 3, Weight: 1}}, rs.Get(1))
+}
+
+func TestRelatedStats_BoundedNeighbors(t *testing.T) {
+	rs := NewRelatedStats(2)
+	rs.Observe([]int{2}, []int{1}, make(map[int]struct{}))
+	rs.Observe([]int{2}, []int{1}, make(map[int]struct{}))
+	rs.Observe([]int{3}, []int{1}, make(map[int]struct{}))
+	rs.Observe([]int{4}, []int{1}, make(map[int]struct{}))
+
+	got := rs.Get(1)
+	require.Len(t, got, 2)
+	assert.Equal(t, RelatedItem{ID: 2, Weight: 2}, got[0])
+	// 4 replaced the lightest neighbor and inherited its weight
+	assert.Equal(t, RelatedItem{ID: 4, Weight: 2}, got[1])
+}
+
+func TestRelatedStats_Decay(t *testing.T) {
+	rs := NewRelatedStats(10)
+	for i := 0; i < relatedThreshold; i++ {
+		rs.Observe([]int{2}, []int{1}, make(map[int]struct{}))
+	}
+	rs.Observe([]int{3}, []int{1}, make(map[int]struct{}))
+
+	// the threshold crossing halved 2 and dropped the fresh weight of 3
+	assert.Equal(t, []RelatedItem{{ID: 2, Weight: relatedThreshold / 2}}, rs.Get(1))
+}
+
+func TestRelatedStats_GetMissing(t *testing.T) {
+	rs := NewRelatedStats(10)
+	assert.Nil(t, rs.Get(42))
+}
+
+func TestRelatedStats_PutDataAndGetData(t *testing.T) {
+	rs := NewRelatedStats(2)
+	rs.PutData(map[int][]RelatedItem{
+		1: {{ID: 2, Weight: 5}, {ID: 3, Weight: 0}, {ID: 4, Weight: 1}, {ID: 5, Weight: 1}},
+		6: {},
+	})
+
+	data := rs.GetData()
+	assert.Equal(t, map[int][]RelatedItem{1: {{ID: 2, Weight: 5}, {ID: 4, Weight: 1}}}, data)
+	assert.Positive(t, rs.MemoryUsage())
+
+	rs.Observe([]int{2}, []int{1}, make(map[int]struct{}))
+	assert.Equal(t, 6, rs.Get(1)[0].Weight)
+}
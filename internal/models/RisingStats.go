@@ -0,0 +1,84 @@
+package models
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+)
+
+var tickEntryBytes = int64(unsafe.Sizeof(int(0))*2) + mapEntryOverhead
+
+// minTickDuration keeps rates finite for ticks closed right after another.
+const minTickDuration = time.Millisecond
+
+// RisingTick is a closed aggregation tick: the views of every item and how
+// long the tick lasted. Aggregations triggered by a full buffer make ticks
+// shorter than the interval, so views are only comparable as rates.
+type RisingTick struct {
+	Views    map[int]int
+	Duration time.Duration
+}
+
+// RisingStats keeps the views of every item per aggregation tick for the
+// last ticks ticks. Closed ticks are never modified, so readers may keep
+// them without copying.
+type RisingStats struct {
+	mu      sync.RWMutex
+	size    int
+	current map[int]int
+	ticks   []RisingTick // closed ticks, oldest first
+	bytes   int64
+}
+
+func NewRisingStats(ticks int) *RisingStats {
+	return &RisingStats{size: ticks, current: make(map[int]int)}
+}
+
+// Observe counts the event's views in the current tick.
+func (rs *RisingStats) Observe(val *InputStats) {
+	if val == nil || len(val.Views) == 0 {
+		return
+	}
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	parseIDs(val.Views, func(id int) {
+		if _, ok := rs.current[id]; !ok {
+			rs.bytes += tickEntryBytes
+		}
+		rs.current[id]++
+	})
+}
+
+// Tick closes the current tick after it lasted d, dropping the oldest one
+// once the window is full, and reports whether the window changed: a window
+// of empty ticks stays the same.
+func (rs *RisingStats) Tick(d time.Duration) bool {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	changed := len(rs.current) > 0
+	if len(rs.ticks) == rs.size {
+		changed = changed || len(rs.ticks[0].Views) > 0
+		rs.bytes -= int64(len(rs.ticks[0].Views)) * tickEntryBytes
+		rs.ticks = append(rs.ticks[:0:0], rs.ticks[1:]...)
+	} else {
+		changed = true
+	}
+	rs.ticks = append(rs.ticks, RisingTick{Views: rs.current, Duration: max(d, minTickDuration)})
+	rs.current = make(map[int]int, len(rs.current))
+	return changed
+}
+
+// Ticks returns the closed ticks, oldest first. The maps are shared and must
+// not be modified.
+func (rs *RisingStats) Ticks() []RisingTick {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return append([]RisingTick(nil), rs.ticks...)
+}
+
+// MemoryUsage returns the approximate number of bytes held by the ticks.
+func (rs *RisingStats) MemoryUsage() int64 {
+	rs.mu.RLock()
+	defer rs.mu.RUnlock()
+	return rs.bytes
+}
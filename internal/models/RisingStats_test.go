@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRisingStats_Window(t *testing.T) {
+	rs := NewRisingStats(2)
+	rs.Observe(&InputStats{Views: []string{"1", "1", "2", "x"}, Clicks: []string{"3"}})
+	assert.True(t, rs.Tick(10*time.Second))
+	assert.Equal(t, []RisingTick{{Views: map[int]int{1: 2, 2: 1}, Duration: 10 * time.Second}}, rs.Ticks())
+	assert.Equal(t, 2*tickEntryBytes, rs.MemoryUsage())
+
+	rs.Observe(&InputStats{Views: []string{"2"}})
+	assert.True(t, rs.Tick(10*time.Second))
+	rs.Observe(&InputStats{Views: []string{"3"}})
+	assert.True(t, rs.Tick(2*time.Second), "the oldest tick is dropped")
+	assert.Equal(t, []RisingTick{
+		{Views: map[int]int{2: 1}, Duration: 10 * time.Second},
+		{Views: map[int]int{3: 1}, Duration: 2 * time.Second},
+	}, rs.Ticks())
+	assert.Equal(t, 2*tickEntryBytes, rs.MemoryUsage())
+}
+
+func TestRisingStats_EmptyTicks(t *testing.T) {
+	rs := NewRisingStats(2)
+	rs.Observe(&InputStats{Views: []string{"1"}})
+	rs.Tick(time.Second)
+	assert.True(t, rs.Tick(time.Second), "an empty tick still moves a non-empty window")
+	assert.True(t, rs.Tick(time.Second))
+	assert.False(t, rs.Tick(time.Second), "a window of empty ticks stays the same")
+	assert.Equal(t, []RisingTick{{Views: map[int]int{}, Duration: time.Second}, {Views: map[int]int{}, Duration: time.Second}}, rs.Ticks())
+	assert.Zero(t, rs.MemoryUsage())
+}
+
+func TestRisingStats_MinimumDuration(t *testing.T) {
+	rs := NewRisingStats(2)
+	rs.Tick(0)
+	rs.Tick(-time.Second)
+	for _, tick := range rs.Ticks() {
+		assert.Equal(t, minTickDuration, tick.Duration, "back-to-back ticks keep a finite rate")
+	}
+}
+
+func TestRisingStats_TicksAreShared(t *testing.T) {
+	rs := NewRisingStats(3)
+	rs.Observe(&InputStats{Views: []string{"1"}})
+	rs.Tick(time.Second)
+	ticks := rs.Ticks()
+
+	rs.Observe(&InputStats{Views: []string{"1"}})
+	rs.Tick(time.Second)
+	assert.Equal(t, []RisingTick{{Views: map[int]int{1: 1}, Duration: time.Second}}, ticks, "closed ticks do not change")
+}
@@ -1,14 +1,35 @@
 package models
 
-import (
-	"strconv"
-	"sync"
-)
+import "sync"
 
 type StatRecord struct {
 	Views  int
 	Clicks int
 	Ftr    int
+	Value  *ValueSummary `json:",omitempty"`
+	// Exposure is the number of views weighted by the propensity of the
+	// slot they were shown in; only set once an item has positioned views.
+	Exposure float64 `json:",omitempty"`
+	// CorrectedCtr is Clicks / Exposure, the click-through rate corrected
+	// for position bias. It is derived and not read back on restore.
+	CorrectedCtr float64 `json:",omitempty"`
+}
+
+// ValueSummary aggregates the numeric values reported with an item, such as
+// revenue or watch seconds. Count and Sum decay with the item's views.
+type ValueSummary struct {
+	Count int
+	Sum   float64
+	Min   float64
+	Max   float64
+}
+
+// Avg returns the mean value, or 0 without values.
+func (v *ValueSummary) Avg() float64 {
+	if v == nil || v.Count == 0 {
+		return 0
+	}
+	return v.Sum / float64(v.Count)
 }
 
 type Statistic struct {
@@ -48,13 +69,13 @@ func (sm *Statistic) GetData() map[int]*StatRecord {
 	sm.mutex.RLock()
 	defer sm.mutex.RUnlock()
 
+	slab := make([]StatRecord, len(sm.Data))
 	copyMap := make(map[int]*StatRecord, len(sm.Data))
+	i := 0
 	for k, v := range sm.Data {
-		copyMap[k] = &StatRecord{
-			Views:  v.Views,
-			Clicks: v.Clicks,
-			Ftr:    v.Ftr,
-		}
+		slab[i] = *v
+		copyMap[k] = &slab[i]
+		i++
 	}
 	return copyMap
 }
@@ -67,17 +88,10 @@ func (sm *Statistic) IncStats(data *InputStats) {
 		return
 	}
 
-	for _, v := range data.Views {
-		if v == "" {
-			continue
-		}
-		key, err := strconv.Atoi(v)
-		if err != nil {
-			continue
-		}
+	parseIDs(data.Views, func(key int) {
 		if existing, ok := sm.Data[key]; ok {
 			existing.Views++
-			if existing.Views > 512 {
+			if existing.Views > trendThreshold {
 				existing.Views = (existing.Views + 1) >> 1
 				existing.Clicks = (existing.Clicks + 1) >> 1
 				existing.Ftr++
@@ -85,19 +99,12 @@ func (sm *Statistic) IncStats(data *InputStats) {
 		} else {
 			sm.Data[key] = &StatRecord{Views: 1}
 		}
-	}
-	for _, v := range data.Clicks {
-		if v == "" {
-			continue
-		}
-		key, err := strconv.Atoi(v)
-		if err != nil {
-			continue
-		}
+	})
+	parseIDs(data.Clicks, func(key int) {
 		if existing, ok := sm.Data[key]; ok {
 			existing.Clicks++
 		} else {
 			sm.Data[key] = &StatRecord{Clicks: 1}
 		}
-	}
+	})
 }
@@ -1,10 +1,34 @@
 package models
 
 type ChannelData struct {
-	TrendStats    map[int]*StatRecord   `json:"trend_stats"`
-	PersonalStats map[string]*Statistic `json:"personal_stats"`
+	TrendStats    map[int]*StatRecord                         `json:"trend_stats"`
+	PersonalStats map[string]*Statistic                       `json:"personal_stats"`
+	Related       map[int][]RelatedItem                       `json:"related,omitempty"`
+	Catalog       map[int]*CatalogItem                        `json:"catalog,omitempty"`
+	Dims          map[string]map[string]map[int]*StatRecord   `json:"dims,omitempty"`
+	Experiments   map[string]map[string]map[int]VariantCounts `json:"experiments,omitempty"`
+	ExpSeen       map[string]uint32                           `json:"experiments_seen,omitempty"`
+	Funnels       map[string]*FunnelData                      `json:"funnels,omitempty"`
+	Positions     *PositionData                               `json:"positions,omitempty"`
+}
+
+// RuleData is a threshold rule as stored in the snapshot.
+type RuleData struct {
+	Name      string  `json:"name"`
+	Metric    string  `json:"metric"`
+	Channel   string  `json:"channel,omitempty"`
+	Item      *int    `json:"item,omitempty"`
+	Threshold float64 `json:"threshold"`
+}
+
+// RuleOverrides are the rules set and deleted through the admin API. They
+// are applied over the configured rules at restore.
+type RuleOverrides struct {
+	Set     []RuleData `json:"set,omitempty"`
+	Deleted []string   `json:"deleted,omitempty"`
 }
 
 type Storage struct {
 	Channels map[string]*ChannelData `json:"channels"`
+	Rules    *RuleOverrides          `json:"rules,omitempty"`
 }
@@ -0,0 +1,246 @@
+package models
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Propensity returns how likely an item shown in a 1-based slot is to be
+// looked at, relative to slot 1; positions below 1 mean no position.
+type Propensity func(pos int) float64
+
+// TrendStats is the per-channel trend store. Records are held by value, so a
+// channel with a million items is a single pointer-free map instead of a
+// million separately allocated StatRecords. Values and position exposure
+// live in a second map that is only allocated once an item needs it.
+type TrendStats struct {
+	mutex  sync.RWMutex
+	data   map[int]record
+	extras map[int]extra
+	bytes  atomic.Int64
+}
+
+func NewTrendStats() *TrendStats {
+	return &TrendStats{data: make(map[int]record)}
+}
+
+// statRecord converts an item's records into their exported form. Callers
+// must hold the lock.
+func (ts *TrendStats) statRecord(key int, r record) StatRecord {
+	rec := r.toStatRecord()
+	if e, ok := ts.extras[key]; ok {
+		e.apply(&rec, new(ValueSummary))
+	}
+	return rec
+}
+
+func (ts *TrendStats) Get(key int) (*StatRecord, bool) {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	val, ok := ts.data[key]
+	if !ok {
+		return nil, false
+	}
+	rec := ts.statRecord(key, val)
+	return &rec, true
+}
+
+func (ts *TrendStats) Set(key int, val *StatRecord) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	if _, ok := ts.data[key]; !ok {
+		ts.bytes.Add(trendEntryBytes)
+	}
+	r := fromStatRecord(val)
+	r.touched = clock()
+	ts.data[key] = r
+	ts.setExtra(key, extraFromStatRecord(val))
+}
+
+// setExtra stores or, if empty, removes an item's extra aggregates. Callers
+// must hold the lock.
+func (ts *TrendStats) setExtra(key int, e extra) {
+	_, had := ts.extras[key]
+	switch {
+	case !e.empty():
+		if ts.extras == nil {
+			ts.extras = make(map[int]extra)
+		}
+		ts.extras[key] = e
+		if !had {
+			ts.bytes.Add(extraEntryBytes)
+		}
+	case had:
+		delete(ts.extras, key)
+		ts.bytes.Add(-extraEntryBytes)
+	}
+}
+
+func (ts *TrendStats) Len() int {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	return len(ts.data)
+}
+
+// MemoryUsage returns the approximate number of bytes held by the store.
+func (ts *TrendStats) MemoryUsage() int64 {
+	return ts.bytes.Load()
+}
+
+func (ts *TrendStats) PutData(data map[int]*StatRecord) {
+	now := clock()
+	compact := make(map[int]record, len(data))
+	var extras map[int]extra
+	for k, v := range data {
+		if v == nil {
+			continue
+		}
+		r := fromStatRecord(v)
+		r.touched = now
+		compact[k] = r
+		if e := extraFromStatRecord(v); !e.empty() {
+			if extras == nil {
+				extras = make(map[int]extra)
+			}
+			extras[k] = e
+		}
+	}
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+	ts.data = compact
+	ts.extras = extras
+	ts.bytes.Store(int64(len(compact))*trendEntryBytes + int64(len(extras))*extraEntryBytes)
+}
+
+// GetData returns the records in their exported form, slab-allocated so the
+// copy costs two allocations regardless of the number of items, plus one
+// more for value summaries if any item has extra aggregates.
+func (ts *TrendStats) GetData() map[int]*StatRecord {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+
+	slab := make([]StatRecord, len(ts.data))
+	var summaries []ValueSummary
+	if len(ts.extras) > 0 {
+		summaries = make([]ValueSummary, len(ts.extras))
+	}
+	copyMap := make(map[int]*StatRecord, len(ts.data))
+	i, j := 0, 0
+	for k, v := range ts.data {
+		slab[i] = v.toStatRecord()
+		if e, ok := ts.extras[k]; ok {
+			e.apply(&slab[i], &summaries[j])
+			j++
+		}
+		copyMap[k] = &slab[i]
+		i++
+	}
+	return copyMap
+}
+
+// IncStats counts the event's views, clicks and values.
+func (ts *TrendStats) IncStats(data *InputStats) {
+	ts.IncStatsCorrected(data, nil)
+}
+
+// IncStatsCorrected is IncStats that also adds the propensity of their slot
+// to the exposure of positioned views. Once an item has exposure, views
+// without a position add 1; its earlier views are counted as fully examined.
+func (ts *TrendStats) IncStatsCorrected(data *InputStats, p Propensity) {
+	if data == nil {
+		return
+	}
+	now := clock()
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	added := 0
+	parseViews(data.Views, data.Positions, func(id, pos int) {
+		r, ok := ts.data[id]
+		if !ok {
+			added++
+		}
+		e := ts.extras[id]
+		if p != nil && (pos > 0 || e.exposure > 0) {
+			if e.exposure == 0 {
+				e.exposure = float64(r.views)
+			}
+			e.exposure += p(pos)
+			ts.setExtra(id, e)
+		}
+		if r.addView() {
+			if e, ok := ts.extras[id]; ok {
+				e.halve()
+				ts.extras[id] = e
+			}
+		}
+		r.touched = now
+		ts.data[id] = r
+	})
+	parseIDs(data.Clicks, func(id int) {
+		r, ok := ts.data[id]
+		if !ok {
+			added++
+		}
+		r.addClick()
+		r.touched = now
+		ts.data[id] = r
+	})
+	parseValues(data.Values, func(id int, x float64) {
+		r, ok := ts.data[id]
+		if !ok {
+			added++
+		}
+		r.touched = now
+		ts.data[id] = r
+		e := ts.extras[id]
+		e.value.add(x)
+		ts.setExtra(id, e)
+	})
+	if added > 0 {
+		ts.bytes.Add(int64(added) * trendEntryBytes)
+	}
+}
+
+// entryBytes is the accounted size of an item. Callers must hold the lock.
+func (ts *TrendStats) entryBytes(key int) int64 {
+	if _, ok := ts.extras[key]; ok {
+		return trendEntryBytes + extraEntryBytes
+	}
+	return trendEntryBytes
+}
+
+// AgeHistogram returns the accounted bytes per last-update stamp.
+func (ts *TrendStats) AgeHistogram() map[uint32]int64 {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+
+	var hist map[uint32]int64
+	for k, v := range ts.data {
+		hist = addAge(hist, v.touched, ts.entryBytes(k))
+	}
+	return hist
+}
+
+// EvictBefore removes items last updated before stamp until at least limit
+// bytes are freed, and returns the number of removed items and freed bytes.
+func (ts *TrendStats) EvictBefore(stamp uint32, limit int64) (int, int64) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	evicted := 0
+	var freed int64
+	for k, v := range ts.data {
+		if freed >= limit {
+			break
+		}
+		if v.touched < stamp {
+			freed += ts.entryBytes(k)
+			delete(ts.data, k)
+			delete(ts.extras, k)
+			evicted++
+		}
+	}
+	ts.bytes.Add(-freed)
+	return evicted, freed
+}
@@ -0,0 +1,266 @@
+package models
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrendStats_SetAndGet(t *testing.T) {
+	ts := NewTrendStats()
+	ts.Set(1, &StatRecord{Views: 10, Clicks: 2, Ftr: 1})
+
+	val, ok := ts.Get(1)
+	require.True(t, ok)
+	assert.Equal(t, &StatRecord{Views: 10, Clicks: 2, Ftr: 1}, val)
+}
+
+func TestTrendStats_GetMissing(t *testing.T) {
+	ts := NewTrendStats()
+	val, ok := ts.Get(999)
+	assert.False(t, ok)
+	assert.Nil(t, val)
+}
+
+func TestTrendStats_PutDataSkipsNil(t *testing.T) {
+	ts := NewTrendStats()
+	ts.Set(1, &StatRecord{Views: 1})
+	ts.PutData(map[int]*StatRecord{10: {Views: 100}, 11: nil})
+
+	assert.Equal(t, 1, ts.Len())
+	val, ok := ts.Get(10)
+	require.True(t, ok)
+	assert.Equal(t, 100, val.Views)
+}
+
+func TestTrendStats_GetDataDeepCopy(t *testing.T) {
+	ts := NewTrendStats()
+	ts.Set(1, &StatRecord{Views: 10})
+	ts.Set(2, &StatRecord{Views: 20})
+
+	copied := ts.GetData()
+	copied[1].Views = 999
+
+	original, _ := ts.Get(1)
+	assert.Equal(t, 10, original.Views)
+	assert.Equal(t, 20, copied[2].Views)
+}
+
+func TestTrendStats_IncStats(t *testing.T) {
+	ts := NewTrendStats()
+	ts.IncStats(&InputStats{Views: []string{"1", "2", "x", ""}, Clicks: []string{"1", "3"}})
+
+	assert.Equal(t, 3, ts.Len())
+	v1, _ := ts.Get(1)
+	assert.Equal(t, &StatRecord{Views: 1, Clicks: 1}, v1)
+	v3, _ := ts.Get(3)
+	assert.Equal(t, &StatRecord{Clicks: 1}, v3)
+}
+
+func TestTrendStats_IncStats_TrendingHalving(t *testing.T) {
+	ts := NewTrendStats()
+	ts.Set(1, &StatRecord{Views: 512, Clicks: 100})
+	ts.IncStats(&InputStats{Views: []string{"1"}})
+
+	v, _ := ts.Get(1)
+	assert.Equal(t, &StatRecord{Views: 257, Clicks: 50, Ftr: 1}, v)
+}
+
+func TestTrendStats_MatchesStatistic(t *testing.T) {
+	ts := NewTrendStats()
+	s := &Statistic{Data: make(map[int]*StatRecord)}
+	input := &InputStats{Views: []string{"1", "2", "1"}, Clicks: []string{"2", "4"}}
+	for i := 0; i < 700; i++ {
+		ts.IncStats(input)
+		s.IncStats(input)
+	}
+	assert.Equal(t, s.GetData(), ts.GetData())
+}
+
+func TestTrendStats_IncStats_Nil(t *testing.T) {
+	ts := NewTrendStats()
+	ts.IncStats(nil)
+	assert.Equal(t, 0, ts.Len())
+}
+
+func TestTrendStats_ConcurrentAccess(t *testing.T) {
+	ts := NewTrendStats()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			ts.IncStats(&InputStats{Views: []string{"1"}})
+		}()
+		go func() {
+			defer wg.Done()
+			ts.GetData()
+		}()
+	}
+	wg.Wait()
+
+	v, _ := ts.Get(1)
+	assert.Equal(t, 50, v.Views)
+}
+
+// BenchmarkTrendStats_Layout measures the value-map trend store.
+func BenchmarkTrendStats_Layout(b *testing.B) {
+	benchmarkLayout(b, func(inputs []*InputStats) any {
+		ts := NewTrendStats()
+		for _, in := range inputs {
+			ts.IncStats(in)
+		}
+		return ts
+	})
+}
+
+// BenchmarkTrendStats_LegacyLayout is the pointer-per-record Statistic.
+func BenchmarkTrendStats_LegacyLayout(b *testing.B) {
+	benchmarkLayout(b, func(inputs []*InputStats) any {
+		s := &Statistic{Data: make(map[int]*StatRecord)}
+		for _, in := range inputs {
+			s.IncStats(in)
+		}
+		return s
+	})
+}
+
+func withClock(t *testing.T, stamp *uint32) {
+	orig := clock
+	clock = func() uint32 { return *stamp }
+	t.Cleanup(func() { clock = orig })
+}
+
+func TestTrendStats_MemoryUsage(t *testing.T) {
+	ts := NewTrendStats()
+	ts.IncStats(&InputStats{Views: []string{"1", "2"}, Clicks: []string{"2", "3"}})
+	assert.Equal(t, 3*trendEntryBytes, ts.MemoryUsage())
+
+	ts.PutData(map[int]*StatRecord{1: {Views: 1}})
+	assert.Equal(t, trendEntryBytes, ts.MemoryUsage())
+}
+
+func TestTrendStats_EvictBefore(t *testing.T) {
+	stamp := uint32(100)
+	withClock(t, &stamp)
+
+	ts := NewTrendStats()
+	ts.IncStats(&InputStats{Views: []string{"1", "2"}})
+	stamp = 200
+	ts.IncStats(&InputStats{Views: []string{"3"}})
+
+	assert.Equal(t, map[uint32]int64{100: 2 * trendEntryBytes, 200: trendEntryBytes}, ts.AgeHistogram())
+
+	n, freed := ts.EvictBefore(200, trendEntryBytes)
+	assert.Equal(t, 1, n, "stops once the limit is reached")
+	assert.Equal(t, trendEntryBytes, freed)
+
+	n, _ = ts.EvictBefore(200, 1<<40)
+	assert.Equal(t, 1, n)
+	_, ok := ts.Get(3)
+	assert.True(t, ok, "newer items survive")
+	assert.Equal(t, trendEntryBytes, ts.MemoryUsage())
+}
+
+func TestTrendStats_IncStats_Values(t *testing.T) {
+	ts := NewTrendStats()
+	ts.IncStats(&InputStats{Views: []string{"1"}, Values: map[string]float64{"1": 20, "2": 5}})
+	ts.IncStats(&InputStats{Values: map[string]float64{"1": 10}})
+
+	v1, _ := ts.Get(1)
+	assert.Equal(t, &StatRecord{Views: 1, Value: &ValueSummary{Count: 2, Sum: 30, Min: 10, Max: 20}}, v1)
+	v2, ok := ts.Get(2)
+	require.True(t, ok, "a value alone creates the item")
+	assert.Equal(t, 5.0, v2.Value.Sum)
+	assert.Equal(t, 2*(trendEntryBytes+extraEntryBytes), ts.MemoryUsage())
+}
+
+func TestTrendStats_ValuesDecayWithViews(t *testing.T) {
+	ts := NewTrendStats()
+	ts.Set(1, &StatRecord{Views: 512, Value: &ValueSummary{Count: 100, Sum: 250, Min: 1, Max: 4}})
+	ts.IncStats(&InputStats{Views: []string{"1"}})
+
+	v, _ := ts.Get(1)
+	assert.Equal(t, 1, v.Ftr)
+	assert.Equal(t, 50, v.Value.Count)
+	assert.InDelta(t, 125.0, v.Value.Sum, 1e-9)
+	assert.InDelta(t, 2.5, v.Value.Avg(), 1e-9)
+}
+
+func TestTrendStats_ValuesDecayWithoutViews(t *testing.T) {
+	ts := NewTrendStats()
+	for i := 0; i < trendThreshold; i++ {
+		ts.IncStats(&InputStats{Values: map[string]float64{"1": 2}})
+	}
+	v, _ := ts.Get(1)
+	assert.Equal(t, trendThreshold, v.Value.Count)
+
+	ts.IncStats(&InputStats{Values: map[string]float64{"1": 2}})
+	v, _ = ts.Get(1)
+	assert.Equal(t, 0, v.Views)
+	assert.Equal(t, (trendThreshold+2)/2, v.Value.Count)
+	assert.InDelta(t, 2.0, v.Value.Avg(), 1e-9)
+}
+
+func TestTrendStats_ValuesRoundtrip(t *testing.T) {
+	ts := NewTrendStats()
+	ts.PutData(map[int]*StatRecord{
+		1: {Views: 3, Value: &ValueSummary{Count: 1, Sum: 9, Min: 9, Max: 9}},
+		2: {Views: 4},
+	})
+	assert.Equal(t, 2*trendEntryBytes+extraEntryBytes, ts.MemoryUsage())
+
+	data := ts.GetData()
+	assert.Equal(t, 9.0, data[1].Value.Sum)
+	assert.Nil(t, data[2].Value)
+
+	ts.Set(1, &StatRecord{Views: 3})
+	assert.Equal(t, 2*trendEntryBytes, ts.MemoryUsage(), "setting a record without values drops them")
+
+	ts.PutData(data)
+	n, freed := ts.EvictBefore(clock()+1, 1<<40)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 2*trendEntryBytes+extraEntryBytes, freed)
+}
+
+func TestTrendStats_IncStatsCorrected(t *testing.T) {
+	ts := NewTrendStats()
+	half := func(pos int) float64 {
+		if pos > 1 {
+			return 0.5
+		}
+		return 1
+	}
+	ts.IncStatsCorrected(&InputStats{Views: []string{"1", "2"}, Clicks: []string{"1"}}, half)
+	v1, _ := ts.Get(1)
+	assert.Zero(t, v1.Exposure, "no exposure without positioned views")
+
+	ts.IncStatsCorrected(&InputStats{Views: []string{"1", "2"}, Positions: map[string]int{"1": 3, "2": 1}}, half)
+	ts.IncStatsCorrected(&InputStats{Views: []string{"1"}, Clicks: []string{"1"}}, half)
+
+	v1, _ = ts.Get(1)
+	assert.Equal(t, 2.5, v1.Exposure, "1 earlier view, 0.5 in slot 3, 1 unpositioned")
+	assert.InDelta(t, 0.8, v1.CorrectedCtr, 1e-9)
+	v2, _ := ts.Get(2)
+	assert.Equal(t, 2.0, v2.Exposure)
+	assert.Zero(t, v2.CorrectedCtr)
+	assert.Equal(t, 2*(trendEntryBytes+extraEntryBytes), ts.MemoryUsage())
+
+	data := ts.GetData()
+	assert.Equal(t, 2.5, data[1].Exposure)
+	ts.PutData(data)
+	v1, _ = ts.Get(1)
+	assert.InDelta(t, 0.8, v1.CorrectedCtr, 1e-9, "exposure survives a restore")
+}
+
+func TestTrendStats_ExposureDecaysWithViews(t *testing.T) {
+	ts := NewTrendStats()
+	ts.Set(1, &StatRecord{Views: 512, Clicks: 64, Exposure: 255})
+	ts.IncStatsCorrected(&InputStats{Views: []string{"1"}, Positions: map[string]int{"1": 1}}, func(int) float64 { return 1 })
+
+	v, _ := ts.Get(1)
+	assert.Equal(t, 128.0, v.Exposure)
+	assert.InDelta(t, 0.25, v.CorrectedCtr, 1e-9)
+}
@@ -0,0 +1,307 @@
+package models
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+	"unsafe"
+)
+
+// trendThreshold is the view count above which a record is halved.
+const trendThreshold = 512
+
+// Approximate in-memory sizes used for memory accounting. Map entries are
+// charged their key and value plus a per-entry share of the bucket overhead.
+const (
+	mapEntryOverhead      = 16
+	trendEntryBytes       = int64(unsafe.Sizeof(int(0))+unsafe.Sizeof(record{})) + mapEntryOverhead
+	extraEntryBytes       = int64(unsafe.Sizeof(int(0))+unsafe.Sizeof(extra{})) + mapEntryOverhead
+	itemRecordBytes       = int64(unsafe.Sizeof(itemRecord{}))
+	fingerprintEntryBytes = int64(unsafe.Sizeof("")+unsafe.Sizeof(&fpRecords{})+unsafe.Sizeof(fpRecords{})) + mapEntryOverhead
+)
+
+var (
+	viewRecordBytes    = int64(unsafe.Sizeof(int(0))+unsafe.Sizeof(&StatRecord{})+unsafe.Sizeof(StatRecord{})) + mapEntryOverhead
+	viewStatisticBytes = int64(unsafe.Sizeof("")+unsafe.Sizeof(&Statistic{})+unsafe.Sizeof(Statistic{})) + mapEntryOverhead
+)
+
+// RecordMapBytes approximates the size of a map of n StatRecords as handed
+// out by GetData.
+func RecordMapBytes(n int) int64 {
+	return int64(n) * viewRecordBytes
+}
+
+// StatisticBytes approximates the size of a fingerprint's entry in a copied
+// fingerprint map.
+func StatisticBytes(fp string, stat *Statistic) int64 {
+	return viewStatisticBytes + int64(len(fp)) + RecordMapBytes(len(stat.Data))
+}
+
+// clock returns the update stamp written to touched records: Unix seconds,
+// which fit in 32 bits until 2106. Tests replace it.
+var clock = func() uint32 {
+	return uint32(time.Now().Unix())
+}
+
+// record is the pointer-free in-memory form of StatRecord. Maps and slices of
+// records need no per-item heap object and are never scanned by the GC.
+type record struct {
+	views   int
+	clicks  int
+	ftr     int
+	touched uint32 // last update, see clock
+}
+
+// addView counts a view and reports whether the record was halved.
+func (r *record) addView() bool {
+	r.views++
+	if r.views > trendThreshold {
+		r.views = (r.views + 1) >> 1
+		r.clicks = (r.clicks + 1) >> 1
+		r.ftr++
+		return true
+	}
+	return false
+}
+
+func (r *record) addClick() {
+	r.clicks++
+}
+
+func (r record) toStatRecord() StatRecord {
+	return StatRecord{Views: r.views, Clicks: r.clicks, Ftr: r.ftr}
+}
+
+func fromStatRecord(s *StatRecord) record {
+	return record{views: s.Views, clicks: s.Clicks, ftr: s.Ftr}
+}
+
+// valueRecord aggregates the numeric values reported for an item.
+type valueRecord struct {
+	count    int
+	sum      float64
+	min, max float64
+}
+
+// add counts a value. Values decay on their own once their count exceeds
+// trendThreshold, so items that report values without views are halved too.
+func (v *valueRecord) add(x float64) {
+	if v.count == 0 || x < v.min {
+		v.min = x
+	}
+	if v.count == 0 || x > v.max {
+		v.max = x
+	}
+	v.count++
+	v.sum += x
+	if v.count > trendThreshold {
+		v.halve()
+	}
+}
+
+// halve decays count and sum, also along with the item's views. The sum is scaled
+// by the same factor as the rounded count, so the average is unchanged;
+// min and max are extremes and stay as they are.
+func (v *valueRecord) halve() {
+	if v.count == 0 {
+		return
+	}
+	n := (v.count + 1) >> 1
+	v.sum *= float64(n) / float64(v.count)
+	v.count = n
+}
+
+func fromSummary(s *ValueSummary) valueRecord {
+	return valueRecord{count: s.Count, sum: s.Sum, min: s.Min, max: s.Max}
+}
+
+// extra holds the optional aggregates of an item. It is kept apart from
+// record so that items without values or positioned views pay nothing for it.
+type extra struct {
+	value    valueRecord
+	exposure float64 // propensity-weighted views, 0 until the first positioned view
+}
+
+func (e *extra) empty() bool {
+	return e.value.count == 0 && e.exposure == 0
+}
+
+// halve decays the aggregates along with the item's views.
+func (e *extra) halve() {
+	e.value.halve()
+	e.exposure /= 2
+}
+
+// apply adds the extra fields to an exported record of the same item.
+func (e extra) apply(rec *StatRecord, summary *ValueSummary) {
+	if e.value.count > 0 {
+		*summary = ValueSummary{Count: e.value.count, Sum: e.value.sum, Min: e.value.min, Max: e.value.max}
+		rec.Value = summary
+	}
+	if e.exposure > 0 {
+		rec.Exposure = e.exposure
+		rec.CorrectedCtr = float64(rec.Clicks) / e.exposure
+	}
+}
+
+func extraFromStatRecord(s *StatRecord) extra {
+	var e extra
+	if s.Value != nil {
+		e.value = fromSummary(s.Value)
+	}
+	if s.Exposure > 0 {
+		e.exposure = s.Exposure
+	}
+	return e
+}
+
+// itemRecord is a record keyed by item ID inside a sorted slice.
+type itemRecord struct {
+	id int
+	record
+}
+
+// itemRecords is a per-fingerprint record set kept sorted by ID. A
+// fingerprint usually touches few items, so one contiguous slice is far
+// cheaper than a map plus one heap object per item.
+type itemRecords []itemRecord
+
+// find returns the position of id and whether it is present.
+func (ir itemRecords) find(id int) (int, bool) {
+	i := sort.Search(len(ir), func(i int) bool { return ir[i].id >= id })
+	return i, i < len(ir) && ir[i].id == id
+}
+
+// insert adds zero records for the IDs in ids that are missing. ids must be
+// sorted and may repeat. The set grows once and the new records are merged
+// in from the back, so an event with many new items costs one pass over the
+// set instead of one shift per item.
+func (ir *itemRecords) insert(ids []int) {
+	missing := 0
+	for n, id := range ids {
+		if n > 0 && ids[n-1] == id {
+			continue
+		}
+		if _, ok := ir.find(id); !ok {
+			missing++
+		}
+	}
+	if missing == 0 {
+		return
+	}
+	old := len(*ir)
+	s := slices.Grow(*ir, missing)[:old+missing]
+	i, k := old-1, len(s)-1
+	for j := len(ids) - 1; j >= 0; j-- {
+		if j > 0 && ids[j-1] == ids[j] {
+			continue
+		}
+		for i >= 0 && s[i].id > ids[j] {
+			s[k] = s[i]
+			i, k = i-1, k-1
+		}
+		if i >= 0 && s[i].id == ids[j] {
+			s[k] = s[i]
+			i, k = i-1, k-1
+			continue
+		}
+		s[k] = itemRecord{id: ids[j]}
+		k--
+	}
+	*ir = s
+}
+
+// get returns the record for id, which must be present.
+func (ir itemRecords) get(id int) *record {
+	i, _ := ir.find(id)
+	return &ir[i].record
+}
+
+// toMap converts the set into its exported form. All records share one
+// backing array, so the conversion costs two allocations regardless of size.
+func (ir itemRecords) toMap() map[int]*StatRecord {
+	slab := make([]StatRecord, len(ir))
+	out := make(map[int]*StatRecord, len(ir))
+	for i, v := range ir {
+		slab[i] = v.toStatRecord()
+		out[v.id] = &slab[i]
+	}
+	return out
+}
+
+func itemRecordsFromMap(data map[int]*StatRecord) itemRecords {
+	ir := make(itemRecords, 0, len(data))
+	for id, v := range data {
+		if v == nil {
+			continue
+		}
+		ir = append(ir, itemRecord{id: id, record: fromStatRecord(v)})
+	}
+	sort.Slice(ir, func(i, j int) bool { return ir[i].id < ir[j].id })
+	return ir
+}
+
+// fpRecords is everything stored for one fingerprint.
+type fpRecords struct {
+	items   itemRecords
+	touched uint32
+}
+
+// bytes is the accounted size of a fingerprint entry with the given key.
+func (f *fpRecords) bytes(key string) int64 {
+	return fingerprintEntryBytes + int64(len(key)) + int64(cap(f.items))*itemRecordBytes
+}
+
+// addAge adds size to the bucket of stamp, creating the map lazily.
+func addAge(hist map[uint32]int64, stamp uint32, size int64) map[uint32]int64 {
+	if hist == nil {
+		hist = make(map[uint32]int64)
+	}
+	hist[stamp] += size
+	return hist
+}
+
+// parseValues calls fn for every valid numeric ID in values with a finite value.
+func parseValues(values map[string]float64, fn func(id int, x float64)) {
+	for k, x := range values {
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			continue
+		}
+		id, err := strconv.Atoi(k)
+		if err != nil {
+			continue
+		}
+		fn(id, x)
+	}
+}
+
+// parseViews calls fn for every valid numeric ID in views with the position
+// it was shown in, or 0 without one.
+func parseViews(views []string, positions map[string]int, fn func(id, pos int)) {
+	for _, v := range views {
+		if v == "" {
+			continue
+		}
+		key, err := strconv.Atoi(v)
+		if err != nil {
+			continue
+		}
+		fn(key, positions[v])
+	}
+}
+
+// parseIDs calls fn for every valid numeric ID in ids.
+func parseIDs(ids []string, fn func(id int)) {
+	for _, v := range ids {
+		if v == "" {
+			continue
+		}
+		key, err := strconv.Atoi(v)
+		if err != nil {
+			continue
+		}
+		fn(key)
+	}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemRecords_InsertKeepsOrder(t *testing.T) {
+	ir := itemRecords{{id: 3, record: record{views: 4}}, {id: 8}}
+	ir.insert([]int{1, 1, 3, 5, 5, 9})
+
+	ids := make([]int, len(ir))
+	for i, v := range ir {
+		ids[i] = v.id
+	}
+	assert.Equal(t, []int{1, 3, 5, 8, 9}, ids)
+	assert.Equal(t, 4, ir.get(3).views, "existing records are kept")
+	assert.Equal(t, 0, ir.get(5).views)
+
+	before := cap(ir)
+	ir.insert([]int{3, 8})
+	assert.Len(t, ir, 5)
+	assert.Equal(t, before, cap(ir), "no growth without new IDs")
+}
+
+func TestItemRecords_InsertMany(t *testing.T) {
+	var ir itemRecords
+	ids := make([]int, 0, 2000)
+	for id := 3999; id >= 0; id -= 2 {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	ir.insert(ids)
+	ir.insert([]int{0, 2, 4000})
+
+	require.Len(t, ir, 2003)
+	assert.True(t, slices.IsSortedFunc(ir, func(a, b itemRecord) int { return a.id - b.id }))
+}
+
+func TestItemRecords_Find(t *testing.T) {
+	ir := itemRecords{{id: 2}, {id: 4}}
+	i, ok := ir.find(4)
+	assert.True(t, ok)
+	assert.Equal(t, 1, i)
+	i, ok = ir.find(3)
+	assert.False(t, ok)
+	assert.Equal(t, 1, i)
+}
+
+func TestItemRecords_MapRoundtrip(t *testing.T) {
+	in := map[int]*StatRecord{7: {Views: 1, Clicks: 2, Ftr: 3}, 2: {Views: 4}, 9: nil}
+	ir := itemRecordsFromMap(in)
+	assert.Len(t, ir, 2)
+	assert.Equal(t, 2, ir[0].id)
+	assert.Equal(t, map[int]*StatRecord{7: {Views: 1, Clicks: 2, Ftr: 3}, 2: {Views: 4}}, ir.toMap())
+}
+
+func TestRecord_AddViewHalves(t *testing.T) {
+	r := record{views: trendThreshold, clicks: 9}
+	r.addView()
+	assert.Equal(t, record{views: 257, clicks: 5, ftr: 1}, r)
+}
+
+func TestValueRecord_AddAndHalve(t *testing.T) {
+	var v valueRecord
+	for _, x := range []float64{4, -1, 9} {
+		v.add(x)
+	}
+	assert.Equal(t, valueRecord{count: 3, sum: 12, min: -1, max: 9}, v)
+
+	v.halve()
+	assert.Equal(t, 2, v.count)
+	assert.InDelta(t, 8.0, v.sum, 1e-9, "sum keeps the average of 4")
+	assert.Equal(t, -1.0, v.min)
+	assert.Equal(t, 9.0, v.max)
+}
+
+func TestParseValues_SkipsInvalid(t *testing.T) {
+	got := map[int]float64{}
+	parseValues(map[string]float64{"1": 2.5, "x": 1, "2": math.NaN(), "3": math.Inf(1)}, func(id int, x float64) {
+		got[id] = x
+	})
+	assert.Equal(t, map[int]float64{1: 2.5}, got)
+}
@@ -0,0 +1,8 @@
+// Package pb holds the gRPC API generated from ssd.proto.
+//
+// The compiler and both plugins are pinned in go.mod, so generation needs
+// only the Go toolchain. The compiler is protocompile rather than protoc,
+// which is why the headers report the protoc version as unknown.
+package pb
+
+//go:generate go run ssd/internal/pb/protogen ssd.proto
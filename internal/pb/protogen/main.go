@@ -0,0 +1,106 @@
+// Command protogen compiles a .proto file with protocompile and runs the
+// protoc-gen-go and protoc-gen-go-grpc tools of go.mod on it, so the
+// generated code depends on no compiler installed on the machine.
+//
+// Usage, from the directory of the file:
+//
+//	go run ssd/internal/pb/protogen ssd.proto
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// plugins are run as go tools, in this order, with paths=source_relative.
+var plugins = []string{"protoc-gen-go", "protoc-gen-go-grpc"}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: protogen <file.proto>")
+		os.Exit(2)
+	}
+	if err := generate(os.Args[1]); err != nil {
+		fmt.Fprintln(os.Stderr, "protogen:", err)
+		os.Exit(1)
+	}
+}
+
+func generate(file string) error {
+	compiler := protocompile.Compiler{
+		Resolver:       protocompile.WithStandardImports(&protocompile.SourceResolver{}),
+		SourceInfoMode: protocompile.SourceInfoStandard,
+	}
+	files, err := compiler.Compile(context.Background(), file)
+	if err != nil {
+		return err
+	}
+
+	req := &pluginpb.CodeGeneratorRequest{
+		FileToGenerate: []string{file},
+		Parameter:      proto.String("paths=source_relative"),
+		ProtoFile:      withImports(files[0], nil, map[string]bool{}),
+	}
+	for _, plugin := range plugins {
+		if err := run(plugin, req); err != nil {
+			return fmt.Errorf("%s: %w", plugin, err)
+		}
+	}
+	return nil
+}
+
+// withImports appends fd after its imports, as plugins expect every file
+// to follow the files it depends on.
+func withImports(fd protoreflect.FileDescriptor, out []*descriptorpb.FileDescriptorProto, seen map[string]bool) []*descriptorpb.FileDescriptorProto {
+	if seen[fd.Path()] {
+		return out
+	}
+	seen[fd.Path()] = true
+	imports := fd.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		out = withImports(imports.Get(i).FileDescriptor, out, seen)
+	}
+	return append(out, protodesc.ToFileDescriptorProto(fd))
+}
+
+func run(plugin string, req *pluginpb.CodeGeneratorRequest) error {
+	in, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command("go", "tool", plugin)
+	cmd.Stdin = bytes.NewReader(in)
+	cmd.Stderr = os.Stderr
+	raw, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+
+	var resp pluginpb.CodeGeneratorResponse
+	if err := proto.Unmarshal(raw, &resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s", resp.GetError())
+	}
+	for _, f := range resp.GetFile() {
+		if err := os.MkdirAll(filepath.Dir(f.GetName()), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.GetName(), []byte(f.GetContent()), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
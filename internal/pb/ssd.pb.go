@@ -0,0 +1,907 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: ssd.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Event carries the fields of the POST / body.
+type Event struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Views       []string               `protobuf:"bytes,1,rep,name=views,proto3" json:"views,omitempty"`
+	Clicks      []string               `protobuf:"bytes,2,rep,name=clicks,proto3" json:"clicks,omitempty"`
+	Fingerprint string                 `protobuf:"bytes,3,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	Channel     string                 `protobuf:"bytes,4,opt,name=channel,proto3" json:"channel,omitempty"`
+	Dims        map[string]string      `protobuf:"bytes,5,rep,name=dims,proto3" json:"dims,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// experiment ID -> variant
+	Experiments map[string]string `protobuf:"bytes,6,rep,name=experiments,proto3" json:"experiments,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// custom event type -> item IDs
+	Events map[string]*ItemIDs `protobuf:"bytes,7,rep,name=events,proto3" json:"events,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// item ID -> value, e.g. revenue
+	Values map[string]float64 `protobuf:"bytes,8,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	// item ID -> 1-based slot it was shown in
+	Positions     map[string]int32 `protobuf:"bytes,9,rep,name=positions,proto3" json:"positions,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_ssd_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Event) GetViews() []string {
+	if x != nil {
+		return x.Views
+	}
+	return nil
+}
+
+func (x *Event) GetClicks() []string {
+	if x != nil {
+		return x.Clicks
+	}
+	return nil
+}
+
+func (x *Event) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *Event) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *Event) GetDims() map[string]string {
+	if x != nil {
+		return x.Dims
+	}
+	return nil
+}
+
+func (x *Event) GetExperiments() map[string]string {
+	if x != nil {
+		return x.Experiments
+	}
+	return nil
+}
+
+func (x *Event) GetEvents() map[string]*ItemIDs {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *Event) GetValues() map[string]float64 {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *Event) GetPositions() map[string]int32 {
+	if x != nil {
+		return x.Positions
+	}
+	return nil
+}
+
+type ItemIDs struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []string               `protobuf:"bytes,1,rep,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ItemIDs) Reset() {
+	*x = ItemIDs{}
+	mi := &file_ssd_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ItemIDs) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ItemIDs) ProtoMessage() {}
+
+func (x *ItemIDs) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ItemIDs.ProtoReflect.Descriptor instead.
+func (*ItemIDs) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ItemIDs) GetIds() []string {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type IngestResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Accepted      int64                  `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestResponse) Reset() {
+	*x = IngestResponse{}
+	mi := &file_ssd_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestResponse) ProtoMessage() {}
+
+func (x *IngestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestResponse.ProtoReflect.Descriptor instead.
+func (*IngestResponse) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *IngestResponse) GetAccepted() int64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+// CatalogFilter narrows records like the tag, category and publishedAfter
+// query parameters.
+type CatalogFilter struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Tag      string                 `protobuf:"bytes,1,opt,name=tag,proto3" json:"tag,omitempty"`
+	Category string                 `protobuf:"bytes,2,opt,name=category,proto3" json:"category,omitempty"`
+	// Unix seconds, 0 = any
+	PublishedAfter int64 `protobuf:"varint,3,opt,name=published_after,json=publishedAfter,proto3" json:"published_after,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CatalogFilter) Reset() {
+	*x = CatalogFilter{}
+	mi := &file_ssd_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CatalogFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CatalogFilter) ProtoMessage() {}
+
+func (x *CatalogFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CatalogFilter.ProtoReflect.Descriptor instead.
+func (*CatalogFilter) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CatalogFilter) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *CatalogFilter) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *CatalogFilter) GetPublishedAfter() int64 {
+	if x != nil {
+		return x.PublishedAfter
+	}
+	return 0
+}
+
+type ListRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       string                 `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Filter        *CatalogFilter         `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_ssd_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListRequest) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *ListRequest) GetFilter() *CatalogFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type TopRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Channel string                 `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	// views, clicks, sum, avg or ctr; default views
+	Sort string `protobuf:"bytes,2,opt,name=sort,proto3" json:"sort,omitempty"`
+	// default 10, at most 100
+	N             int32          `protobuf:"varint,3,opt,name=n,proto3" json:"n,omitempty"`
+	Filter        *CatalogFilter `protobuf:"bytes,4,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TopRequest) Reset() {
+	*x = TopRequest{}
+	mi := &file_ssd_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TopRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TopRequest) ProtoMessage() {}
+
+func (x *TopRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TopRequest.ProtoReflect.Descriptor instead.
+func (*TopRequest) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *TopRequest) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *TopRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *TopRequest) GetN() int32 {
+	if x != nil {
+		return x.N
+	}
+	return 0
+}
+
+func (x *TopRequest) GetFilter() *CatalogFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type FingerprintRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       string                 `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Fingerprint   string                 `protobuf:"bytes,2,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FingerprintRequest) Reset() {
+	*x = FingerprintRequest{}
+	mi := &file_ssd_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FingerprintRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FingerprintRequest) ProtoMessage() {}
+
+func (x *FingerprintRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FingerprintRequest.ProtoReflect.Descriptor instead.
+func (*FingerprintRequest) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *FingerprintRequest) GetChannel() string {
+	if x != nil {
+		return x.Channel
+	}
+	return ""
+}
+
+func (x *FingerprintRequest) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+type ChannelsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChannelsRequest) Reset() {
+	*x = ChannelsRequest{}
+	mi := &file_ssd_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChannelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChannelsRequest) ProtoMessage() {}
+
+func (x *ChannelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChannelsRequest.ProtoReflect.Descriptor instead.
+func (*ChannelsRequest) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{7}
+}
+
+type ChannelsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channels      []string               `protobuf:"bytes,1,rep,name=channels,proto3" json:"channels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChannelsResponse) Reset() {
+	*x = ChannelsResponse{}
+	mi := &file_ssd_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChannelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChannelsResponse) ProtoMessage() {}
+
+func (x *ChannelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChannelsResponse.ProtoReflect.Descriptor instead.
+func (*ChannelsResponse) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ChannelsResponse) GetChannels() []string {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+type ValueSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	Sum           float64                `protobuf:"fixed64,2,opt,name=sum,proto3" json:"sum,omitempty"`
+	Min           float64                `protobuf:"fixed64,3,opt,name=min,proto3" json:"min,omitempty"`
+	Max           float64                `protobuf:"fixed64,4,opt,name=max,proto3" json:"max,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ValueSummary) Reset() {
+	*x = ValueSummary{}
+	mi := &file_ssd_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ValueSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ValueSummary) ProtoMessage() {}
+
+func (x *ValueSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ValueSummary.ProtoReflect.Descriptor instead.
+func (*ValueSummary) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ValueSummary) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *ValueSummary) GetSum() float64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+func (x *ValueSummary) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *ValueSummary) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+type Record struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Views         int64                  `protobuf:"varint,2,opt,name=views,proto3" json:"views,omitempty"`
+	Clicks        int64                  `protobuf:"varint,3,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	Ftr           int64                  `protobuf:"varint,4,opt,name=ftr,proto3" json:"ftr,omitempty"`
+	Value         *ValueSummary          `protobuf:"bytes,5,opt,name=value,proto3" json:"value,omitempty"`
+	Exposure      float64                `protobuf:"fixed64,6,opt,name=exposure,proto3" json:"exposure,omitempty"`
+	CorrectedCtr  float64                `protobuf:"fixed64,7,opt,name=corrected_ctr,json=correctedCtr,proto3" json:"corrected_ctr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Record) Reset() {
+	*x = Record{}
+	mi := &file_ssd_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Record) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Record) ProtoMessage() {}
+
+func (x *Record) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Record.ProtoReflect.Descriptor instead.
+func (*Record) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Record) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Record) GetViews() int64 {
+	if x != nil {
+		return x.Views
+	}
+	return 0
+}
+
+func (x *Record) GetClicks() int64 {
+	if x != nil {
+		return x.Clicks
+	}
+	return 0
+}
+
+func (x *Record) GetFtr() int64 {
+	if x != nil {
+		return x.Ftr
+	}
+	return 0
+}
+
+func (x *Record) GetValue() *ValueSummary {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *Record) GetExposure() float64 {
+	if x != nil {
+		return x.Exposure
+	}
+	return 0
+}
+
+func (x *Record) GetCorrectedCtr() float64 {
+	if x != nil {
+		return x.CorrectedCtr
+	}
+	return 0
+}
+
+// Records are ordered by ID for List and Fingerprint and by rank for Top.
+type Records struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Records       []*Record              `protobuf:"bytes,1,rep,name=records,proto3" json:"records,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Records) Reset() {
+	*x = Records{}
+	mi := &file_ssd_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Records) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Records) ProtoMessage() {}
+
+func (x *Records) ProtoReflect() protoreflect.Message {
+	mi := &file_ssd_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Records.ProtoReflect.Descriptor instead.
+func (*Records) Descriptor() ([]byte, []int) {
+	return file_ssd_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Records) GetRecords() []*Record {
+	if x != nil {
+		return x.Records
+	}
+	return nil
+}
+
+var File_ssd_proto protoreflect.FileDescriptor
+
+const file_ssd_proto_rawDesc = "" +
+	"\n" +
+	"\tssd.proto\x12\x06ssd.v1\"\xc0\x05\n" +
+	"\x05Event\x12\x14\n" +
+	"\x05views\x18\x01 \x03(\tR\x05views\x12\x16\n" +
+	"\x06clicks\x18\x02 \x03(\tR\x06clicks\x12 \n" +
+	"\vfingerprint\x18\x03 \x01(\tR\vfingerprint\x12\x18\n" +
+	"\achannel\x18\x04 \x01(\tR\achannel\x12+\n" +
+	"\x04dims\x18\x05 \x03(\v2\x17.ssd.v1.Event.DimsEntryR\x04dims\x12@\n" +
+	"\vexperiments\x18\x06 \x03(\v2\x1e.ssd.v1.Event.ExperimentsEntryR\vexperiments\x121\n" +
+	"\x06events\x18\a \x03(\v2\x19.ssd.v1.Event.EventsEntryR\x06events\x121\n" +
+	"\x06values\x18\b \x03(\v2\x19.ssd.v1.Event.ValuesEntryR\x06values\x12:\n" +
+	"\tpositions\x18\t \x03(\v2\x1c.ssd.v1.Event.PositionsEntryR\tpositions\x1a7\n" +
+	"\tDimsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a>\n" +
+	"\x10ExperimentsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aJ\n" +
+	"\vEventsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12%\n" +
+	"\x05value\x18\x02 \x01(\v2\x0f.ssd.v1.ItemIDsR\x05value:\x028\x01\x1a9\n" +
+	"\vValuesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\x1a<\n" +
+	"\x0ePositionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\x1b\n" +
+	"\aItemIDs\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\tR\x03ids\",\n" +
+	"\x0eIngestResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x03R\baccepted\"f\n" +
+	"\rCatalogFilter\x12\x10\n" +
+	"\x03tag\x18\x01 \x01(\tR\x03tag\x12\x1a\n" +
+	"\bcategory\x18\x02 \x01(\tR\bcategory\x12'\n" +
+	"\x0fpublished_after\x18\x03 \x01(\x03R\x0epublishedAfter\"V\n" +
+	"\vListRequest\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\tR\achannel\x12-\n" +
+	"\x06filter\x18\x02 \x01(\v2\x15.ssd.v1.CatalogFilterR\x06filter\"w\n" +
+	"\n" +
+	"TopRequest\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\tR\achannel\x12\x12\n" +
+	"\x04sort\x18\x02 \x01(\tR\x04sort\x12\f\n" +
+	"\x01n\x18\x03 \x01(\x05R\x01n\x12-\n" +
+	"\x06filter\x18\x04 \x01(\v2\x15.ssd.v1.CatalogFilterR\x06filter\"P\n" +
+	"\x12FingerprintRequest\x12\x18\n" +
+	"\achannel\x18\x01 \x01(\tR\achannel\x12 \n" +
+	"\vfingerprint\x18\x02 \x01(\tR\vfingerprint\"\x11\n" +
+	"\x0fChannelsRequest\".\n" +
+	"\x10ChannelsResponse\x12\x1a\n" +
+	"\bchannels\x18\x01 \x03(\tR\bchannels\"Z\n" +
+	"\fValueSummary\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\x12\x10\n" +
+	"\x03sum\x18\x02 \x01(\x01R\x03sum\x12\x10\n" +
+	"\x03min\x18\x03 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x04 \x01(\x01R\x03max\"\xc5\x01\n" +
+	"\x06Record\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05views\x18\x02 \x01(\x03R\x05views\x12\x16\n" +
+	"\x06clicks\x18\x03 \x01(\x03R\x06clicks\x12\x10\n" +
+	"\x03ftr\x18\x04 \x01(\x03R\x03ftr\x12*\n" +
+	"\x05value\x18\x05 \x01(\v2\x14.ssd.v1.ValueSummaryR\x05value\x12\x1a\n" +
+	"\bexposure\x18\x06 \x01(\x01R\bexposure\x12#\n" +
+	"\rcorrected_ctr\x18\a \x01(\x01R\fcorrectedCtr\"3\n" +
+	"\aRecords\x12(\n" +
+	"\arecords\x18\x01 \x03(\v2\x0e.ssd.v1.RecordR\arecords2\xc6\x02\n" +
+	"\x05Stats\x12/\n" +
+	"\x06Ingest\x12\r.ssd.v1.Event\x1a\x16.ssd.v1.IngestResponse\x127\n" +
+	"\fIngestStream\x12\r.ssd.v1.Event\x1a\x16.ssd.v1.IngestResponse(\x01\x12,\n" +
+	"\x04List\x12\x13.ssd.v1.ListRequest\x1a\x0f.ssd.v1.Records\x12*\n" +
+	"\x03Top\x12\x12.ssd.v1.TopRequest\x1a\x0f.ssd.v1.Records\x12:\n" +
+	"\vFingerprint\x12\x1a.ssd.v1.FingerprintRequest\x1a\x0f.ssd.v1.Records\x12=\n" +
+	"\bChannels\x12\x17.ssd.v1.ChannelsRequest\x1a\x18.ssd.v1.ChannelsResponseB\x11Z\x0fssd/internal/pbb\x06proto3"
+
+var (
+	file_ssd_proto_rawDescOnce sync.Once
+	file_ssd_proto_rawDescData []byte
+)
+
+func file_ssd_proto_rawDescGZIP() []byte {
+	file_ssd_proto_rawDescOnce.Do(func() {
+		file_ssd_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ssd_proto_rawDesc), len(file_ssd_proto_rawDesc)))
+	})
+	return file_ssd_proto_rawDescData
+}
+
+var file_ssd_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_ssd_proto_goTypes = []any{
+	(*Event)(nil),              // 0: ssd.v1.Event
+	(*ItemIDs)(nil),            // 1: ssd.v1.ItemIDs
+	(*IngestResponse)(nil),     // 2: ssd.v1.IngestResponse
+	(*CatalogFilter)(nil),      // 3: ssd.v1.CatalogFilter
+	(*ListRequest)(nil),        // 4: ssd.v1.ListRequest
+	(*TopRequest)(nil),         // 5: ssd.v1.TopRequest
+	(*FingerprintRequest)(nil), // 6: ssd.v1.FingerprintRequest
+	(*ChannelsRequest)(nil),    // 7: ssd.v1.ChannelsRequest
+	(*ChannelsResponse)(nil),   // 8: ssd.v1.ChannelsResponse
+	(*ValueSummary)(nil),       // 9: ssd.v1.ValueSummary
+	(*Record)(nil),             // 10: ssd.v1.Record
+	(*Records)(nil),            // 11: ssd.v1.Records
+	nil,                        // 12: ssd.v1.Event.DimsEntry
+	nil,                        // 13: ssd.v1.Event.ExperimentsEntry
+	nil,                        // 14: ssd.v1.Event.EventsEntry
+	nil,                        // 15: ssd.v1.Event.ValuesEntry
+	nil,                        // 16: ssd.v1.Event.PositionsEntry
+}
+var file_ssd_proto_depIdxs = []int32{
+	12, // 0: ssd.v1.Event.dims:type_name -> ssd.v1.Event.DimsEntry
+	13, // 1: ssd.v1.Event.experiments:type_name -> ssd.v1.Event.ExperimentsEntry
+	14, // 2: ssd.v1.Event.events:type_name -> ssd.v1.Event.EventsEntry
+	15, // 3: ssd.v1.Event.values:type_name -> ssd.v1.Event.ValuesEntry
+	16, // 4: ssd.v1.Event.positions:type_name -> ssd.v1.Event.PositionsEntry
+	3,  // 5: ssd.v1.ListRequest.filter:type_name -> ssd.v1.CatalogFilter
+	3,  // 6: ssd.v1.TopRequest.filter:type_name -> ssd.v1.CatalogFilter
+	9,  // 7: ssd.v1.Record.value:type_name -> ssd.v1.ValueSummary
+	10, // 8: ssd.v1.Records.records:type_name -> ssd.v1.Record
+	1,  // 9: ssd.v1.Event.EventsEntry.value:type_name -> ssd.v1.ItemIDs
+	0,  // 10: ssd.v1.Stats.Ingest:input_type -> ssd.v1.Event
+	0,  // 11: ssd.v1.Stats.IngestStream:input_type -> ssd.v1.Event
+	4,  // 12: ssd.v1.Stats.List:input_type -> ssd.v1.ListRequest
+	5,  // 13: ssd.v1.Stats.Top:input_type -> ssd.v1.TopRequest
+	6,  // 14: ssd.v1.Stats.Fingerprint:input_type -> ssd.v1.FingerprintRequest
+	7,  // 15: ssd.v1.Stats.Channels:input_type -> ssd.v1.ChannelsRequest
+	2,  // 16: ssd.v1.Stats.Ingest:output_type -> ssd.v1.IngestResponse
+	2,  // 17: ssd.v1.Stats.IngestStream:output_type -> ssd.v1.IngestResponse
+	11, // 18: ssd.v1.Stats.List:output_type -> ssd.v1.Records
+	11, // 19: ssd.v1.Stats.Top:output_type -> ssd.v1.Records
+	11, // 20: ssd.v1.Stats.Fingerprint:output_type -> ssd.v1.Records
+	8,  // 21: ssd.v1.Stats.Channels:output_type -> ssd.v1.ChannelsResponse
+	16, // [16:22] is the sub-list for method output_type
+	10, // [10:16] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_ssd_proto_init() }
+func file_ssd_proto_init() {
+	if File_ssd_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ssd_proto_rawDesc), len(file_ssd_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ssd_proto_goTypes,
+		DependencyIndexes: file_ssd_proto_depIdxs,
+		MessageInfos:      file_ssd_proto_msgTypes,
+	}.Build()
+	File_ssd_proto = out.File
+	file_ssd_proto_goTypes = nil
+	file_ssd_proto_depIdxs = nil
+}
@@ -12,12 +12,20 @@ type cacheMetricsTestMetrics struct {
 	misses int
 }
 
-func (m *cacheMetricsTestMetrics) IncRequestsTotal(_ string, _ int)                 {}
-func (m *cacheMetricsTestMetrics) ObserveRequestDuration(_ string, _ time.Duration) {}
-func (m *cacheMetricsTestMetrics) IncCacheHits()                                    { m.hits++ }
-func (m *cacheMetricsTestMetrics) IncCacheMisses()                                  { m.misses++ }
-func (m *cacheMetricsTestMetrics) ObservePersistenceDuration(_ time.Duration)       {}
-func (m *cacheMetricsTestMetrics) SetRecordsTotal(_ string, _ int)                  {}
+func (m *cacheMetricsTestMetrics) IncRequestsTotal(_ string, _ int)                     {}
+func (m *cacheMetricsTestMetrics) ObserveRequestDuration(_ string, _ time.Duration)     {}
+func (m *cacheMetricsTestMetrics) IncCacheHits()                                        { m.hits++ }
+func (m *cacheMetricsTestMetrics) IncCacheMisses()                                      { m.misses++ }
+func (m *cacheMetricsTestMetrics) ObservePersistenceDuration(_ time.Duration)           {}
+func (m *cacheMetricsTestMetrics) ObserveAggregationDuration(_ string, _ time.Duration) {}
+func (m *cacheMetricsTestMetrics) ObserveAggregationBatchSize(_ string, _ int)          {}
+func (m *cacheMetricsTestMetrics) SetRecordsTotal(_ string, _ int)                      {}
+func (m *cacheMetricsTestMetrics) SetMemoryBytes(_ string, _ int64)                     {}
+func (m *cacheMetricsTestMetrics) IncIngestFiltered(_ string)                           {}
+func (m *cacheMetricsTestMetrics) SetStreamSubscribers(_ string, _ int)                 {}
+func (m *cacheMetricsTestMetrics) IncStreamDropped()                                    {}
+func (m *cacheMetricsTestMetrics) IncUDPParseErrors()                                   {}
+func (m *cacheMetricsTestMetrics) IncUDPDropped(_ string)                               {}
 
 type cacheMetricsTestInner struct {
 	data map[string][]byte
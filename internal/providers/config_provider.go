@@ -18,10 +18,64 @@ func NewConfigProvider(flags *structures.CliFlags) (*structures.Config, error) {
 
 	viper.BindEnv("logger.level", "SSD_LOG_LEVEL")
 	viper.BindEnv("statistic.interval", "SSD_AGGREGATION_INTERVAL")
+	viper.BindEnv("statistic.maxBufferSize", "SSD_AGGREGATION_MAX_BUFFER")
+	viper.BindEnv("statistic.minInterval", "SSD_AGGREGATION_MIN_INTERVAL")
 	viper.BindEnv("persistence.saveInterval", "SSD_SAVE_INTERVAL")
 	viper.BindEnv("cache.enabled", "SSD_CACHE_ENABLED")
 	viper.BindEnv("cache.size", "SSD_CACHE_SIZE")
 	viper.BindEnv("metrics.enabled", "SSD_METRICS_ENABLED")
+	viper.BindEnv("memory.maxBytes", "SSD_MEMORY_MAX_BYTES")
+	viper.BindEnv("memory.eviction", "SSD_MEMORY_EVICTION")
+	viper.BindEnv("related.enabled", "SSD_RELATED_ENABLED")
+	viper.BindEnv("related.maxNeighbors", "SSD_RELATED_MAX_NEIGHBORS")
+	viper.BindEnv("related.historySize", "SSD_RELATED_HISTORY_SIZE")
+	viper.BindEnv("feed.seenWeight", "SSD_FEED_SEEN_WEIGHT")
+	viper.BindEnv("feed.relatedBoost", "SSD_FEED_RELATED_BOOST")
+	viper.BindEnv("catalog.file", "SSD_CATALOG_FILE")
+	viper.BindEnv("dimensions.allowed", "SSD_DIMENSIONS_ALLOWED")
+	viper.BindEnv("dimensions.maxValues", "SSD_DIMENSIONS_MAX_VALUES")
+	viper.BindEnv("userAgent.enabled", "SSD_USER_AGENT_ENABLED")
+	viper.BindEnv("userAgent.rulesFile", "SSD_USER_AGENT_RULES_FILE")
+	viper.BindEnv("userAgent.botAction", "SSD_USER_AGENT_BOT_ACTION")
+	viper.BindEnv("userAgent.dimensions", "SSD_USER_AGENT_DIMENSIONS")
+	viper.BindEnv("geoip.enabled", "SSD_GEOIP_ENABLED")
+	viper.BindEnv("geoip.database", "SSD_GEOIP_DATABASE")
+	viper.BindEnv("geoip.trustedProxies", "SSD_GEOIP_TRUSTED_PROXIES")
+	viper.BindEnv("geoip.region", "SSD_GEOIP_REGION")
+	viper.BindEnv("experiments.enabled", "SSD_EXPERIMENTS_ENABLED")
+	viper.BindEnv("experiments.maxExperiments", "SSD_EXPERIMENTS_MAX_EXPERIMENTS")
+	viper.BindEnv("experiments.maxVariants", "SSD_EXPERIMENTS_MAX_VARIANTS")
+	viper.BindEnv("experiments.ttl", "SSD_EXPERIMENTS_TTL")
+	viper.BindEnv("experiments.alpha", "SSD_EXPERIMENTS_ALPHA")
+	viper.BindEnv("positions.enabled", "SSD_POSITIONS_ENABLED")
+	viper.BindEnv("positions.maxPositions", "SSD_POSITIONS_MAX_POSITIONS")
+	viper.BindEnv("positions.minViews", "SSD_POSITIONS_MIN_VIEWS")
+	viper.BindEnv("rising.enabled", "SSD_RISING_ENABLED")
+	viper.BindEnv("rising.ticks", "SSD_RISING_TICKS")
+	viper.BindEnv("rising.minViews", "SSD_RISING_MIN_VIEWS")
+	viper.BindEnv("anomalies.enabled", "SSD_ANOMALIES_ENABLED")
+	viper.BindEnv("anomalies.threshold", "SSD_ANOMALIES_THRESHOLD")
+	viper.BindEnv("anomalies.minVolume", "SSD_ANOMALIES_MIN_VOLUME")
+	viper.BindEnv("anomalies.cooldown", "SSD_ANOMALIES_COOLDOWN")
+	viper.BindEnv("webhooks.urls", "SSD_WEBHOOKS_URLS")
+	viper.BindEnv("webhooks.maxRetries", "SSD_WEBHOOKS_MAX_RETRIES")
+	viper.BindEnv("webhooks.secret", "SSD_WEBHOOKS_SECRET")
+	viper.BindEnv("webhooks.deadLetterFile", "SSD_WEBHOOKS_DEAD_LETTER_FILE")
+	viper.BindEnv("stream.enabled", "SSD_STREAM_ENABLED")
+	viper.BindEnv("stream.maxSubscribers", "SSD_STREAM_MAX_SUBSCRIBERS")
+	viper.BindEnv("stream.heartbeat", "SSD_STREAM_HEARTBEAT")
+	viper.BindEnv("websocket.enabled", "SSD_WEBSOCKET_ENABLED")
+	viper.BindEnv("websocket.maxConnections", "SSD_WEBSOCKET_MAX_CONNECTIONS")
+	viper.BindEnv("websocket.pingInterval", "SSD_WEBSOCKET_PING_INTERVAL")
+	viper.BindEnv("cors.enabled", "SSD_CORS_ENABLED")
+	viper.BindEnv("cors.allowedOrigins", "SSD_CORS_ALLOWED_ORIGINS")
+	viper.BindEnv("cors.maxAge", "SSD_CORS_MAX_AGE")
+	viper.BindEnv("grpc.enabled", "SSD_GRPC_ENABLED")
+	viper.BindEnv("udp.enabled", "SSD_UDP_ENABLED")
+	viper.BindEnv("udp.rateLimit", "SSD_UDP_RATE_LIMIT")
+	viper.BindEnv("webServer.unixSocket.path", "SSD_UNIX_SOCKET_PATH")
+	viper.BindEnv("webServer.unixSocket.mode", "SSD_UNIX_SOCKET_MODE")
+	viper.BindEnv("webServer.unixSocket.only", "SSD_UNIX_SOCKET_ONLY")
 
 	err := viper.ReadInConfig()
 	if err != nil {
@@ -61,3 +61,30 @@ func TestConfigValidator_InvalidLogLevel(t *testing.T) {
 	v := NewCnfValidator(c)
 	assert.Error(t, v.Validate())
 }
+
+func TestConfigValidator_EvictionPolicy(t *testing.T) {
+	c := validConfig()
+	c.Memory.Eviction = "items"
+	assert.NoError(t, NewCnfValidator(c).Validate())
+
+	c.Memory.Eviction = "random"
+	assert.Error(t, NewCnfValidator(c).Validate())
+}
+
+func TestConfigValidator_BotAction(t *testing.T) {
+	c := validConfig()
+	c.UserAgent.BotAction = "segregate"
+	assert.NoError(t, NewCnfValidator(c).Validate())
+
+	c.UserAgent.BotAction = "block"
+	assert.Error(t, NewCnfValidator(c).Validate())
+}
+
+func TestConfigValidator_UnixSocketPath(t *testing.T) {
+	c := validConfig()
+	c.WebServer.UnixSocket.Path = "/run/ssd/ssd.sock"
+	assert.NoError(t, NewCnfValidator(c).Validate())
+
+	c.WebServer.UnixSocket.Path = "run\x00ssd.sock"
+	assert.Error(t, NewCnfValidator(c).Validate())
+}
@@ -0,0 +1,112 @@
+package providers
+
+import (
+	"net/http"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"strconv"
+	"strings"
+)
+
+const defaultCORSHeaders = "Content-Type"
+
+// CORSPolicyInterface decides which browser origins may call the API. Wrap
+// answers preflights for a route; Allowed is also checked by the ingest
+// handlers once the channel of an event is known, since browsers send simple
+// requests without a preflight.
+type CORSPolicyInterface interface {
+	Wrap(method string, next http.Handler) http.Handler
+	Allowed(origin, channel string) bool
+}
+
+// corsPolicy is the policy of cors.enabled.
+type corsPolicy struct {
+	origins  []string
+	channels map[string][]string
+	headers  string
+	maxAge   string
+}
+
+func NewCORSPolicy(conf *structures.Config) CORSPolicyInterface {
+	if !conf.CORS.Enabled {
+		return noopCORS{}
+	}
+	p := &corsPolicy{
+		origins:  conf.CORS.AllowedOrigins,
+		channels: conf.CORS.Channels,
+		headers:  strings.Join(conf.CORS.AllowedHeaders, ", "),
+	}
+	if p.headers == "" {
+		p.headers = defaultCORSHeaders
+	}
+	if conf.CORS.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(conf.CORS.MaxAge.Seconds()))
+	}
+	return p
+}
+
+// Allowed reports whether origin may send events to channel; an empty
+// channel is the default one. A channel listed under channels uses its own
+// origins instead of allowedOrigins.
+func (p *corsPolicy) Allowed(origin, channel string) bool {
+	if channel == "" {
+		channel = services.DefaultChannel
+	}
+	origins := p.origins
+	if list, ok := p.channels[channel]; ok {
+		origins = list
+	}
+	for _, o := range origins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Wrap answers preflights for the route's method and adds the allow-origin
+// header to requests from allowed origins. Requests from other origins are
+// served without it, so the browser withholds the response. A preflight
+// carries no body, so it is checked against the channel in the ch query.
+func (p *corsPolicy) Wrap(method string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		requested := r.Header.Get("Access-Control-Request-Method")
+		preflight := r.Method == http.MethodOptions && origin != "" && requested != ""
+		if origin == "" || !p.Allowed(origin, r.URL.Query().Get("ch")) {
+			if preflight {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !preflight {
+			header.Set("Access-Control-Allow-Origin", origin)
+			next.ServeHTTP(w, r)
+			return
+		}
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+		if requested != method {
+			header.Set("Allow", method)
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		header.Set("Access-Control-Allow-Origin", origin)
+		header.Set("Access-Control-Allow-Methods", method)
+		header.Set("Access-Control-Allow-Headers", p.headers)
+		if p.maxAge != "" {
+			header.Set("Access-Control-Max-Age", p.maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// noopCORS leaves routes unchanged and restricts no origin when CORS is off.
+type noopCORS struct{}
+
+func (noopCORS) Wrap(_ string, next http.Handler) http.Handler { return next }
+func (noopCORS) Allowed(_, _ string) bool                      { return true }
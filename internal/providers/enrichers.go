@@ -0,0 +1,9 @@
+package providers
+
+import "ssd/internal/services"
+
+// NewEnrichers orders the enrichers of the ingest service: bot traffic is
+// dropped before its address is looked up.
+func NewEnrichers(agents UserAgentProviderInterface, geo GeoIPProviderInterface) []services.Enricher {
+	return []services.Enricher{agents, geo}
+}
@@ -0,0 +1,54 @@
+package providers
+
+import (
+	"os"
+	"time"
+)
+
+// fileWatcher reports changes of a file's modification time. Providers poll
+// it from a background worker, so requests never wait for a reload.
+type fileWatcher struct {
+	path     string
+	interval time.Duration
+	modTime  int64
+}
+
+func newFileWatcher(path string, interval time.Duration) *fileWatcher {
+	fw := &fileWatcher{path: path, interval: interval}
+	if info, err := os.Stat(path); err == nil {
+		fw.modTime = info.ModTime().UnixNano()
+	}
+	return fw
+}
+
+// modified stats the file now and reports whether it was modified since the
+// last change was reported.
+func (fw *fileWatcher) modified() bool {
+	info, err := os.Stat(fw.path)
+	if err != nil {
+		return false
+	}
+	mod := info.ModTime().UnixNano()
+	if mod == fw.modTime {
+		return false
+	}
+	fw.modTime = mod
+	return true
+}
+
+// watch checks the file once per interval and calls reload when it changed,
+// until stop is closed.
+func (fw *fileWatcher) watch(stop <-chan struct{}, reload func()) {
+	ticker := time.NewTicker(fw.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if fw.modified() {
+				reload()
+			}
+		}
+	}
+}
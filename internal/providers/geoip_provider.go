@@ -0,0 +1,195 @@
+package providers
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"ssd/internal/models"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+const (
+	countryDimension = "country"
+	regionDimension  = "region"
+
+	defaultGeoIPReload = time.Minute
+)
+
+// GeoIPProviderInterface resolves the client address of an ingested event to
+// country and region dimensions. The address itself is never stored, and no
+// event is dropped. Close stops the reload of the database.
+type GeoIPProviderInterface interface {
+	services.Enricher
+	Close()
+}
+
+// geoRecord is the subset of a GeoIP2/GeoLite2 Country or City record we read.
+type geoRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+}
+
+type GeoIPProvider struct {
+	conf    structures.GeoIPConfig
+	logger  Logger
+	trusted []netip.Prefix
+	db      atomic.Pointer[maxminddb.Reader]
+	watcher *fileWatcher
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// ClientIP returns the address of the client of src. X-Forwarded-For is
+// only honored when the direct peer is a trusted proxy; the chain is walked
+// from the right and the first untrusted hop is the client.
+func (p *GeoIPProvider) ClientIP(src services.Source) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(src.RemoteAddr)
+	if err != nil {
+		host = src.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	addr = addr.Unmap()
+	if !p.isTrusted(addr) {
+		return addr, true
+	}
+
+	hops := strings.Split(strings.Join(src.ForwardedFor, ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !p.isTrusted(addr) {
+			break
+		}
+	}
+	return addr, true
+}
+
+func (p *GeoIPProvider) isTrusted(addr netip.Addr) bool {
+	for _, prefix := range p.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Enrich sets the country (and, with geoip.region, the region) dimension.
+// These dimensions belong to the server: values sent by the client are
+// dropped, so an unknown address records no location rather than a spoofed
+// one.
+func (p *GeoIPProvider) Enrich(src services.Source, payload *models.InputStats) bool {
+	delete(payload.Dims, countryDimension)
+	if p.conf.Region {
+		delete(payload.Dims, regionDimension)
+	}
+
+	addr, ok := p.ClientIP(src)
+	if !ok {
+		return true
+	}
+	var rec geoRecord
+	if err := p.db.Load().Lookup(net.IP(addr.AsSlice()), &rec); err != nil || rec.Country.ISOCode == "" {
+		return true
+	}
+
+	if payload.Dims == nil {
+		payload.Dims = make(map[string]string, 2)
+	}
+	payload.Dims[countryDimension] = rec.Country.ISOCode
+	if p.conf.Region && len(rec.Subdivisions) > 0 {
+		payload.Dims[regionDimension] = rec.Country.ISOCode + "-" + rec.Subdivisions[0].ISOCode
+	}
+	return true
+}
+
+// Close stops the reload worker.
+func (p *GeoIPProvider) Close() {
+	p.once.Do(func() {
+		close(p.stop)
+		p.wg.Wait()
+	})
+}
+
+// reload swaps in the changed database, keeping the current one on error.
+// It runs on the reload worker, so ingest requests never wait for it.
+// The file is read into memory rather than mapped, so in-flight lookups on
+// the previous reader stay valid until it is garbage collected.
+func (p *GeoIPProvider) reload() {
+	db, err := openGeoIPDatabase(p.conf.Database)
+	if err != nil {
+		p.logger.Errorf(TypeApp, "GeoIP database reload failed: %s", err)
+		return
+	}
+	p.db.Store(db)
+	p.logger.Infof(TypeApp, "Loaded GeoIP database %s (%s)", p.conf.Database, db.Metadata.DatabaseType)
+}
+
+func openGeoIPDatabase(path string) (*maxminddb.Reader, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return maxminddb.FromBytes(buf)
+}
+
+func NewGeoIPProvider(conf *structures.Config, logger Logger) (GeoIPProviderInterface, error) {
+	if !conf.GeoIP.Enabled {
+		return &noopGeoIP{}, nil
+	}
+
+	p := &GeoIPProvider{conf: conf.GeoIP, logger: logger, stop: make(chan struct{})}
+	for _, cidr := range p.conf.TrustedProxies {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(cidr)
+			if addrErr != nil {
+				return nil, fmt.Errorf("geoip.trustedProxies: %w", err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		p.trusted = append(p.trusted, prefix.Masked())
+	}
+
+	db, err := openGeoIPDatabase(p.conf.Database)
+	if err != nil {
+		return nil, fmt.Errorf("geoip.database: %w", err)
+	}
+	p.db.Store(db)
+
+	interval := p.conf.ReloadInterval
+	if interval <= 0 {
+		interval = defaultGeoIPReload
+	}
+	p.watcher = newFileWatcher(p.conf.Database, interval)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.watcher.watch(p.stop, p.reload)
+	}()
+	return p, nil
+}
+
+// noopGeoIP leaves events unchanged when GeoIP enrichment is off.
+type noopGeoIP struct{}
+
+func (n *noopGeoIP) Enrich(_ services.Source, _ *models.InputStats) bool { return true }
+func (n *noopGeoIP) Close()                                              {}
@@ -0,0 +1,219 @@
+package providers
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"ssd/internal/models"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// --- minimal MaxMind DB writer (IPv4 tree, 24-bit records) ---
+
+type mmdbEntry struct {
+	prefix      string
+	country     string
+	subdivision string
+}
+
+func mmdbString(s string) []byte { return append([]byte{0x40 | byte(len(s))}, s...) }
+
+func mmdbUint16(v uint16) []byte {
+	return binary.BigEndian.AppendUint16([]byte{5<<5 | 2}, v)
+}
+
+func mmdbUint32(v uint32) []byte {
+	return binary.BigEndian.AppendUint32([]byte{6<<5 | 4}, v)
+}
+
+func mmdbMap(pairs ...[]byte) []byte {
+	out := []byte{7<<5 | byte(len(pairs)/2)}
+	for _, p := range pairs {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func mmdbRecord(e mmdbEntry) []byte {
+	pairs := [][]byte{mmdbString("country"), mmdbMap(mmdbString("iso_code"), mmdbString(e.country))}
+	if e.subdivision != "" {
+		// Arrays are an extended type: control byte type 0, next byte 11-7.
+		subdivisions := append([]byte{1, 4}, mmdbMap(mmdbString("iso_code"), mmdbString(e.subdivision))...)
+		pairs = append(pairs, mmdbString("subdivisions"), subdivisions)
+	}
+	return mmdbMap(pairs...)
+}
+
+func writeTestMMDB(t *testing.T, path string, entries ...mmdbEntry) {
+	t.Helper()
+	const empty = -1
+	// Records >= 0 point at nodes, empty means "not found", values below
+	// empty are data offsets encoded as -offset-2.
+	nodes := [][2]int{{empty, empty}}
+	var data []byte
+	for _, e := range entries {
+		prefix := netip.MustParsePrefix(e.prefix)
+		ip := prefix.Addr().As4()
+		node := 0
+		for bit := 0; bit < prefix.Bits(); bit++ {
+			side := int(ip[bit/8]>>(7-bit%8)) & 1
+			if bit == prefix.Bits()-1 {
+				nodes[node][side] = -len(data) - 2
+				break
+			}
+			if nodes[node][side] < 0 {
+				nodes = append(nodes, [2]int{empty, empty})
+				nodes[node][side] = len(nodes) - 1
+			}
+			node = nodes[node][side]
+		}
+		data = append(data, mmdbRecord(e)...)
+	}
+
+	count := len(nodes)
+	var buf []byte
+	for _, n := range nodes {
+		for _, rec := range n {
+			v := rec
+			switch {
+			case rec == empty:
+				v = count
+			case rec < empty:
+				v = count + 16 - rec - 2
+			}
+			buf = append(buf, byte(v>>16), byte(v>>8), byte(v))
+		}
+	}
+	buf = append(buf, make([]byte, 16)...)
+	buf = append(buf, data...)
+	buf = append(buf, "\xAB\xCD\xEFMaxMind.com"...)
+	buf = append(buf, mmdbMap(
+		mmdbString("node_count"), mmdbUint32(uint32(count)),
+		mmdbString("record_size"), mmdbUint16(24),
+		mmdbString("ip_version"), mmdbUint16(4),
+		mmdbString("database_type"), mmdbString("Test-City"),
+	)...)
+	require.NoError(t, os.WriteFile(path, buf, 0o644))
+}
+
+// --- tests ---
+
+func newTestGeoIPProvider(t *testing.T, conf structures.GeoIPConfig) *GeoIPProvider {
+	t.Helper()
+	if conf.Database == "" {
+		conf.Database = filepath.Join(t.TempDir(), "geo.mmdb")
+		writeTestMMDB(t, conf.Database,
+			mmdbEntry{prefix: "81.0.0.0/8", country: "DE", subdivision: "BE"},
+			mmdbEntry{prefix: "8.8.8.0/24", country: "US"},
+		)
+	}
+	conf.Enabled = true
+	p, err := NewGeoIPProvider(&structures.Config{GeoIP: conf}, &cacheTestLogger{})
+	require.NoError(t, err)
+	t.Cleanup(p.Close)
+	return p.(*GeoIPProvider)
+}
+
+func geoEnrich(p *GeoIPProvider, remote string, xff ...string) map[string]string {
+	payload := &models.InputStats{}
+	p.Enrich(services.Source{RemoteAddr: remote, ForwardedFor: xff}, payload)
+	return payload.Dims
+}
+
+func TestGeoIPProvider_CountryAndRegion(t *testing.T) {
+	p := newTestGeoIPProvider(t, structures.GeoIPConfig{Region: true})
+
+	assert.Equal(t, map[string]string{"country": "DE", "region": "DE-BE"}, geoEnrich(p, "81.2.3.4:5555"))
+	assert.Equal(t, map[string]string{"country": "US"}, geoEnrich(p, "[::ffff:8.8.8.8]:5555"))
+	assert.Nil(t, geoEnrich(p, "192.0.2.1:5555"), "unknown addresses add no dimensions")
+	assert.Nil(t, geoEnrich(p, "[2001:db8::1]:5555"))
+}
+
+func TestGeoIPProvider_RegionDisabled(t *testing.T) {
+	p := newTestGeoIPProvider(t, structures.GeoIPConfig{})
+	assert.Equal(t, map[string]string{"country": "DE"}, geoEnrich(p, "81.2.3.4:5555"))
+}
+
+func TestGeoIPProvider_TrustedForwardedFor(t *testing.T) {
+	p := newTestGeoIPProvider(t, structures.GeoIPConfig{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.7"}})
+
+	assert.Nil(t, geoEnrich(p, "203.0.113.9:80", "81.2.3.4"), "untrusted peers cannot forward")
+	assert.Equal(t, "DE", geoEnrich(p, "10.0.0.1:80", "81.2.3.4")["country"])
+	assert.Equal(t, "DE", geoEnrich(p, "10.0.0.1:80", "81.2.3.4, 10.1.1.1", "192.0.2.7")["country"])
+	assert.Equal(t, "DE", geoEnrich(p, "10.0.0.1:80", "8.8.8.8, 81.2.3.4")["country"], "spoofed leading hops are ignored")
+	assert.Nil(t, geoEnrich(p, "10.0.0.1:80", "garbage"))
+}
+
+func TestGeoIPProvider_ServerDimensionsWin(t *testing.T) {
+	p := newTestGeoIPProvider(t, structures.GeoIPConfig{Region: true})
+
+	payload := &models.InputStats{Dims: map[string]string{"country": "FR", "region": "FR-IDF", "device": "mobile"}}
+	p.Enrich(services.Source{RemoteAddr: "81.2.3.4:5555"}, payload)
+	assert.Equal(t, map[string]string{"country": "DE", "region": "DE-BE", "device": "mobile"}, payload.Dims)
+
+	payload = &models.InputStats{Dims: map[string]string{"country": "FR", "device": "mobile"}}
+	p.Enrich(services.Source{RemoteAddr: "192.0.2.1:5555"}, payload)
+	assert.Equal(t, map[string]string{"device": "mobile"}, payload.Dims, "unknown addresses cannot claim a location")
+}
+
+func TestGeoIPProvider_HotReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geo.mmdb")
+	writeTestMMDB(t, path, mmdbEntry{prefix: "81.0.0.0/8", country: "DE"})
+	p := newTestGeoIPProvider(t, structures.GeoIPConfig{Database: path, ReloadInterval: time.Millisecond})
+	assert.Equal(t, "DE", geoEnrich(p, "81.2.3.4:5555")["country"])
+
+	require.NoError(t, os.WriteFile(path, []byte("corrupt"), 0o644))
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, future, future))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, "DE", geoEnrich(p, "81.2.3.4:5555")["country"], "a broken file keeps the loaded database")
+
+	writeTestMMDB(t, path, mmdbEntry{prefix: "81.0.0.0/8", country: "AT"})
+	future = future.Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, future, future))
+	assert.Eventually(t, func() bool {
+		return geoEnrich(p, "81.2.3.4:5555")["country"] == "AT"
+	}, time.Second, time.Millisecond, "the database is reloaded in the background")
+}
+
+func TestGeoIPProvider_EnrichDoesNotReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "geo.mmdb")
+	writeTestMMDB(t, path, mmdbEntry{prefix: "81.0.0.0/8", country: "DE"})
+	p := newTestGeoIPProvider(t, structures.GeoIPConfig{Database: path, ReloadInterval: time.Hour})
+
+	writeTestMMDB(t, path, mmdbEntry{prefix: "81.0.0.0/8", country: "AT"})
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, future, future))
+	assert.Equal(t, "DE", geoEnrich(p, "81.2.3.4:5555")["country"])
+
+	p.Close()
+	p.Close()
+}
+
+func TestGeoIPProvider_InvalidConfig(t *testing.T) {
+	conf := &structures.Config{GeoIP: structures.GeoIPConfig{Enabled: true, Database: filepath.Join(t.TempDir(), "missing.mmdb")}}
+	_, err := NewGeoIPProvider(conf, &cacheTestLogger{})
+	assert.Error(t, err)
+
+	conf.GeoIP.Database = filepath.Join(t.TempDir(), "geo.mmdb")
+	writeTestMMDB(t, conf.GeoIP.Database, mmdbEntry{prefix: "81.0.0.0/8", country: "DE"})
+	conf.GeoIP.TrustedProxies = []string{"not-a-cidr"}
+	_, err = NewGeoIPProvider(conf, &cacheTestLogger{})
+	assert.Error(t, err)
+}
+
+func TestGeoIPProvider_DisabledIsNoop(t *testing.T) {
+	p, err := NewGeoIPProvider(&structures.Config{}, &cacheTestLogger{})
+	require.NoError(t, err)
+
+	payload := &models.InputStats{}
+	p.Enrich(services.Source{}, payload)
+	assert.Nil(t, payload.Dims)
+}
@@ -1,8 +1,16 @@
 package providers
 
 import (
+	"bufio"
+	"context"
+	"net"
 	"net/http"
+	"strings"
 	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type statusWriter struct {
@@ -19,6 +27,15 @@ func (w *statusWriter) Unwrap() http.ResponseWriter {
 	return w.ResponseWriter
 }
 
+// Hijack lets WebSocket upgrades through; the request is counted as 101.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
 func MetricsMiddleware(metrics MetricsProviderInterface, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -27,8 +44,75 @@ func MetricsMiddleware(metrics MetricsProviderInterface, next http.Handler) http
 		next.ServeHTTP(sw, r)
 
 		duration := time.Since(start)
-		endpoint := r.URL.Path
+		// The matched mux pattern keeps path parameters such as
+		// /experiments/{id} from creating a label value per request.
+		endpoint := r.Pattern
+		if endpoint == "" || endpoint == "/" {
+			endpoint = r.URL.Path
+		}
 		metrics.IncRequestsTotal(endpoint, sw.status)
 		metrics.ObserveRequestDuration(endpoint, duration)
 	})
 }
+
+// MetricsUnaryInterceptor counts gRPC calls in the request metrics, labeled
+// by full method name and the HTTP status matching the gRPC code. Health
+// checks are not counted, like /health.
+func MetricsUnaryInterceptor(metrics MetricsProviderInterface) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeGrpc(metrics, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// MetricsStreamInterceptor is MetricsUnaryInterceptor for streaming calls,
+// which are observed once they end.
+func MetricsStreamInterceptor(metrics MetricsProviderInterface) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observeGrpc(metrics, info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observeGrpc(metrics MetricsProviderInterface, method string, start time.Time, err error) {
+	if strings.HasPrefix(method, "/grpc.health.v1.Health/") {
+		return
+	}
+	metrics.IncRequestsTotal(method, grpcHTTPStatus(status.Code(err)))
+	metrics.ObserveRequestDuration(method, time.Since(start))
+}
+
+// grpcHTTPStatus maps a gRPC code to the HTTP status of the same meaning, so
+// both transports share the status classes of ssd_requests_total.
+func grpcHTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // client closed request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
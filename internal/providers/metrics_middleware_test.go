@@ -1,12 +1,16 @@
 package providers
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type mockMetrics struct {
@@ -14,6 +18,11 @@ type mockMetrics struct {
 	requestStatus   int
 	requestCalls    int
 	durationCalls   int
+	filtered        map[string]int
+	streamSubs      map[string]int
+	streamDropped   int
+	udpParseErrors  int
+	udpDropped      map[string]int
 }
 
 func (m *mockMetrics) IncRequestsTotal(endpoint string, status int) {
@@ -21,11 +30,38 @@ func (m *mockMetrics) IncRequestsTotal(endpoint string, status int) {
 	m.requestStatus = status
 	m.requestCalls++
 }
-func (m *mockMetrics) ObserveRequestDuration(_ string, _ time.Duration) { m.durationCalls++ }
-func (m *mockMetrics) IncCacheHits()                                    {}
-func (m *mockMetrics) IncCacheMisses()                                  {}
-func (m *mockMetrics) ObservePersistenceDuration(_ time.Duration)       {}
-func (m *mockMetrics) SetRecordsTotal(_ string, _ int)                  {}
+func (m *mockMetrics) ObserveRequestDuration(_ string, _ time.Duration)     { m.durationCalls++ }
+func (m *mockMetrics) IncCacheHits()                                        {}
+func (m *mockMetrics) IncCacheMisses()                                      {}
+func (m *mockMetrics) ObservePersistenceDuration(_ time.Duration)           {}
+func (m *mockMetrics) ObserveAggregationDuration(_ string, _ time.Duration) {}
+func (m *mockMetrics) ObserveAggregationBatchSize(_ string, _ int)          {}
+func (m *mockMetrics) SetRecordsTotal(_ string, _ int)                      {}
+func (m *mockMetrics) SetMemoryBytes(_ string, _ int64)                     {}
+
+func (m *mockMetrics) IncIngestFiltered(reason string) {
+	if m.filtered == nil {
+		m.filtered = make(map[string]int)
+	}
+	m.filtered[reason]++
+}
+
+func (m *mockMetrics) SetStreamSubscribers(channel string, count int) {
+	if m.streamSubs == nil {
+		m.streamSubs = make(map[string]int)
+	}
+	m.streamSubs[channel] = count
+}
+
+func (m *mockMetrics) IncStreamDropped()  { m.streamDropped++ }
+func (m *mockMetrics) IncUDPParseErrors() { m.udpParseErrors++ }
+
+func (m *mockMetrics) IncUDPDropped(reason string) {
+	if m.udpDropped == nil {
+		m.udpDropped = make(map[string]int)
+	}
+	m.udpDropped[reason]++
+}
 
 func TestMetricsMiddleware_CapturesStatusAndEndpoint(t *testing.T) {
 	metrics := &mockMetrics{}
@@ -46,6 +82,20 @@ func TestMetricsMiddleware_CapturesStatusAndEndpoint(t *testing.T) {
 	assert.Equal(t, 1, metrics.durationCalls)
 }
 
+func TestMetricsMiddleware_UsesRoutePattern(t *testing.T) {
+	metrics := &mockMetrics{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/experiments/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {})
+	mw := MetricsMiddleware(metrics, mux)
+
+	mw.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/experiments/headline-42", nil))
+	assert.Equal(t, "/experiments/{id}", metrics.requestEndpoint)
+
+	mw.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/unknown", nil))
+	assert.Equal(t, "/unknown", metrics.requestEndpoint)
+}
+
 func TestMetricsMiddleware_DefaultStatus200(t *testing.T) {
 	metrics := &mockMetrics{}
 
@@ -70,3 +120,22 @@ func TestStatusWriter_WriteHeader(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, sw.status)
 	assert.Equal(t, http.StatusNotFound, rr.Code)
 }
+
+func TestMetricsUnaryInterceptor_MapsStatus(t *testing.T) {
+	metrics := &mockMetrics{}
+	intercept := MetricsUnaryInterceptor(metrics)
+	info := &grpc.UnaryServerInfo{FullMethod: "/ssd.v1.Stats/Top"}
+
+	_, _ = intercept(context.Background(), nil, info, func(context.Context, any) (any, error) { return nil, nil })
+	assert.Equal(t, "/ssd.v1.Stats/Top", metrics.requestEndpoint)
+	assert.Equal(t, http.StatusOK, metrics.requestStatus)
+
+	_, _ = intercept(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		return nil, status.Error(codes.InvalidArgument, "bad sort")
+	})
+	assert.Equal(t, http.StatusBadRequest, metrics.requestStatus)
+
+	health := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	_, _ = intercept(context.Background(), nil, health, func(context.Context, any) (any, error) { return nil, nil })
+	assert.Equal(t, 2, metrics.requestCalls, "health checks are not counted")
+}
@@ -14,7 +14,15 @@ type MetricsProviderInterface interface {
 	IncCacheHits()
 	IncCacheMisses()
 	ObservePersistenceDuration(duration time.Duration)
+	ObserveAggregationDuration(trigger string, duration time.Duration)
+	ObserveAggregationBatchSize(trigger string, size int)
 	SetRecordsTotal(channel string, count int)
+	SetMemoryBytes(channel string, bytes int64)
+	IncIngestFiltered(reason string)
+	SetStreamSubscribers(channel string, count int)
+	IncStreamDropped()
+	IncUDPParseErrors()
+	IncUDPDropped(reason string)
 }
 
 type MetricsProvider struct {
@@ -23,7 +31,15 @@ type MetricsProvider struct {
 	cacheHits           prometheus.Counter
 	cacheMisses         prometheus.Counter
 	persistenceDuration prometheus.Histogram
+	aggregationDuration *prometheus.HistogramVec
+	aggregationBatch    *prometheus.HistogramVec
 	recordsTotal        *prometheus.GaugeVec
+	memoryBytes         *prometheus.GaugeVec
+	ingestFiltered      *prometheus.CounterVec
+	streamSubscribers   *prometheus.GaugeVec
+	streamDropped       prometheus.Counter
+	udpParseErrors      prometheus.Counter
+	udpDropped          *prometheus.CounterVec
 }
 
 func (m *MetricsProvider) IncRequestsTotal(endpoint string, status int) {
@@ -46,10 +62,42 @@ func (m *MetricsProvider) ObservePersistenceDuration(duration time.Duration) {
 	m.persistenceDuration.Observe(duration.Seconds())
 }
 
+func (m *MetricsProvider) ObserveAggregationDuration(trigger string, duration time.Duration) {
+	m.aggregationDuration.WithLabelValues(trigger).Observe(duration.Seconds())
+}
+
+func (m *MetricsProvider) ObserveAggregationBatchSize(trigger string, size int) {
+	m.aggregationBatch.WithLabelValues(trigger).Observe(float64(size))
+}
+
 func (m *MetricsProvider) SetRecordsTotal(channel string, count int) {
 	m.recordsTotal.WithLabelValues(channel).Set(float64(count))
 }
 
+func (m *MetricsProvider) SetMemoryBytes(channel string, bytes int64) {
+	m.memoryBytes.WithLabelValues(channel).Set(float64(bytes))
+}
+
+func (m *MetricsProvider) IncIngestFiltered(reason string) {
+	m.ingestFiltered.WithLabelValues(reason).Inc()
+}
+
+func (m *MetricsProvider) SetStreamSubscribers(channel string, count int) {
+	m.streamSubscribers.WithLabelValues(channel).Set(float64(count))
+}
+
+func (m *MetricsProvider) IncStreamDropped() {
+	m.streamDropped.Inc()
+}
+
+func (m *MetricsProvider) IncUDPParseErrors() {
+	m.udpParseErrors.Inc()
+}
+
+func (m *MetricsProvider) IncUDPDropped(reason string) {
+	m.udpDropped.WithLabelValues(reason).Inc()
+}
+
 func httpStatusBucket(code int) string {
 	switch {
 	case code < 200:
@@ -73,12 +121,12 @@ func NewMetricsProvider(conf *structures.Config, service services.StatisticServi
 	m := &MetricsProvider{
 		requestsTotal: promauto.NewCounterVec(prometheus.CounterOpts{
 			Name: "ssd_requests_total",
-			Help: "Total number of HTTP requests",
+			Help: "Total number of HTTP and gRPC requests",
 		}, []string{"endpoint", "status"}),
 
 		requestDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
 			Name:    "ssd_request_duration_seconds",
-			Help:    "HTTP request duration in seconds",
+			Help:    "HTTP and gRPC request duration in seconds",
 			Buckets: prometheus.DefBuckets,
 		}, []string{"endpoint"}),
 
@@ -98,10 +146,50 @@ func NewMetricsProvider(conf *structures.Config, service services.StatisticServi
 			Buckets: prometheus.DefBuckets,
 		}),
 
+		aggregationDuration: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ssd_aggregation_duration_seconds",
+			Help:    "Duration of aggregation runs in seconds",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"trigger"}),
+
+		aggregationBatch: promauto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ssd_aggregation_batch_size",
+			Help:    "Number of buffered items processed per aggregation run",
+			Buckets: prometheus.ExponentialBuckets(1, 4, 11),
+		}, []string{"trigger"}),
+
 		recordsTotal: promauto.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "ssd_records_total",
 			Help: "Total number of stat records per channel",
 		}, []string{"channel"}),
+
+		memoryBytes: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ssd_memory_bytes",
+			Help: "Approximate memory held by stat records per channel",
+		}, []string{"channel"}),
+
+		ingestFiltered: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ssd_ingest_filtered_total",
+			Help: "Total number of ingested events dropped or segregated by filters",
+		}, []string{"reason"}),
+
+		streamSubscribers: promauto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ssd_stream_subscribers",
+			Help: "Current number of /stream subscribers per channel",
+		}, []string{"channel"}),
+
+		streamDropped: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "ssd_stream_dropped_total",
+			Help: "Total number of /stream subscribers dropped for falling behind",
+		}),
+		udpParseErrors: promauto.NewCounter(prometheus.CounterOpts{
+			Name: "ssd_udp_parse_errors_total",
+			Help: "Total number of malformed UDP event lines",
+		}),
+		udpDropped: promauto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ssd_udp_packets_dropped_total",
+			Help: "Total number of UDP datagrams dropped unread",
+		}, []string{"reason"}),
 	}
 
 	promauto.NewGaugeFunc(prometheus.GaugeOpts{
@@ -118,15 +206,51 @@ func NewMetricsProvider(conf *structures.Config, service services.StatisticServi
 		return float64(len(service.GetChannels()))
 	})
 
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "ssd_memory_max_bytes",
+		Help: "Configured memory budget (0 = unlimited)",
+	}, func() float64 {
+		return float64(conf.Memory.MaxBytes)
+	})
+
+	promauto.NewCounterFunc(prometheus.CounterOpts{
+		Name: "ssd_experiment_assignments_rejected_total",
+		Help: "Total number of experiment assignments not counted because of the experiment or variant caps",
+	}, func() float64 {
+		return float64(service.GetExperimentRejects())
+	})
+
+	for _, kind := range []string{services.EvictItems, services.EvictFingerprints} {
+		promauto.NewCounterFunc(prometheus.CounterOpts{
+			Name:        "ssd_evictions_total",
+			Help:        "Total number of records evicted to stay within the memory budget",
+			ConstLabels: prometheus.Labels{"kind": kind},
+		}, func() float64 {
+			mem := service.GetMemoryStats()
+			if kind == services.EvictItems {
+				return float64(mem.EvictedItems)
+			}
+			return float64(mem.EvictedFingerprints)
+		})
+	}
+
 	return m
 }
 
 // noopMetrics is a no-op implementation for when metrics are disabled.
 type noopMetrics struct{}
 
-func (n *noopMetrics) IncRequestsTotal(_ string, _ int)                 {}
-func (n *noopMetrics) ObserveRequestDuration(_ string, _ time.Duration) {}
-func (n *noopMetrics) IncCacheHits()                                    {}
-func (n *noopMetrics) IncCacheMisses()                                  {}
-func (n *noopMetrics) ObservePersistenceDuration(_ time.Duration)       {}
-func (n *noopMetrics) SetRecordsTotal(_ string, _ int)                  {}
+func (n *noopMetrics) IncRequestsTotal(_ string, _ int)                     {}
+func (n *noopMetrics) ObserveRequestDuration(_ string, _ time.Duration)     {}
+func (n *noopMetrics) IncCacheHits()                                        {}
+func (n *noopMetrics) IncCacheMisses()                                      {}
+func (n *noopMetrics) ObservePersistenceDuration(_ time.Duration)           {}
+func (n *noopMetrics) ObserveAggregationDuration(_ string, _ time.Duration) {}
+func (n *noopMetrics) ObserveAggregationBatchSize(_ string, _ int)          {}
+func (n *noopMetrics) SetRecordsTotal(_ string, _ int)                      {}
+func (n *noopMetrics) SetMemoryBytes(_ string, _ int64)                     {}
+func (n *noopMetrics) IncIngestFiltered(_ string)                           {}
+func (n *noopMetrics) SetStreamSubscribers(_ string, _ int)                 {}
+func (n *noopMetrics) IncStreamDropped()                                    {}
+func (n *noopMetrics) IncUDPParseErrors()                                   {}
+func (n *noopMetrics) IncUDPDropped(_ string)                               {}
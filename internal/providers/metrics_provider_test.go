@@ -2,6 +2,7 @@ package providers
 
 import (
 	"ssd/internal/models"
+	"ssd/internal/services"
 	"ssd/internal/structures"
 	"testing"
 	"time"
@@ -15,16 +16,48 @@ import (
 type metricsTestService struct{}
 
 func (m *metricsTestService) AddStats(_ *models.InputStats)                              {}
-func (m *metricsTestService) AggregateStats()                                            {}
+func (m *metricsTestService) AggregateStats() int                                        { return 0 }
 func (m *metricsTestService) GetStatistic(_ string) map[int]*models.StatRecord           { return nil }
+func (m *metricsTestService) GetStatisticJSON(_ string) []byte                           { return nil }
 func (m *metricsTestService) GetPersonalStatistic(_ string) map[string]*models.Statistic { return nil }
+func (m *metricsTestService) GetPersonalStatisticJSON(_ string) []byte                   { return nil }
 func (m *metricsTestService) GetByFingerprint(_, _ string) map[int]*models.StatRecord    { return nil }
-func (m *metricsTestService) PutChannelData(_ string, _ map[int]*models.StatRecord, _ map[string]*models.Statistic) {
+func (m *metricsTestService) GetFeed(_, _ string, _ int, _ models.CatalogFilter) []services.FeedItem {
+	return nil
 }
-func (m *metricsTestService) GetChannels() []string        { return []string{"default"} }
-func (m *metricsTestService) GetSnapshot() *models.Storage { return nil }
-func (m *metricsTestService) GetBufferSize() int           { return 5 }
-func (m *metricsTestService) GetRecordCount(_ string) int  { return 0 }
+func (m *metricsTestService) GetFilteredStatistic(_ string, _ models.CatalogFilter) map[int]*models.StatRecord {
+	return nil
+}
+func (m *metricsTestService) GetDimensionStatistic(_ string, _ models.DimKey, _ models.CatalogFilter) map[int]*models.StatRecord {
+	return nil
+}
+func (m *metricsTestService) GetFunnel(_, _ string, _ []int) *services.FunnelReport {
+	return nil
+}
+func (m *metricsTestService) GetRising(_ string, _ int) []services.RisingItem {
+	return nil
+}
+func (m *metricsTestService) GetLastBatch() map[string]services.BatchStats {
+	return nil
+}
+func (m *metricsTestService) GetExperimentRejects() int64 { return 0 }
+func (m *metricsTestService) GetExperiment(_, _ string, _ []int) *services.ExperimentReport {
+	return nil
+}
+func (m *metricsTestService) GetBreakdown(_ string, _ int, _ string) map[string]*models.StatRecord {
+	return nil
+}
+func (m *metricsTestService) GetCatalogItem(_ string, _ int) (*models.CatalogItem, bool) {
+	return nil, false
+}
+func (m *metricsTestService) UpdateCatalog(_ string, _ map[int]*models.CatalogItem, _ []int) {}
+func (m *metricsTestService) GetRelated(_ string, _, _ int) []models.RelatedItem             { return nil }
+func (m *metricsTestService) PutChannelData(_ string, _ *models.ChannelData)                 {}
+func (m *metricsTestService) GetChannels() []string                                          { return []string{"default"} }
+func (m *metricsTestService) GetSnapshot() *models.Storage                                   { return nil }
+func (m *metricsTestService) GetBufferSize() int                                             { return 5 }
+func (m *metricsTestService) GetRecordCount(_ string) int                                    { return 0 }
+func (m *metricsTestService) GetMemoryStats() services.MemoryStats                           { return services.MemoryStats{} }
 
 func TestNoopMetrics_WhenDisabled(t *testing.T) {
 	conf := &structures.Config{
@@ -40,7 +73,15 @@ func TestNoopMetrics_WhenDisabled(t *testing.T) {
 	m.IncCacheHits()
 	m.IncCacheMisses()
 	m.ObservePersistenceDuration(time.Millisecond)
+	m.ObserveAggregationDuration("interval", time.Millisecond)
+	m.ObserveAggregationBatchSize("buffer", 10)
 	m.SetRecordsTotal("default", 10)
+	m.SetMemoryBytes("default", 1024)
+	m.IncIngestFiltered("crawler")
+	m.SetStreamSubscribers("default", 3)
+	m.IncStreamDropped()
+	m.IncUDPParseErrors()
+	m.IncUDPDropped(UDPDropRateLimited)
 }
 
 func TestMetricsProvider_WhenEnabled(t *testing.T) {
@@ -81,7 +122,15 @@ func TestMetricsProvider_IncrementCounters(t *testing.T) {
 	m.IncCacheHits()
 	m.IncCacheMisses()
 	m.ObservePersistenceDuration(100 * time.Millisecond)
+	m.ObserveAggregationDuration("interval", 20*time.Millisecond)
+	m.ObserveAggregationBatchSize("buffer", 5000)
 	m.SetRecordsTotal("default", 42)
+	m.SetMemoryBytes("default", 4096)
+	m.IncIngestFiltered("crawler")
+	m.SetStreamSubscribers("default", 3)
+	m.IncStreamDropped()
+	m.IncUDPParseErrors()
+	m.IncUDPDropped(UDPDropRateLimited)
 }
 
 func TestHttpStatusBucket(t *testing.T) {
@@ -8,21 +8,32 @@ import (
 type RouterProviderInterface interface {
 	Get(url string, handler http.Handler)
 	Post(url string, handler http.Handler)
+	AdminPost(url string, handler http.Handler)
 	GetRoutes() []structures.Route
 }
 
 type RouterProvider struct {
 	routes []structures.Route
+	cors   CORSPolicyInterface
 }
 
 func (rp *RouterProvider) Get(url string, handler http.Handler) {
 	rp.routes = append(rp.routes, structures.Route{
 		Url:     url,
-		Handler: methodHandler(http.MethodGet, handler),
+		Handler: rp.cors.Wrap(http.MethodGet, methodHandler(http.MethodGet, handler)),
 	})
 }
 
 func (rp *RouterProvider) Post(url string, handler http.Handler) {
+	rp.routes = append(rp.routes, structures.Route{
+		Url:     url,
+		Handler: rp.cors.Wrap(http.MethodPost, methodHandler(http.MethodPost, handler)),
+	})
+}
+
+// AdminPost registers a POST route outside the CORS policy, so browsers on
+// other origins can neither preflight it nor read its response.
+func (rp *RouterProvider) AdminPost(url string, handler http.Handler) {
 	rp.routes = append(rp.routes, structures.Route{
 		Url:     url,
 		Handler: methodHandler(http.MethodPost, handler),
@@ -33,13 +44,14 @@ func (rp *RouterProvider) GetRoutes() []structures.Route {
 	return rp.routes
 }
 
-func NewRouterProvider() RouterProviderInterface {
-	return &RouterProvider{}
+func NewRouterProvider(cors CORSPolicyInterface) RouterProviderInterface {
+	return &RouterProvider{cors: cors}
 }
 
 func methodHandler(method string, handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != method {
+			w.Header().Set("Allow", method)
 			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
 			return
 		}
@@ -3,7 +3,9 @@ package providers
 import (
 	"net/http"
 	"net/http/httptest"
+	"ssd/internal/structures"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -17,7 +19,7 @@ func dummyHandler() http.Handler {
 }
 
 func TestRouterProvider_GetAddsRoute(t *testing.T) {
-	rp := NewRouterProvider()
+	rp := NewRouterProvider(NewCORSPolicy(&structures.Config{}))
 	rp.Get("/test", dummyHandler())
 
 	routes := rp.GetRoutes()
@@ -26,7 +28,7 @@ func TestRouterProvider_GetAddsRoute(t *testing.T) {
 }
 
 func TestRouterProvider_PostAddsRoute(t *testing.T) {
-	rp := NewRouterProvider()
+	rp := NewRouterProvider(NewCORSPolicy(&structures.Config{}))
 	rp.Post("/submit", dummyHandler())
 
 	routes := rp.GetRoutes()
@@ -35,7 +37,7 @@ func TestRouterProvider_PostAddsRoute(t *testing.T) {
 }
 
 func TestRouterProvider_MultipleRoutes(t *testing.T) {
-	rp := NewRouterProvider()
+	rp := NewRouterProvider(NewCORSPolicy(&structures.Config{}))
 	rp.Get("/a", dummyHandler())
 	rp.Post("/b", dummyHandler())
 	rp.Get("/c", dummyHandler())
@@ -66,7 +68,7 @@ func TestMethodHandler_WrongMethod(t *testing.T) {
 }
 
 func TestRouterProvider_GetRouteRejectsPost(t *testing.T) {
-	rp := NewRouterProvider()
+	rp := NewRouterProvider(NewCORSPolicy(&structures.Config{}))
 	rp.Get("/test", dummyHandler())
 
 	route := rp.GetRoutes()[0]
@@ -78,7 +80,7 @@ func TestRouterProvider_GetRouteRejectsPost(t *testing.T) {
 }
 
 func TestRouterProvider_PostRouteRejectsGet(t *testing.T) {
-	rp := NewRouterProvider()
+	rp := NewRouterProvider(NewCORSPolicy(&structures.Config{}))
 	rp.Post("/submit", dummyHandler())
 
 	route := rp.GetRoutes()[0]
@@ -88,3 +90,128 @@ func TestRouterProvider_PostRouteRejectsGet(t *testing.T) {
 
 	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
 }
+
+func corsRoute(t *testing.T, conf structures.CORSConfig) http.Handler {
+	t.Helper()
+	conf.Enabled = true
+	rp := NewRouterProvider(NewCORSPolicy(&structures.Config{CORS: conf}))
+	rp.Post("/", dummyHandler())
+	return rp.GetRoutes()[0].Handler
+}
+
+func preflight(handler http.Handler, target, origin, method string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, target, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	handler := corsRoute(t, structures.CORSConfig{
+		AllowedOrigins: []string{"https://www.example.com"},
+		AllowedHeaders: []string{"Content-Type", "X-Request-Id"},
+		MaxAge:         10 * time.Minute,
+	})
+
+	rr := preflight(handler, "/", "https://www.example.com", http.MethodPost)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://www.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.MethodPost, rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Content-Type, X-Request-Id", rr.Header().Get("Access-Control-Allow-Headers"))
+	assert.Equal(t, "600", rr.Header().Get("Access-Control-Max-Age"))
+	assert.Contains(t, rr.Header().Values("Vary"), "Origin")
+
+	rr = preflight(handler, "/", "https://evil.example", http.MethodPost)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+
+	rr = preflight(handler, "/", "https://www.example.com", http.MethodDelete)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, http.MethodPost, rr.Header().Get("Allow"))
+}
+
+func TestCORS_ActualRequest(t *testing.T) {
+	handler := corsRoute(t, structures.CORSConfig{AllowedOrigins: []string{"https://www.example.com"}})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Origin", "https://www.example.com")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "https://www.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Headers"), "only preflights list headers")
+
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code, "the browser, not the server, withholds the response")
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+
+	// An OPTIONS request that is not a preflight is still a wrong method.
+	req = httptest.NewRequest(http.MethodOptions, "/", nil)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestCORS_PerChannelOrigins(t *testing.T) {
+	handler := corsRoute(t, structures.CORSConfig{
+		AllowedOrigins: []string{"*"},
+		Channels:       map[string][]string{"shop": {"https://shop.example.com"}},
+	})
+
+	assert.Equal(t, http.StatusNoContent, preflight(handler, "/?ch=news", "https://any.example", http.MethodPost).Code)
+	assert.Equal(t, http.StatusNoContent, preflight(handler, "/", "https://any.example", http.MethodPost).Code)
+	assert.Equal(t, http.StatusForbidden, preflight(handler, "/?ch=shop", "https://any.example", http.MethodPost).Code)
+
+	rr := preflight(handler, "/?ch=shop", "https://shop.example.com", http.MethodPost)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://shop.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, defaultCORSHeaders, rr.Header().Get("Access-Control-Allow-Headers"))
+	assert.Empty(t, rr.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORSPolicy_Allowed(t *testing.T) {
+	p := NewCORSPolicy(&structures.Config{CORS: structures.CORSConfig{
+		Enabled:        true,
+		AllowedOrigins: []string{"https://www.example.com"},
+		Channels:       map[string][]string{"shop": {"https://shop.example.com"}},
+	}})
+
+	assert.True(t, p.Allowed("https://WWW.example.com", ""), "an empty channel is the default one")
+	assert.True(t, p.Allowed("https://www.example.com", "news"))
+	assert.False(t, p.Allowed("https://www.example.com", "shop"))
+	assert.True(t, p.Allowed("https://shop.example.com", "shop"))
+	assert.False(t, p.Allowed("https://evil.example", "news"))
+
+	assert.True(t, NewCORSPolicy(&structures.Config{}).Allowed("https://evil.example", "shop"), "without cors.enabled no origin is restricted")
+}
+
+func TestCORS_DisabledKeepsPreflightRejected(t *testing.T) {
+	rp := NewRouterProvider(NewCORSPolicy(&structures.Config{}))
+	rp.Post("/", dummyHandler())
+
+	rr := preflight(rp.GetRoutes()[0].Handler, "/", "https://www.example.com", http.MethodPost)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestRouterProvider_AdminPostSkipsCORS(t *testing.T) {
+	rp := NewRouterProvider(NewCORSPolicy(&structures.Config{CORS: structures.CORSConfig{Enabled: true, AllowedOrigins: []string{"*"}}}))
+	rp.AdminPost("/rules/update", dummyHandler())
+	handler := rp.GetRoutes()[0].Handler
+
+	rr := preflight(handler, "/rules/update", "https://www.example.com", http.MethodPost)
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+
+	req := httptest.NewRequest(http.MethodPost, "/rules/update", nil)
+	req.Header.Set("Origin", "https://www.example.com")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"))
+}
@@ -0,0 +1,327 @@
+package providers
+
+import (
+	"bytes"
+	"errors"
+	json "github.com/goccy/go-json"
+	"slices"
+	"ssd/internal/models"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"sync"
+	"time"
+)
+
+const (
+	StreamTop     = "top"
+	StreamChanges = "changes"
+
+	defaultStreamSubscribers = 1000
+	defaultStreamQueueSize   = 16
+	defaultStreamHeartbeat   = 15 * time.Second
+)
+
+var (
+	ErrStreamDisabled = errors.New("stream disabled")
+	ErrStreamFull     = errors.New("too many stream subscribers")
+	ErrStreamChannel  = errors.New("unknown stream channel")
+)
+
+// StreamFilter selects what a subscriber receives: the N most viewed
+// records, or in changes mode the N most viewed records that changed in the
+// last aggregation. Items and Catalog narrow the records further.
+type StreamFilter struct {
+	Mode    string
+	N       int
+	Items   []int
+	Catalog models.CatalogFilter
+}
+
+// StreamEvent is an event for a subscriber; Data is the JSON array of
+// records.
+type StreamEvent struct {
+	Event string
+	Data  []byte
+}
+
+// Subscription receives the events of a subscriber. C is closed when the
+// subscriber was dropped for falling behind or the stream was closed.
+type Subscription struct {
+	C       <-chan StreamEvent
+	c       chan StreamEvent
+	channel string
+	filter  StreamFilter
+	items   map[int]struct{}
+	last    []byte // last payload, so unchanged top lists are not resent
+}
+
+// StreamProviderInterface fans aggregation results out to /stream and /ws
+// subscribers.
+type StreamProviderInterface interface {
+	Subscribe(channel string, filter StreamFilter) (*Subscription, error)
+	Unsubscribe(sub *Subscription)
+	Publish()
+	Heartbeat() time.Duration
+	Close()
+}
+
+type StreamProvider struct {
+	mu        sync.Mutex
+	conf      structures.StreamConfig
+	service   services.StatisticServiceInterface
+	metrics   MetricsProviderInterface
+	logger    Logger
+	subs      map[string]map[*Subscription]struct{} // channel -> subscribers
+	count     int
+	prev      map[string]map[int]*models.StatRecord // channel -> trend at the last publish
+	closed    bool
+	publishMu sync.Mutex // serializes Publish, which reads and replaces prev, with closing subscriber channels
+}
+
+type noopStream struct{}
+
+func (noopStream) Subscribe(string, StreamFilter) (*Subscription, error) {
+	return nil, ErrStreamDisabled
+}
+func (noopStream) Unsubscribe(*Subscription) {}
+func (noopStream) Publish()                  {}
+func (noopStream) Heartbeat() time.Duration  { return defaultStreamHeartbeat }
+func (noopStream) Close()                    {}
+
+func NewStreamProvider(conf *structures.Config, service services.StatisticServiceInterface, metrics MetricsProviderInterface, logger Logger) StreamProviderInterface {
+	if !conf.Stream.Enabled {
+		return noopStream{}
+	}
+	return newStreamProvider(conf.Stream, service, metrics, logger)
+}
+
+func newStreamProvider(conf structures.StreamConfig, service services.StatisticServiceInterface, metrics MetricsProviderInterface, logger Logger) *StreamProvider {
+	if conf.MaxSubscribers <= 0 {
+		conf.MaxSubscribers = defaultStreamSubscribers
+	}
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = defaultStreamQueueSize
+	}
+	if conf.Heartbeat <= 0 {
+		conf.Heartbeat = defaultStreamHeartbeat
+	}
+	return &StreamProvider{
+		conf:    conf,
+		service: service,
+		metrics: metrics,
+		logger:  logger,
+		subs:    make(map[string]map[*Subscription]struct{}),
+		prev:    make(map[string]map[int]*models.StatRecord),
+	}
+}
+
+// Subscribe registers a subscriber and queues the current top records of
+// its channel as the first event. Only channels that have received events,
+// and the default channel, can be subscribed, so clients cannot create
+// per-channel state and metric series at will.
+func (p *StreamProvider) Subscribe(channel string, filter StreamFilter) (*Subscription, error) {
+	if channel != services.DefaultChannel && !slices.Contains(p.service.GetChannels(), channel) {
+		return nil, ErrStreamChannel
+	}
+	c := make(chan StreamEvent, p.conf.QueueSize)
+	sub := &Subscription{C: c, c: c, channel: channel, filter: filter}
+	if len(filter.Items) > 0 {
+		sub.items = make(map[int]struct{}, len(filter.Items))
+		for _, id := range filter.Items {
+			sub.items[id] = struct{}{}
+		}
+	}
+
+	// Holding publishMu keeps a concurrent Publish from sending the first
+	// changes against a trend older than the initial event.
+	p.publishMu.Lock()
+	defer p.publishMu.Unlock()
+	trend := p.service.GetStatistic(channel)
+	if ev, ok := sub.render(StreamTop, p.records(sub), nil); ok {
+		c <- ev
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrStreamDisabled
+	}
+	if p.count >= p.conf.MaxSubscribers {
+		return nil, ErrStreamFull
+	}
+	if p.subs[channel] == nil {
+		p.subs[channel] = make(map[*Subscription]struct{})
+	}
+	p.subs[channel][sub] = struct{}{}
+	p.count++
+	p.metrics.SetStreamSubscribers(channel, len(p.subs[channel]))
+	if _, ok := p.prev[channel]; !ok {
+		p.prev[channel] = trend
+	}
+	return sub, nil
+}
+
+// Unsubscribe removes a subscriber that went away on its own.
+func (p *StreamProvider) Unsubscribe(sub *Subscription) {
+	p.publishMu.Lock()
+	defer p.publishMu.Unlock()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.remove(sub)
+}
+
+// remove deletes sub and closes its channel. The caller holds publishMu,
+// so no Publish can still send on the channel, and mu.
+func (p *StreamProvider) remove(sub *Subscription) bool {
+	subs := p.subs[sub.channel]
+	if _, ok := subs[sub]; !ok {
+		return false
+	}
+	delete(subs, sub)
+	close(sub.c)
+	p.count--
+	p.metrics.SetStreamSubscribers(sub.channel, len(subs))
+	if len(subs) == 0 {
+		delete(p.subs, sub.channel)
+	}
+	return true
+}
+
+// Publish sends every subscriber the records of its channel after an
+// aggregation. Subscribers whose queue is full are dropped.
+func (p *StreamProvider) Publish() {
+	p.publishMu.Lock()
+	defer p.publishMu.Unlock()
+
+	p.mu.Lock()
+	targets := make(map[string][]*Subscription, len(p.subs))
+	for channel, subs := range p.subs {
+		for sub := range subs {
+			targets[channel] = append(targets[channel], sub)
+		}
+	}
+	p.mu.Unlock()
+
+	prev := p.prev
+	p.prev = make(map[string]map[int]*models.StatRecord, len(targets))
+	var dropped []*Subscription
+	for channel, subs := range targets {
+		trend := p.service.GetStatistic(channel)
+		p.prev[channel] = trend
+		var changed map[int]struct{}
+		for _, sub := range subs {
+			mode := sub.filter.Mode
+			if mode == StreamChanges && changed == nil {
+				changed = changedRecords(prev[channel], trend)
+			}
+			ev, ok := sub.render(mode, p.records(sub), changed)
+			if !ok {
+				continue
+			}
+			select {
+			case sub.c <- ev:
+			default:
+				dropped = append(dropped, sub)
+			}
+		}
+	}
+	if len(dropped) == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, sub := range dropped {
+		if p.remove(sub) {
+			p.metrics.IncStreamDropped()
+			p.logger.Warnf(TypeApp, "Stream subscriber of channel %s dropped: queue full", sub.channel)
+		}
+	}
+}
+
+// records returns the trend of the subscriber's channel, narrowed by its
+// catalog filter.
+func (p *StreamProvider) records(sub *Subscription) map[int]*models.StatRecord {
+	if sub.filter.Catalog.IsEmpty() {
+		return p.service.GetStatistic(sub.channel)
+	}
+	return p.service.GetFilteredStatistic(sub.channel, sub.filter.Catalog)
+}
+
+// render builds an event, or reports false when there is nothing new to
+// send.
+func (sub *Subscription) render(event string, records map[int]*models.StatRecord, changed map[int]struct{}) (StreamEvent, bool) {
+	if sub.items != nil || event == StreamChanges {
+		selected := make(map[int]*models.StatRecord)
+		for id, r := range records {
+			if _, ok := sub.items[id]; sub.items != nil && !ok {
+				continue
+			}
+			if _, ok := changed[id]; event == StreamChanges && !ok {
+				continue
+			}
+			selected[id] = r
+		}
+		records = selected
+	}
+	top := services.TopRecords(records, "views", sub.filter.N)
+	if event == StreamChanges && len(top) == 0 {
+		return StreamEvent{}, false
+	}
+	if top == nil {
+		top = []services.RankedRecord{}
+	}
+	data, err := json.Marshal(top)
+	if err != nil {
+		return StreamEvent{}, false
+	}
+	if event == StreamTop {
+		if bytes.Equal(data, sub.last) {
+			return StreamEvent{}, false
+		}
+		sub.last = data
+	}
+	return StreamEvent{Event: event, Data: data}, true
+}
+
+// changedRecords returns the IDs of records that are new or differ from the
+// previous trend. Published records are rebuilt with every view, so they
+// are compared by value.
+func changedRecords(prev, cur map[int]*models.StatRecord) map[int]struct{} {
+	changed := make(map[int]struct{})
+	for id, r := range cur {
+		old, ok := prev[id]
+		if !ok || !sameRecord(old, r) {
+			changed[id] = struct{}{}
+		}
+	}
+	return changed
+}
+
+func sameRecord(a, b *models.StatRecord) bool {
+	if a.Views != b.Views || a.Clicks != b.Clicks || a.Ftr != b.Ftr || a.Exposure != b.Exposure {
+		return false
+	}
+	if a.Value == nil || b.Value == nil {
+		return a.Value == b.Value
+	}
+	return *a.Value == *b.Value
+}
+
+func (p *StreamProvider) Heartbeat() time.Duration {
+	return p.conf.Heartbeat
+}
+
+// Close ends all subscriptions and refuses new ones, so open streams do not
+// hold up the server shutdown.
+func (p *StreamProvider) Close() {
+	p.publishMu.Lock()
+	defer p.publishMu.Unlock()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, subs := range p.subs {
+		for sub := range subs {
+			p.remove(sub)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package providers
+
+import (
+	json "github.com/goccy/go-json"
+	"ssd/internal/models"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStream(conf structures.StreamConfig) (*StreamProvider, services.StatisticServiceInterface, *mockMetrics) {
+	svc := services.NewStatisticService(&structures.Config{})
+	metrics := &mockMetrics{}
+	return newStreamProvider(conf, svc, metrics, &cacheTestLogger{}), svc, metrics
+}
+
+func addViews(svc services.StatisticServiceInterface, channel string, ids ...string) {
+	svc.AddStats(&models.InputStats{Views: ids, Channel: channel})
+	svc.AggregateStats()
+}
+
+// nextEvent reads a queued event without blocking and decodes its records.
+func nextEvent(t *testing.T, sub *Subscription) (string, []services.RankedRecord) {
+	t.Helper()
+	select {
+	case ev, ok := <-sub.C:
+		require.True(t, ok, "subscription closed")
+		var records []services.RankedRecord
+		require.NoError(t, json.Unmarshal(ev.Data, &records))
+		return ev.Event, records
+	default:
+		t.Fatal("no event queued")
+		return "", nil
+	}
+}
+
+func ids(records []services.RankedRecord) []int {
+	out := make([]int, len(records))
+	for i, r := range records {
+		out[i] = r.ID
+	}
+	return out
+}
+
+func TestStreamProvider_TopEvents(t *testing.T) {
+	p, svc, metrics := newTestStream(structures.StreamConfig{})
+	addViews(svc, "news", "1", "2", "2")
+
+	sub, err := p.Subscribe("news", StreamFilter{Mode: StreamTop, N: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, metrics.streamSubs["news"])
+
+	event, records := nextEvent(t, sub)
+	assert.Equal(t, StreamTop, event)
+	assert.Equal(t, []int{2}, ids(records))
+
+	addViews(svc, "news", "3")
+	p.Publish()
+	assert.Empty(t, sub.C, "top list unchanged")
+
+	addViews(svc, "news", "1", "1")
+	p.Publish()
+	_, records = nextEvent(t, sub)
+	assert.Equal(t, []int{1}, ids(records))
+	assert.Equal(t, 3, records[0].Views)
+
+	p.Unsubscribe(sub)
+	_, open := <-sub.C
+	assert.False(t, open)
+	assert.Equal(t, 0, metrics.streamSubs["news"])
+}
+
+func TestStreamProvider_ChangesAndItems(t *testing.T) {
+	p, svc, _ := newTestStream(structures.StreamConfig{})
+	addViews(svc, "news", "1", "2", "3")
+
+	changes, err := p.Subscribe("news", StreamFilter{Mode: StreamChanges, N: 10})
+	require.NoError(t, err)
+	items, err := p.Subscribe("news", StreamFilter{Mode: StreamTop, N: 10, Items: []int{3}})
+	require.NoError(t, err)
+	event, records := nextEvent(t, changes)
+	assert.Equal(t, StreamTop, event, "the first event is the current top list")
+	assert.Equal(t, []int{1, 2, 3}, ids(records))
+	_, records = nextEvent(t, items)
+	assert.Equal(t, []int{3}, ids(records))
+
+	addViews(svc, "news", "2", "4")
+	p.Publish()
+	event, records = nextEvent(t, changes)
+	assert.Equal(t, StreamChanges, event)
+	assert.Equal(t, []int{2, 4}, ids(records))
+	assert.Empty(t, items.C, "item 3 did not change")
+
+	addViews(svc, "blog", "1")
+	p.Publish()
+	assert.Empty(t, changes.C, "nothing changed in the channel")
+}
+
+func TestStreamProvider_DropsSlowSubscribers(t *testing.T) {
+	p, svc, metrics := newTestStream(structures.StreamConfig{QueueSize: 2})
+	sub, err := p.Subscribe(services.DefaultChannel, StreamFilter{Mode: StreamChanges, N: 10})
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		addViews(svc, services.DefaultChannel, "1")
+		p.Publish()
+	}
+
+	assert.Equal(t, 1, metrics.streamDropped)
+	assert.Equal(t, 0, metrics.streamSubs[services.DefaultChannel])
+	var frames int
+	for range sub.C {
+		frames++
+	}
+	assert.Equal(t, 2, frames, "queued frames are still delivered before the close")
+}
+
+func TestStreamProvider_MaxSubscribersAndClose(t *testing.T) {
+	p, svc, _ := newTestStream(structures.StreamConfig{MaxSubscribers: 1})
+	addViews(svc, "blog")
+	sub, err := p.Subscribe(services.DefaultChannel, StreamFilter{Mode: StreamTop, N: 10})
+	require.NoError(t, err)
+
+	_, err = p.Subscribe("blog", StreamFilter{Mode: StreamTop, N: 10})
+	assert.ErrorIs(t, err, ErrStreamFull)
+
+	p.Close()
+	<-sub.C // the empty top list
+	_, open := <-sub.C
+	assert.False(t, open)
+	_, err = p.Subscribe(services.DefaultChannel, StreamFilter{Mode: StreamTop, N: 10})
+	assert.ErrorIs(t, err, ErrStreamDisabled)
+	p.Unsubscribe(sub)
+}
+
+func TestStreamProvider_RejectsUnknownChannels(t *testing.T) {
+	p, svc, metrics := newTestStream(structures.StreamConfig{MaxSubscribers: 1})
+	addViews(svc, "news", "1")
+
+	_, err := p.Subscribe("random-1", StreamFilter{Mode: StreamTop, N: 10})
+	assert.ErrorIs(t, err, ErrStreamChannel)
+	assert.NotContains(t, metrics.streamSubs, "random-1")
+
+	_, err = p.Subscribe("news", StreamFilter{Mode: StreamTop, N: 10})
+	require.NoError(t, err)
+	_, err = p.Subscribe(services.DefaultChannel, StreamFilter{Mode: StreamTop, N: 10})
+	assert.ErrorIs(t, err, ErrStreamFull)
+	assert.NotContains(t, metrics.streamSubs, services.DefaultChannel)
+	assert.NotContains(t, p.prev, services.DefaultChannel, "refused subscriptions leave no state")
+	assert.Contains(t, p.prev, "news")
+}
+
+func TestStreamProvider_CloseDuringPublish(t *testing.T) {
+	p, svc, _ := newTestStream(structures.StreamConfig{QueueSize: 64})
+	addViews(svc, "news", "1")
+
+	subs := make([]*Subscription, 200)
+	for i := range subs {
+		sub, err := p.Subscribe("news", StreamFilter{Mode: StreamTop, N: 10})
+		require.NoError(t, err)
+		subs[i] = sub
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 30; i++ {
+			addViews(svc, "news", strconv.Itoa(i))
+			p.Publish()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for _, sub := range subs[:150] {
+			p.Unsubscribe(sub)
+		}
+		p.Close()
+	}()
+	wg.Wait()
+}
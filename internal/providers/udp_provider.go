@@ -0,0 +1,262 @@
+package providers
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"net/netip"
+	"ssd/internal/models"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultUDPPort          = 8125
+	defaultUDPMaxPacketSize = 8192
+	udpBucketIdle           = time.Minute
+	// udpMaxBuckets bounds the per-source buckets, which spoofed source
+	// addresses could otherwise grow without limit. Sources beyond it share
+	// one bucket until idle buckets are swept.
+	udpMaxBuckets     = 10000
+	udpReadBackoffMin = 5 * time.Millisecond
+	udpReadBackoffMax = time.Second
+
+	UDPDropRateLimited = "rate_limited"
+	UDPDropOversized   = "oversized"
+)
+
+var errUDPLine = errors.New("malformed line")
+
+// UDPListenerInterface feeds events sent as StatsD-style datagrams into the
+// ingest service.
+type UDPListenerInterface interface {
+	Listen() error
+	Close()
+}
+
+type UDPListener struct {
+	conf    structures.UDPConfig
+	addr    string
+	ingest  services.IngestServiceInterface
+	metrics MetricsProviderInterface
+	logger  Logger
+	conn    net.PacketConn
+	buckets map[netip.Addr]*udpBucket // only touched by the read loop
+	shared  *udpBucket                // sources beyond maxBuckets
+	swept   time.Time
+	now     func() time.Time
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// udpBucket is the token bucket of a source address, refilled by rateLimit
+// tokens per second up to rateLimit.
+type udpBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type noopUDPListener struct{}
+
+func (noopUDPListener) Listen() error { return nil }
+func (noopUDPListener) Close()        {}
+
+func NewUDPListener(conf *structures.Config, ingest services.IngestServiceInterface, metrics MetricsProviderInterface, logger Logger) UDPListenerInterface {
+	if !conf.UDP.Enabled {
+		return noopUDPListener{}
+	}
+	port := conf.UDP.Port
+	if port == 0 {
+		port = defaultUDPPort
+	}
+	return newUDPListener(conf.UDP, net.JoinHostPort(conf.WebServer.Host, strconv.Itoa(port)), ingest, metrics, logger)
+}
+
+func newUDPListener(conf structures.UDPConfig, addr string, ingest services.IngestServiceInterface, metrics MetricsProviderInterface, logger Logger) *UDPListener {
+	if conf.MaxPacketSize <= 0 {
+		conf.MaxPacketSize = defaultUDPMaxPacketSize
+	}
+	return &UDPListener{
+		conf:    conf,
+		addr:    addr,
+		ingest:  ingest,
+		metrics: metrics,
+		logger:  logger,
+		buckets: make(map[netip.Addr]*udpBucket),
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+}
+
+// Listen binds the socket and reads datagrams in the background until
+// Close.
+func (l *UDPListener) Listen() error {
+	conn, err := net.ListenPacket("udp", l.addr)
+	if err != nil {
+		return err
+	}
+	l.conn = conn
+	l.logger.Infof(TypeApp, "Listening UDP events on %s", conn.LocalAddr())
+	l.wg.Add(1)
+	go l.run()
+	return nil
+}
+
+func (l *UDPListener) run() {
+	defer l.wg.Done()
+	// One spare byte tells datagrams over the limit from ones that fit.
+	buf := make([]byte, l.conf.MaxPacketSize+1)
+	// Read errors that persist back off exponentially, so they neither spin
+	// nor flood the log.
+	var backoff time.Duration
+	for {
+		n, addr, err := l.conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			backoff = min(max(2*backoff, udpReadBackoffMin), udpReadBackoffMax)
+			l.logger.Warnf(TypeApp, "UDP read failed, retrying in %s: %s", backoff, err)
+			select {
+			case <-time.After(backoff):
+			case <-l.stop:
+				return
+			}
+			continue
+		}
+		backoff = 0
+		var source netip.Addr
+		if udpAddr, ok := addr.(*net.UDPAddr); ok {
+			source = udpAddr.AddrPort().Addr().Unmap()
+		}
+		l.handle(source, buf[:n])
+	}
+}
+
+// handle records the events of one datagram, one per line. Datagrams over
+// maxPacketSize or the rate limit of their source are dropped whole.
+func (l *UDPListener) handle(source netip.Addr, packet []byte) {
+	if len(packet) > l.conf.MaxPacketSize {
+		l.metrics.IncUDPDropped(UDPDropOversized)
+		return
+	}
+	if !l.allow(source) {
+		l.metrics.IncUDPDropped(UDPDropRateLimited)
+		return
+	}
+	src := services.Source{NoUserAgent: true}
+	if source.IsValid() {
+		src.RemoteAddr = source.String()
+	}
+	for len(packet) > 0 {
+		var line []byte
+		line, packet, _ = bytes.Cut(packet, []byte{'\n'})
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		payload, err := parseUDPLine(string(line))
+		if err != nil {
+			l.metrics.IncUDPParseErrors()
+			continue
+		}
+		l.ingest.Ingest(src, payload)
+	}
+}
+
+// allow takes a token from the source's bucket. Buckets idle for a minute
+// are full again and are swept; new sources beyond udpMaxBuckets take from
+// the shared bucket instead.
+func (l *UDPListener) allow(source netip.Addr) bool {
+	if l.conf.RateLimit <= 0 {
+		return true
+	}
+	now := l.now()
+	if now.Sub(l.swept) > udpBucketIdle {
+		for addr, b := range l.buckets {
+			if now.Sub(b.last) > udpBucketIdle {
+				delete(l.buckets, addr)
+			}
+		}
+		l.swept = now
+	}
+	limit := float64(l.conf.RateLimit)
+	b, ok := l.buckets[source]
+	switch {
+	case ok:
+	case len(l.buckets) < udpMaxBuckets:
+		b = &udpBucket{tokens: limit, last: now}
+		l.buckets[source] = b
+	default:
+		if l.shared == nil {
+			l.shared = &udpBucket{tokens: limit, last: now}
+		}
+		b = l.shared
+	}
+	b.tokens = min(limit, b.tokens+now.Sub(b.last).Seconds()*limit)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// parseUDPLine parses "<channel>:<id>[,<id>...]|<type>[|#f:<fingerprint>]".
+// The type is v for views, c for clicks or the name of a custom event; an
+// empty channel is the default channel. Unknown tags are ignored.
+func parseUDPLine(line string) (*models.InputStats, error) {
+	head, rest, ok := strings.Cut(line, "|")
+	if !ok {
+		return nil, errUDPLine
+	}
+	channel, ids, ok := strings.Cut(head, ":")
+	if !ok || ids == "" {
+		return nil, errUDPLine
+	}
+	kind, tags, _ := strings.Cut(rest, "|")
+	if kind == "" {
+		return nil, errUDPLine
+	}
+	if channel == "" {
+		channel = services.DefaultChannel
+	}
+	payload := &models.InputStats{Channel: channel}
+	if tags != "" {
+		if !strings.HasPrefix(tags, "#") {
+			return nil, errUDPLine
+		}
+		for _, tag := range strings.Split(tags[1:], ",") {
+			if fp, ok := strings.CutPrefix(tag, "f:"); ok {
+				payload.Fingerprint = fp
+			}
+		}
+	}
+	list := strings.Split(ids, ",")
+	switch kind {
+	case "v":
+		payload.Views = list
+	case "c":
+		payload.Clicks = list
+	default:
+		payload.Events = map[string][]string{kind: list}
+	}
+	return payload, nil
+}
+
+// Close stops reading; datagrams not yet read are lost.
+func (l *UDPListener) Close() {
+	l.once.Do(func() {
+		close(l.stop)
+		if l.conn == nil {
+			return
+		}
+		_ = l.conn.Close()
+		l.wg.Wait()
+	})
+}
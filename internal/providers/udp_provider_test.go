@@ -0,0 +1,183 @@
+package providers
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+	"ssd/internal/models"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type udpTestIngest struct {
+	added   []*models.InputStats
+	sources []services.Source
+}
+
+func (s *udpTestIngest) Ingest(src services.Source, data *models.InputStats) bool {
+	s.added = append(s.added, data)
+	s.sources = append(s.sources, src)
+	return true
+}
+
+func TestParseUDPLine(t *testing.T) {
+	got, err := parseUDPLine("news:1,2|v|#f:fp1")
+	require.NoError(t, err)
+	assert.Equal(t, &models.InputStats{Channel: "news", Views: []string{"1", "2"}, Fingerprint: "fp1"}, got)
+
+	got, err = parseUDPLine(":7|c")
+	require.NoError(t, err)
+	assert.Equal(t, &models.InputStats{Channel: services.DefaultChannel, Clicks: []string{"7"}}, got)
+
+	got, err = parseUDPLine("shop:7|subscribe|#env:prod,f:fp2")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"subscribe": {"7"}}, got.Events)
+	assert.Equal(t, "fp2", got.Fingerprint)
+
+	for _, line := range []string{"news", "news:1", "news|v", "news:|v", "news:1|", "news:1|v|f:fp"} {
+		_, err := parseUDPLine(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestUDPListener_HandleLines(t *testing.T) {
+	svc := &udpTestIngest{}
+	metrics := &mockMetrics{}
+	l := newUDPListener(structures.UDPConfig{}, "", svc, metrics, &cacheTestLogger{})
+
+	l.handle(netip.MustParseAddr("10.0.0.1"), []byte("news:1|v\n\nnews:1|c|#f:a\r\nbroken\nblog:2|v\n"))
+
+	require.Len(t, svc.added, 3)
+	assert.Equal(t, []string{"1"}, svc.added[1].Clicks)
+	assert.Equal(t, "blog", svc.added[2].Channel)
+	assert.Equal(t, services.Source{RemoteAddr: "10.0.0.1", NoUserAgent: true}, svc.sources[0])
+	assert.Equal(t, 1, metrics.udpParseErrors)
+}
+
+func TestUDPListener_DropsOversized(t *testing.T) {
+	svc := &udpTestIngest{}
+	metrics := &mockMetrics{}
+	l := newUDPListener(structures.UDPConfig{MaxPacketSize: 8}, "", svc, metrics, &cacheTestLogger{})
+
+	l.handle(netip.Addr{}, []byte("news:12|v"))
+
+	assert.Empty(t, svc.added)
+	assert.Equal(t, 1, metrics.udpDropped[UDPDropOversized])
+}
+
+func TestUDPListener_RateLimitPerSource(t *testing.T) {
+	svc := &udpTestIngest{}
+	metrics := &mockMetrics{}
+	l := newUDPListener(structures.UDPConfig{RateLimit: 2}, "", svc, metrics, &cacheTestLogger{})
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	a, b := netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("10.0.0.2")
+
+	for i := 0; i < 3; i++ {
+		l.handle(a, []byte("news:1|v"))
+	}
+	l.handle(b, []byte("news:1|v"))
+	assert.Len(t, svc.added, 3)
+	assert.Equal(t, 1, metrics.udpDropped[UDPDropRateLimited])
+
+	now = now.Add(500 * time.Millisecond)
+	l.handle(a, []byte("news:1|v"))
+	l.handle(a, []byte("news:1|v"))
+	assert.Len(t, svc.added, 4, "half a second refills one token")
+
+	now = now.Add(2 * udpBucketIdle)
+	l.handle(b, []byte("news:1|v"))
+	assert.Len(t, l.buckets, 1, "idle buckets are swept")
+}
+
+func TestUDPListener_BucketsAreCapped(t *testing.T) {
+	svc := &udpTestIngest{}
+	metrics := &mockMetrics{}
+	l := newUDPListener(structures.UDPConfig{RateLimit: 1}, "", svc, metrics, &cacheTestLogger{})
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+	l.swept = now
+	for i := 0; i < udpMaxBuckets; i++ {
+		l.buckets[netip.AddrFrom4([4]byte{10, byte(i >> 16), byte(i >> 8), byte(i)})] = &udpBucket{tokens: 1, last: now}
+	}
+
+	// Two new sources share one bucket, so the second is limited.
+	l.handle(netip.MustParseAddr("192.0.2.1"), []byte("news:1|v"))
+	l.handle(netip.MustParseAddr("192.0.2.2"), []byte("news:1|v"))
+	assert.Len(t, svc.added, 1)
+	assert.Equal(t, 1, metrics.udpDropped[UDPDropRateLimited])
+	assert.Len(t, l.buckets, udpMaxBuckets)
+
+	// Once idle buckets are swept, new sources get their own again.
+	now = now.Add(2 * udpBucketIdle)
+	l.handle(netip.MustParseAddr("192.0.2.2"), []byte("news:1|v"))
+	assert.Len(t, svc.added, 2)
+	assert.Len(t, l.buckets, 1)
+}
+
+// udpErrorConn fails every read until it is closed.
+type udpErrorConn struct {
+	net.PacketConn
+	reads  atomic.Int32
+	closed atomic.Bool
+}
+
+func (c *udpErrorConn) ReadFrom([]byte) (int, net.Addr, error) {
+	if c.closed.Load() {
+		return 0, nil, net.ErrClosed
+	}
+	c.reads.Add(1)
+	return 0, nil, errors.New("connection refused")
+}
+
+func (c *udpErrorConn) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+func TestUDPListener_ReadErrorsBackOff(t *testing.T) {
+	conn := &udpErrorConn{}
+	l := newUDPListener(structures.UDPConfig{}, "", &udpTestIngest{}, &mockMetrics{}, &cacheTestLogger{})
+	l.conn = conn
+	l.wg.Add(1)
+	go l.run()
+
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	l.Close()
+
+	// 5, 10, 20, 40 and 80 ms: a handful of reads instead of a busy loop.
+	assert.LessOrEqual(t, conn.reads.Load(), int32(6))
+	assert.Less(t, time.Since(start), udpReadBackoffMax/2, "Close does not wait out the backoff")
+}
+
+func TestUDPListener_ListenAndClose(t *testing.T) {
+	svc := services.NewStatisticService(&structures.Config{})
+	l := newUDPListener(structures.UDPConfig{}, "127.0.0.1:0", services.NewIngestService(svc, nil), &mockMetrics{}, &cacheTestLogger{})
+	require.NoError(t, l.Listen())
+	defer l.Close()
+
+	conn, err := net.Dial("udp", l.conn.LocalAddr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("news:1,2|v\nnews:2|c"))
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return svc.GetBufferSize() == 2 }, time.Second, 5*time.Millisecond)
+	l.Close()
+	svc.AggregateStats()
+	assert.Equal(t, 1, svc.GetStatistic("news")[2].Clicks)
+}
+
+func TestNewUDPListener_Disabled(t *testing.T) {
+	l := NewUDPListener(&structures.Config{}, &udpTestIngest{}, &mockMetrics{}, &cacheTestLogger{})
+
+	require.NoError(t, l.Listen())
+	l.Close()
+}
@@ -0,0 +1,215 @@
+package providers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"ssd/internal/models"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	BotActionDrop      = "drop"
+	BotActionSegregate = "segregate"
+
+	defaultBotSuffix     = ".bots"
+	defaultRulesReload   = 30 * time.Second
+	reasonEmptyUserAgent = "empty_user_agent"
+	deviceDimension      = "device"
+	browserDimension     = "browser"
+	deviceBot            = "bot"
+	deviceOther          = "other"
+)
+
+// UserAgentProviderInterface inspects ingested events by the User-Agent of
+// their source. Enrich reports false when the event must be dropped.
+type UserAgentProviderInterface interface {
+	services.Enricher
+	Close()
+}
+
+// UserAgentInfo is the classification of a User-Agent header.
+type UserAgentInfo struct {
+	Device    string // mobile, tablet, desktop, bot or other
+	Browser   string
+	BotReason string // matched bot rule, empty for regular clients
+}
+
+// uaRule marks User-Agents containing pattern (lower case) as bots.
+type uaRule struct {
+	reason  string
+	pattern string
+}
+
+// defaultBotRules are used when no rules file is configured.
+var defaultBotRules = []uaRule{
+	{"crawler", "bot"}, {"crawler", "crawl"}, {"crawler", "spider"}, {"crawler", "slurp"},
+	{"crawler", "facebookexternalhit"}, {"crawler", "mediapartners"},
+	{"headless", "headlesschrome"}, {"headless", "phantomjs"}, {"headless", "lighthouse"},
+	{"tool", "curl/"}, {"tool", "wget/"}, {"tool", "python-requests"}, {"tool", "go-http-client"},
+	{"tool", "okhttp"}, {"tool", "java/"},
+}
+
+// browserPatterns are checked in order; the first match wins, so engines
+// embedded in other browsers' User-Agents come last.
+var browserPatterns = []struct{ pattern, name string }{
+	{"edg/", "edge"}, {"opr/", "opera"}, {"samsungbrowser", "samsung"}, {"yabrowser", "yandex"},
+	{"firefox/", "firefox"}, {"fxios", "firefox"}, {"crios", "chrome"}, {"chrome/", "chrome"},
+	{"safari/", "safari"},
+}
+
+type UserAgentProvider struct {
+	conf    structures.UserAgentConfig
+	logger  Logger
+	metrics MetricsProviderInterface
+	rules   atomic.Pointer[[]uaRule]
+	watcher *fileWatcher
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// Classify parses a User-Agent into device class and browser and matches it
+// against the bot rules.
+func (p *UserAgentProvider) Classify(ua string) UserAgentInfo {
+	if ua == "" {
+		return UserAgentInfo{Device: deviceOther, Browser: deviceOther, BotReason: reasonEmptyUserAgent}
+	}
+	lower := strings.ToLower(ua)
+	for _, rule := range *p.rules.Load() {
+		if strings.Contains(lower, rule.pattern) {
+			return UserAgentInfo{Device: deviceBot, Browser: deviceOther, BotReason: rule.reason}
+		}
+	}
+
+	info := UserAgentInfo{Device: "desktop", Browser: deviceOther}
+	switch {
+	case strings.Contains(lower, "ipad") || strings.Contains(lower, "tablet") ||
+		(strings.Contains(lower, "android") && !strings.Contains(lower, "mobile")):
+		info.Device = "tablet"
+	case strings.Contains(lower, "mobi") || strings.Contains(lower, "iphone"):
+		info.Device = "mobile"
+	}
+	for _, b := range browserPatterns {
+		if strings.Contains(lower, b.pattern) {
+			info.Browser = b.name
+			break
+		}
+	}
+	return info
+}
+
+// Enrich applies the bot action and, with userAgent.dimensions, sets the
+// device and browser dimensions, replacing any the client sent so they
+// cannot be spoofed. Events of transports without a user agent are passed
+// through unclassified.
+func (p *UserAgentProvider) Enrich(src services.Source, payload *models.InputStats) bool {
+	if src.NoUserAgent {
+		return true
+	}
+
+	info := p.Classify(src.UserAgent)
+	if info.BotReason != "" {
+		p.metrics.IncIngestFiltered(info.BotReason)
+		if p.conf.BotAction != BotActionSegregate {
+			return false
+		}
+		payload.Channel += p.conf.BotSuffix
+	}
+
+	if p.conf.Dimensions {
+		if payload.Dims == nil {
+			payload.Dims = make(map[string]string, 2)
+		}
+		payload.Dims[deviceDimension] = info.Device
+		payload.Dims[browserDimension] = info.Browser
+	}
+	return true
+}
+
+// Close stops the reload worker of the rules file.
+func (p *UserAgentProvider) Close() {
+	p.once.Do(func() {
+		close(p.stop)
+		p.wg.Wait()
+	})
+}
+
+// reload re-reads the rules file, keeping the current rules on error. It
+// runs on the reload worker, so ingest requests never wait for it.
+func (p *UserAgentProvider) reload() {
+	rules, err := loadUserAgentRules(p.conf.RulesFile)
+	if err != nil {
+		p.logger.Errorf(TypeApp, "User-Agent rules reload failed: %s", err)
+		return
+	}
+	p.rules.Store(&rules)
+	p.logger.Infof(TypeApp, "Loaded %d User-Agent rules from %s", len(rules), p.conf.RulesFile)
+}
+
+// loadUserAgentRules reads one "reason pattern" pair per line. Blank lines
+// and lines starting with # are ignored; patterns match case-insensitively.
+func loadUserAgentRules(path string) ([]uaRule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var rules []uaRule
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		reason, pattern, ok := strings.Cut(text, " ")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("%s:%d: want \"reason pattern\"", path, line)
+		}
+		rules = append(rules, uaRule{reason: reason, pattern: strings.ToLower(pattern)})
+	}
+	return rules, scanner.Err()
+}
+
+func NewUserAgentProvider(conf *structures.Config, logger Logger, metrics MetricsProviderInterface) (UserAgentProviderInterface, error) {
+	if !conf.UserAgent.Enabled {
+		return &noopUserAgent{}, nil
+	}
+
+	p := &UserAgentProvider{conf: conf.UserAgent, logger: logger, metrics: metrics, stop: make(chan struct{})}
+	if p.conf.BotSuffix == "" {
+		p.conf.BotSuffix = defaultBotSuffix
+	}
+	rules := defaultBotRules
+	if p.conf.RulesFile != "" {
+		var err error
+		if rules, err = loadUserAgentRules(p.conf.RulesFile); err != nil {
+			return nil, err
+		}
+		interval := p.conf.ReloadInterval
+		if interval <= 0 {
+			interval = defaultRulesReload
+		}
+		p.watcher = newFileWatcher(p.conf.RulesFile, interval)
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.watcher.watch(p.stop, p.reload)
+		}()
+	}
+	p.rules.Store(&rules)
+	return p, nil
+}
+
+// noopUserAgent accepts every event unchanged when User-Agent handling is off.
+type noopUserAgent struct{}
+
+func (n *noopUserAgent) Enrich(_ services.Source, _ *models.InputStats) bool { return true }
+func (n *noopUserAgent) Close()                                              {}
@@ -0,0 +1,167 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"ssd/internal/models"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	uaChromeDesktop = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	uaSafariIPhone  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"
+	uaAndroidTablet = "Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	uaEdge          = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0"
+	uaGooglebot     = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+)
+
+func newTestUserAgentProvider(t *testing.T, conf structures.UserAgentConfig) (*UserAgentProvider, *mockMetrics) {
+	t.Helper()
+	conf.Enabled = true
+	metrics := &mockMetrics{}
+	p, err := NewUserAgentProvider(&structures.Config{UserAgent: conf}, &cacheTestLogger{}, metrics)
+	require.NoError(t, err)
+	t.Cleanup(p.Close)
+	return p.(*UserAgentProvider), metrics
+}
+
+func enrich(p *UserAgentProvider, ua string) (*models.InputStats, bool) {
+	payload := &models.InputStats{Channel: "news"}
+	return payload, p.Enrich(services.Source{UserAgent: ua}, payload)
+}
+
+func TestUserAgentProvider_Classify(t *testing.T) {
+	p, _ := newTestUserAgentProvider(t, structures.UserAgentConfig{})
+
+	cases := []struct {
+		ua   string
+		want UserAgentInfo
+	}{
+		{uaChromeDesktop, UserAgentInfo{Device: "desktop", Browser: "chrome"}},
+		{uaSafariIPhone, UserAgentInfo{Device: "mobile", Browser: "safari"}},
+		{uaAndroidTablet, UserAgentInfo{Device: "tablet", Browser: "chrome"}},
+		{uaEdge, UserAgentInfo{Device: "desktop", Browser: "edge"}},
+		{uaGooglebot, UserAgentInfo{Device: "bot", Browser: "other", BotReason: "crawler"}},
+		{"curl/8.4.0", UserAgentInfo{Device: "bot", Browser: "other", BotReason: "tool"}},
+		{"", UserAgentInfo{Device: "other", Browser: "other", BotReason: "empty_user_agent"}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, p.Classify(c.ua), c.ua)
+	}
+}
+
+func TestUserAgentProvider_DropsBots(t *testing.T) {
+	p, metrics := newTestUserAgentProvider(t, structures.UserAgentConfig{BotAction: BotActionDrop})
+
+	_, ok := enrich(p, uaGooglebot)
+	assert.False(t, ok)
+	_, ok = enrich(p, "")
+	assert.False(t, ok)
+	payload, ok := enrich(p, uaChromeDesktop)
+	assert.True(t, ok)
+	assert.Equal(t, "news", payload.Channel)
+	assert.Nil(t, payload.Dims, "dimensions are only set when enabled")
+
+	assert.Equal(t, map[string]int{"crawler": 1, "empty_user_agent": 1}, metrics.filtered)
+}
+
+func TestUserAgentProvider_SegregatesBots(t *testing.T) {
+	p, metrics := newTestUserAgentProvider(t, structures.UserAgentConfig{BotAction: BotActionSegregate})
+
+	payload, ok := enrich(p, uaGooglebot)
+	assert.True(t, ok)
+	assert.Equal(t, "news.bots", payload.Channel)
+	assert.Equal(t, 1, metrics.filtered["crawler"])
+}
+
+func TestUserAgentProvider_Dimensions(t *testing.T) {
+	p, _ := newTestUserAgentProvider(t, structures.UserAgentConfig{Dimensions: true})
+
+	payload, ok := enrich(p, uaSafariIPhone)
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{"device": "mobile", "browser": "safari"}, payload.Dims)
+
+	payload = &models.InputStats{Dims: map[string]string{"device": "kiosk", "browser": "netscape", "plan": "pro"}}
+	require.True(t, p.Enrich(services.Source{UserAgent: uaSafariIPhone}, payload))
+	assert.Equal(t, map[string]string{"device": "mobile", "browser": "safari", "plan": "pro"}, payload.Dims, "the server classification wins")
+}
+
+func TestUserAgentProvider_RulesFileReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bots.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# custom rules\nmonitor uptimerobot\n"), 0o644))
+
+	p, metrics := newTestUserAgentProvider(t, structures.UserAgentConfig{RulesFile: path, ReloadInterval: time.Millisecond})
+
+	_, ok := enrich(p, "UptimeRobot/2.0")
+	assert.False(t, ok)
+	_, ok = enrich(p, uaGooglebot)
+	assert.True(t, ok, "the rules file replaces the default rules")
+
+	require.NoError(t, os.WriteFile(path, []byte("crawler googlebot\n"), 0o644))
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, future, future))
+	require.Eventually(t, func() bool {
+		return len(*p.rules.Load()) == 1 && (*p.rules.Load())[0].reason == "crawler"
+	}, time.Second, time.Millisecond, "the rules are reloaded in the background")
+
+	_, ok = enrich(p, uaGooglebot)
+	assert.False(t, ok)
+	_, ok = enrich(p, "UptimeRobot/2.0")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]int{"monitor": 1, "crawler": 1}, metrics.filtered)
+}
+
+func TestUserAgentProvider_EnrichDoesNotReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bots.txt")
+	require.NoError(t, os.WriteFile(path, []byte("monitor uptimerobot\n"), 0o644))
+	p, _ := newTestUserAgentProvider(t, structures.UserAgentConfig{RulesFile: path, ReloadInterval: time.Hour})
+
+	require.NoError(t, os.WriteFile(path, []byte("crawler googlebot\n"), 0o644))
+	future := time.Now().Add(time.Hour)
+	require.NoError(t, os.Chtimes(path, future, future))
+	_, ok := enrich(p, uaGooglebot)
+	assert.True(t, ok, "requests use the loaded rules until the worker reloads them")
+
+	p.Close()
+	p.Close()
+}
+
+func TestUserAgentProvider_InvalidRulesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bots.txt")
+	require.NoError(t, os.WriteFile(path, []byte("nopattern\n"), 0o644))
+
+	conf := &structures.Config{UserAgent: structures.UserAgentConfig{Enabled: true, RulesFile: path}}
+	_, err := NewUserAgentProvider(conf, &cacheTestLogger{}, &mockMetrics{})
+	assert.Error(t, err)
+
+	conf.UserAgent.RulesFile = filepath.Join(t.TempDir(), "missing.txt")
+	_, err = NewUserAgentProvider(conf, &cacheTestLogger{}, &mockMetrics{})
+	assert.Error(t, err)
+}
+
+func TestUserAgentProvider_DisabledIsNoop(t *testing.T) {
+	metrics := &mockMetrics{}
+	p, err := NewUserAgentProvider(&structures.Config{}, &cacheTestLogger{}, metrics)
+	require.NoError(t, err)
+
+	payload := &models.InputStats{Channel: "news"}
+	assert.True(t, p.Enrich(services.Source{}, payload))
+	assert.Equal(t, "news", payload.Channel)
+	assert.Nil(t, metrics.filtered)
+}
+
+func TestUserAgentProvider_SourceWithoutUserAgent(t *testing.T) {
+	p, metrics := newTestUserAgentProvider(t, structures.UserAgentConfig{Dimensions: true})
+
+	payload := &models.InputStats{Channel: "news"}
+	assert.True(t, p.Enrich(services.Source{NoUserAgent: true}, payload), "transports without a user agent are not dropped as empty")
+	assert.Equal(t, "news", payload.Channel)
+	assert.Nil(t, payload.Dims)
+	assert.Empty(t, metrics.filtered)
+}
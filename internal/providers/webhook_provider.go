@@ -0,0 +1,229 @@
+package providers
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	json "github.com/goccy/go-json"
+	"net/http"
+	"os"
+	"ssd/internal/structures"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWebhookTimeout    = 5 * time.Second
+	defaultWebhookRetries    = 3
+	defaultWebhookBackoff    = time.Second
+	defaultWebhookQueueSize  = 1000
+	maxWebhookBackoffDoubles = 10
+
+	// WebhookSignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the
+	// body keyed with webhooks.secret.
+	WebhookSignatureHeader = "X-SSD-Signature"
+)
+
+var errWebhookShutdown = errors.New("not delivered before shutdown")
+
+// WebhookProviderInterface delivers events to the configured webhooks in the
+// background. Send never blocks; events are dropped when the queue is full.
+type WebhookProviderInterface interface {
+	Send(event string, data any)
+	Close()
+}
+
+// WebhookMessage is the JSON body posted to every webhook.
+type WebhookMessage struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	Data  any       `json:"data"`
+}
+
+// deadLetter is a line of the dead-letter file.
+type deadLetter struct {
+	Time  time.Time       `json:"time"`
+	URL   string          `json:"url"`
+	Error string          `json:"error"`
+	Body  json.RawMessage `json:"body"`
+}
+
+type WebhookProvider struct {
+	conf   structures.WebhooksConfig
+	logger Logger
+	client *http.Client
+	queue  chan []byte
+	stop   chan struct{}
+	wg     sync.WaitGroup
+	once   sync.Once
+	now    func() time.Time
+	dead   *os.File // opened on the first dead letter
+}
+
+type noopWebhooks struct{}
+
+func (noopWebhooks) Send(string, any) {}
+func (noopWebhooks) Close()           {}
+
+func NewWebhookProvider(conf *structures.Config, logger Logger) WebhookProviderInterface {
+	if len(conf.Webhooks.URLs) == 0 {
+		return noopWebhooks{}
+	}
+	return newWebhookProvider(conf.Webhooks, logger)
+}
+
+func newWebhookProvider(conf structures.WebhooksConfig, logger Logger) *WebhookProvider {
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultWebhookTimeout
+	}
+	if conf.MaxRetries <= 0 {
+		conf.MaxRetries = defaultWebhookRetries
+	}
+	if conf.Backoff <= 0 {
+		conf.Backoff = defaultWebhookBackoff
+	}
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = defaultWebhookQueueSize
+	}
+	p := &WebhookProvider{
+		conf:   conf,
+		logger: logger,
+		client: &http.Client{Timeout: conf.Timeout},
+		queue:  make(chan []byte, conf.QueueSize),
+		stop:   make(chan struct{}),
+		now:    time.Now,
+	}
+	p.wg.Add(1)
+	go p.run()
+	return p
+}
+
+func (p *WebhookProvider) Send(event string, data any) {
+	body, err := json.Marshal(WebhookMessage{Event: event, Time: p.now().UTC(), Data: data})
+	if err != nil {
+		p.logger.Errorf(TypeApp, "Webhook %s not sent: %s", event, err)
+		return
+	}
+	select {
+	case <-p.stop:
+		return
+	default:
+	}
+	select {
+	case p.queue <- body:
+	default:
+		p.logger.Warnf(TypeApp, "Webhook queue full, %s event dropped", event)
+	}
+}
+
+// Close stops the delivery worker. The request in flight is finished, but
+// retries are abandoned; queued events are written to the dead-letter file.
+func (p *WebhookProvider) Close() {
+	p.once.Do(func() {
+		close(p.stop)
+		p.wg.Wait()
+		if n := len(p.queue); n > 0 {
+			p.logger.Warnf(TypeApp, "Webhooks closed with %d undelivered events", n)
+		}
+		for len(p.queue) > 0 {
+			body := <-p.queue
+			for _, url := range p.conf.URLs {
+				p.deadLetter(url, body, errWebhookShutdown)
+			}
+		}
+		if p.dead != nil {
+			_ = p.dead.Close()
+		}
+	})
+}
+
+func (p *WebhookProvider) run() {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case body := <-p.queue:
+			for _, url := range p.conf.URLs {
+				if err := p.deliver(url, body); err != nil {
+					p.logger.Errorf(TypeApp, "Webhook delivery to %s failed: %s", url, err)
+					p.deadLetter(url, body, err)
+				}
+			}
+		}
+	}
+}
+
+// deliver posts body to url, retrying failed attempts up to maxRetries
+// times with exponentially growing pauses.
+func (p *WebhookProvider) deliver(url string, body []byte) error {
+	var err error
+	for attempt := 0; attempt <= p.conf.MaxRetries; attempt++ {
+		if attempt > 0 {
+			wait := p.conf.Backoff << min(attempt-1, maxWebhookBackoffDoubles)
+			select {
+			case <-p.stop:
+				return fmt.Errorf("closed after %d attempts: %w", attempt, err)
+			case <-time.After(wait):
+			}
+		}
+		if err = p.post(url, body); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("%d attempts: %w", p.conf.MaxRetries+1, err)
+}
+
+func (p *WebhookProvider) post(url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.conf.Secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(p.conf.Secret, body))
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// deadLetter appends an undelivered body to webhooks.deadLetterFile. It is
+// only called from the worker, or from Close once the worker has stopped.
+func (p *WebhookProvider) deadLetter(url string, body []byte, cause error) {
+	if p.conf.DeadLetterFile == "" {
+		return
+	}
+	if p.dead == nil {
+		f, err := os.OpenFile(p.conf.DeadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+		if err != nil {
+			p.logger.Errorf(TypeApp, "Webhook dead-letter file not opened: %s", err)
+			return
+		}
+		p.dead = f
+	}
+	line, err := json.Marshal(deadLetter{Time: p.now().UTC(), URL: url, Error: cause.Error(), Body: body})
+	if err != nil {
+		p.logger.Errorf(TypeApp, "Webhook dead letter not written: %s", err)
+		return
+	}
+	if _, err := p.dead.Write(append(line, '\n')); err != nil {
+		p.logger.Errorf(TypeApp, "Webhook dead letter not written: %s", err)
+	}
+}
+
+// SignWebhook returns the X-SSD-Signature value of body for secret.
+func SignWebhook(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,194 @@
+package providers
+
+import (
+	json "github.com/goccy/go-json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"ssd/internal/structures"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewWebhookProvider_NoopWithoutURLs(t *testing.T) {
+	p := NewWebhookProvider(&structures.Config{}, &cacheTestLogger{})
+
+	assert.IsType(t, noopWebhooks{}, p)
+	p.Send("anomaly", 1)
+	p.Close()
+}
+
+func TestWebhookProvider_Delivers(t *testing.T) {
+	bodies := make(chan []byte, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	p := newWebhookProvider(structures.WebhooksConfig{URLs: []string{srv.URL, srv.URL}}, &cacheTestLogger{})
+	defer p.Close()
+	p.now = func() time.Time { return time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC) }
+	p.Send("anomaly", map[string]int{"value": 3})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case body := <-bodies:
+			var msg struct {
+				Event string         `json:"event"`
+				Time  time.Time      `json:"time"`
+				Data  map[string]int `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(body, &msg))
+			assert.Equal(t, "anomaly", msg.Event)
+			assert.Equal(t, 2026, msg.Time.Year())
+			assert.Equal(t, map[string]int{"value": 3}, msg.Data)
+		case <-time.After(2 * time.Second):
+			t.Fatal("webhook not delivered to every URL")
+		}
+	}
+}
+
+func TestWebhookProvider_Retries(t *testing.T) {
+	var calls atomic.Int32
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		close(done)
+	}))
+	defer srv.Close()
+
+	p := newWebhookProvider(structures.WebhooksConfig{URLs: []string{srv.URL}, Backoff: time.Millisecond}, &cacheTestLogger{})
+	defer p.Close()
+	p.Send("anomaly", nil)
+
+	select {
+	case <-done:
+		assert.Equal(t, int32(3), calls.Load())
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook not retried")
+	}
+}
+
+func TestWebhookProvider_CloseAbortsRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	p := newWebhookProvider(structures.WebhooksConfig{URLs: []string{srv.URL}, Backoff: time.Hour}, &cacheTestLogger{})
+	p.Send("anomaly", nil)
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, 2*time.Second, 5*time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		p.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close waited for the backoff")
+	}
+	p.Close()
+	p.Send("anomaly", nil)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWebhookProvider_Signs(t *testing.T) {
+	signatures := make(chan string, 1)
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signatures <- r.Header.Get(WebhookSignatureHeader)
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	p := newWebhookProvider(structures.WebhooksConfig{URLs: []string{srv.URL}, Secret: "s3cret"}, &cacheTestLogger{})
+	defer p.Close()
+	p.Send("rule", nil)
+
+	select {
+	case sig := <-signatures:
+		body := <-bodies
+		assert.Equal(t, SignWebhook("s3cret", body), sig)
+		assert.Regexp(t, "^sha256=[0-9a-f]{64}$", sig)
+		assert.NotEqual(t, SignWebhook("other", body), sig)
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+}
+
+func TestWebhookProvider_DeadLetters(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	file := filepath.Join(t.TempDir(), "dead.log")
+	p := newWebhookProvider(structures.WebhooksConfig{
+		URLs:           []string{srv.URL},
+		MaxRetries:     1,
+		Backoff:        time.Millisecond,
+		DeadLetterFile: file,
+	}, &cacheTestLogger{})
+	p.Send("rule", map[string]string{"rule": "disk"})
+	require.Eventually(t, func() bool { return calls.Load() == 2 }, 2*time.Second, 5*time.Millisecond)
+	p.Close()
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+	var entry struct {
+		URL   string `json:"url"`
+		Error string `json:"error"`
+		Body  struct {
+			Event string            `json:"event"`
+			Data  map[string]string `json:"data"`
+		} `json:"body"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, srv.URL, entry.URL)
+	assert.Contains(t, entry.Error, "status 502")
+	assert.Equal(t, "rule", entry.Body.Event)
+	assert.Equal(t, "disk", entry.Body.Data["rule"])
+}
+
+func TestWebhookProvider_DeadLettersQueueOnClose(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "dead.log")
+	p := &WebhookProvider{
+		conf:   structures.WebhooksConfig{URLs: []string{"http://a", "http://b"}, DeadLetterFile: file},
+		logger: &cacheTestLogger{},
+		queue:  make(chan []byte, 2),
+		stop:   make(chan struct{}),
+		now:    time.Now,
+	}
+	p.Send("rule", 1)
+	p.Close()
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2, "one line per URL")
+	assert.Contains(t, lines[0], `"url":"http://a"`)
+	assert.Contains(t, lines[1], `"url":"http://b"`)
+	assert.Contains(t, lines[1], errWebhookShutdown.Error())
+}
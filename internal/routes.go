@@ -4,16 +4,29 @@ import (
 	"net/http"
 	"ssd/internal/controllers"
 	"ssd/internal/providers"
-	"ssd/internal/structures"
 )
 
-func InitRoutes(apiController *controllers.ApiController, conf *structures.Config) providers.RouterProviderInterface {
-	routers := providers.NewRouterProvider()
+func InitRoutes(apiController *controllers.ApiController, catalogController *controllers.CatalogController, alertsController *controllers.AlertsController, rulesController *controllers.RulesController, streamController *controllers.StreamController, socketController *controllers.SocketController, cors providers.CORSPolicyInterface) providers.RouterProviderInterface {
+	routers := providers.NewRouterProvider(cors)
 
 	routers.Get("/list", http.HandlerFunc(apiController.GetStats))
 	routers.Post("/", http.HandlerFunc(apiController.ReceiveStats))
+	routers.Get("/px.gif", http.HandlerFunc(apiController.TrackPixel))
 	routers.Get("/fingerprints", http.HandlerFunc(apiController.GetPersonalStats))
 	routers.Get("/fingerprint", http.HandlerFunc(apiController.GetByFingerprint))
 	routers.Get("/channels", http.HandlerFunc(apiController.GetChannels))
+	routers.Get("/related", http.HandlerFunc(apiController.GetRelated))
+	routers.Get("/feed", http.HandlerFunc(apiController.GetFeed))
+	routers.Get("/breakdown", http.HandlerFunc(apiController.GetBreakdown))
+	routers.Get("/experiments/{id}", http.HandlerFunc(apiController.GetExperiment))
+	routers.Get("/funnels/{name}", http.HandlerFunc(apiController.GetFunnel))
+	routers.Get("/rising", http.HandlerFunc(apiController.GetRising))
+	routers.Get("/stream", http.HandlerFunc(streamController.Stream))
+	routers.Get("/ws", http.HandlerFunc(socketController.Socket))
+	routers.Get("/alerts", http.HandlerFunc(alertsController.GetAlerts))
+	routers.Get("/rules", http.HandlerFunc(rulesController.GetRules))
+	routers.AdminPost("/rules/update", http.HandlerFunc(rulesController.Update))
+	routers.Get("/catalog", http.HandlerFunc(catalogController.GetItem))
+	routers.AdminPost("/catalog/update", http.HandlerFunc(catalogController.Update))
 	return routers
 }
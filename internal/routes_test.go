@@ -6,9 +6,10 @@ import (
 	"ssd/internal/controllers"
 	"ssd/internal/models"
 	"ssd/internal/providers"
+	"ssd/internal/services"
 	"ssd/internal/structures"
+	"ssd/internal/testutil"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,29 +34,63 @@ func (m *routeTestCache) Set(_ string, _ []byte)      {}
 type routeTestMockService struct{}
 
 func (m *routeTestMockService) AddStats(_ *models.InputStats)                    {}
-func (m *routeTestMockService) AggregateStats()                                  {}
+func (m *routeTestMockService) AggregateStats() int                              { return 0 }
 func (m *routeTestMockService) GetStatistic(_ string) map[int]*models.StatRecord { return nil }
+func (m *routeTestMockService) GetStatisticJSON(_ string) []byte                 { return nil }
 func (m *routeTestMockService) GetPersonalStatistic(_ string) map[string]*models.Statistic {
 	return nil
 }
+func (m *routeTestMockService) GetPersonalStatisticJSON(_ string) []byte                { return nil }
 func (m *routeTestMockService) GetByFingerprint(_, _ string) map[int]*models.StatRecord { return nil }
-func (m *routeTestMockService) PutChannelData(_ string, _ map[int]*models.StatRecord, _ map[string]*models.Statistic) {
+func (m *routeTestMockService) GetFeed(_, _ string, _ int, _ models.CatalogFilter) []services.FeedItem {
+	return nil
 }
-func (m *routeTestMockService) GetChannels() []string        { return nil }
-func (m *routeTestMockService) GetSnapshot() *models.Storage { return nil }
-func (m *routeTestMockService) GetBufferSize() int           { return 0 }
-func (m *routeTestMockService) GetRecordCount(_ string) int  { return 0 }
-
-func TestInitRoutes_RegistersFiveRoutes(t *testing.T) {
-	ac := controllers.NewApiController(&routeTestLogger{}, &routeTestMockService{}, &routeTestCache{})
-	conf := &structures.Config{
-		Statistic: structures.StatisticConfig{Interval: 10 * time.Second},
-	}
-
-	router := InitRoutes(ac, conf)
+func (m *routeTestMockService) GetFilteredStatistic(_ string, _ models.CatalogFilter) map[int]*models.StatRecord {
+	return nil
+}
+func (m *routeTestMockService) GetDimensionStatistic(_ string, _ models.DimKey, _ models.CatalogFilter) map[int]*models.StatRecord {
+	return nil
+}
+func (m *routeTestMockService) GetBreakdown(_ string, _ int, _ string) map[string]*models.StatRecord {
+	return nil
+}
+func (m *routeTestMockService) GetFunnel(_, _ string, _ []int) *services.FunnelReport {
+	return nil
+}
+func (m *routeTestMockService) GetRising(_ string, _ int) []services.RisingItem {
+	return nil
+}
+func (m *routeTestMockService) GetLastBatch() map[string]services.BatchStats {
+	return nil
+}
+func (m *routeTestMockService) GetExperimentRejects() int64 { return 0 }
+func (m *routeTestMockService) GetExperiment(_, _ string, _ []int) *services.ExperimentReport {
+	return nil
+}
+func (m *routeTestMockService) GetCatalogItem(_ string, _ int) (*models.CatalogItem, bool) {
+	return nil, false
+}
+func (m *routeTestMockService) UpdateCatalog(_ string, _ map[int]*models.CatalogItem, _ []int) {}
+func (m *routeTestMockService) GetRelated(_ string, _, _ int) []models.RelatedItem             { return nil }
+func (m *routeTestMockService) PutChannelData(_ string, _ *models.ChannelData)                 {}
+func (m *routeTestMockService) GetChannels() []string                                          { return nil }
+func (m *routeTestMockService) GetSnapshot() *models.Storage                                   { return nil }
+func (m *routeTestMockService) GetBufferSize() int                                             { return 0 }
+func (m *routeTestMockService) GetRecordCount(_ string) int                                    { return 0 }
+func (m *routeTestMockService) GetMemoryStats() services.MemoryStats                           { return services.MemoryStats{} }
+
+func TestInitRoutes_RegistersRoutes(t *testing.T) {
+	svc := &routeTestMockService{}
+	ac := controllers.NewApiController(&routeTestLogger{}, svc, &routeTestCache{}, services.NewIngestService(svc, nil), providers.NewCORSPolicy(&structures.Config{}))
+	cc := controllers.NewCatalogController(svc)
+	alc := controllers.NewAlertsController(&testutil.MockAnomalyDetector{})
+	rc := controllers.NewRulesController(&testutil.MockRuleEngine{})
+	sc := controllers.NewStreamController(&testutil.MockStream{})
+	wsc := controllers.NewSocketController(&structures.Config{}, ac, &testutil.MockStream{})
+	router := InitRoutes(ac, cc, alc, rc, sc, wsc, providers.NewCORSPolicy(&structures.Config{}))
 	routes := router.GetRoutes()
 
-	require.Len(t, routes, 5)
+	require.Len(t, routes, 19)
 
 	urls := make([]string, len(routes))
 	for i, r := range routes {
@@ -64,18 +99,34 @@ func TestInitRoutes_RegistersFiveRoutes(t *testing.T) {
 
 	assert.Contains(t, urls, "/list")
 	assert.Contains(t, urls, "/")
+	assert.Contains(t, urls, "/px.gif")
 	assert.Contains(t, urls, "/fingerprints")
 	assert.Contains(t, urls, "/fingerprint")
 	assert.Contains(t, urls, "/channels")
+	assert.Contains(t, urls, "/related")
+	assert.Contains(t, urls, "/feed")
+	assert.Contains(t, urls, "/breakdown")
+	assert.Contains(t, urls, "/experiments/{id}")
+	assert.Contains(t, urls, "/funnels/{name}")
+	assert.Contains(t, urls, "/rising")
+	assert.Contains(t, urls, "/stream")
+	assert.Contains(t, urls, "/ws")
+	assert.Contains(t, urls, "/alerts")
+	assert.Contains(t, urls, "/rules")
+	assert.Contains(t, urls, "/rules/update")
+	assert.Contains(t, urls, "/catalog")
+	assert.Contains(t, urls, "/catalog/update")
 }
 
 func TestInitRoutes_MethodEnforcement(t *testing.T) {
-	ac := controllers.NewApiController(&routeTestLogger{}, &routeTestMockService{}, &routeTestCache{})
-	conf := &structures.Config{
-		Statistic: structures.StatisticConfig{Interval: 10 * time.Second},
-	}
-
-	router := InitRoutes(ac, conf)
+	svc := &routeTestMockService{}
+	ac := controllers.NewApiController(&routeTestLogger{}, svc, &routeTestCache{}, services.NewIngestService(svc, nil), providers.NewCORSPolicy(&structures.Config{}))
+	cc := controllers.NewCatalogController(svc)
+	alc := controllers.NewAlertsController(&testutil.MockAnomalyDetector{})
+	rc := controllers.NewRulesController(&testutil.MockRuleEngine{})
+	sc := controllers.NewStreamController(&testutil.MockStream{})
+	wsc := controllers.NewSocketController(&structures.Config{}, ac, &testutil.MockStream{})
+	router := InitRoutes(ac, cc, alc, rc, sc, wsc, providers.NewCORSPolicy(&structures.Config{}))
 	routes := router.GetRoutes()
 
 	mux := http.NewServeMux()
@@ -95,3 +146,44 @@ func TestInitRoutes_MethodEnforcement(t *testing.T) {
 	mux.ServeHTTP(rr, req)
 	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
 }
+
+func TestInitRoutes_AdminRoutesSkipCORS(t *testing.T) {
+	cors := providers.NewCORSPolicy(&structures.Config{CORS: structures.CORSConfig{Enabled: true, AllowedOrigins: []string{"*"}}})
+	svc := &routeTestMockService{}
+	ac := controllers.NewApiController(&routeTestLogger{}, svc, &routeTestCache{}, services.NewIngestService(svc, nil), cors)
+	cc := controllers.NewCatalogController(svc)
+	alc := controllers.NewAlertsController(&testutil.MockAnomalyDetector{})
+	rc := controllers.NewRulesController(&testutil.MockRuleEngine{})
+	sc := controllers.NewStreamController(&testutil.MockStream{})
+	wsc := controllers.NewSocketController(&structures.Config{}, ac, &testutil.MockStream{})
+	router := InitRoutes(ac, cc, alc, rc, sc, wsc, cors)
+
+	mux := http.NewServeMux()
+	for _, r := range router.GetRoutes() {
+		mux.Handle(r.Url, r.Handler)
+	}
+
+	request := func(method, target string, preflight bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Origin", "https://evil.example")
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := request(http.MethodOptions, "/", true)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://evil.example", rr.Header().Get("Access-Control-Allow-Origin"))
+
+	for _, target := range []string{"/rules/update", "/catalog/update"} {
+		rr = request(http.MethodOptions, target, true)
+		assert.Equal(t, http.StatusMethodNotAllowed, rr.Code, target)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"), target)
+
+		rr = request(http.MethodPost, target, false)
+		assert.Empty(t, rr.Header().Get("Access-Control-Allow-Origin"), target)
+	}
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"ssd/internal/structures"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	AlertSpike = "spike"
+	AlertDrop  = "drop"
+	// AlertEvent is the webhook event name of anomaly alerts.
+	AlertEvent = "anomaly"
+
+	defaultAnomalyAlpha     = 0.3
+	defaultAnomalyThreshold = 4
+	defaultAnomalyWarmup    = 10
+	defaultAnomalyMinVolume = 50
+	defaultAnomalyTopItems  = 20
+	defaultAnomalyCooldown  = 10 * time.Minute
+	defaultMaxAlerts        = 100
+)
+
+// Alert reports a channel's events or an item's views per aggregation
+// interval departing from their rolling baseline. Item is nil for alerts
+// about a whole channel.
+type Alert struct {
+	ID       string    `json:"id"`
+	Channel  string    `json:"channel"`
+	Item     *int      `json:"item,omitempty"`
+	Kind     string    `json:"kind"`
+	Value    float64   `json:"value"`
+	Baseline float64   `json:"baseline"`
+	ZScore   float64   `json:"zscore"`
+	Time     time.Time `json:"time"`
+}
+
+// AnomalyDetectorInterface compares every aggregation with rolling
+// baselines and keeps the most recent alerts.
+type AnomalyDetectorInterface interface {
+	Detect(batches map[string]BatchStats, elapsed time.Duration) []Alert
+	Alerts(channel string) []Alert
+}
+
+// baseline is an exponentially weighted moving mean and variance.
+type baseline struct {
+	mean     float64
+	variance float64
+	n        int
+}
+
+// score returns the z-score of x. The deviation is floored at that of a
+// Poisson count with the same mean, so a perfectly steady series does not
+// turn every small wobble into an anomaly.
+func (b *baseline) score(x float64) float64 {
+	std := max(math.Sqrt(b.variance), math.Sqrt(max(b.mean, 1)))
+	return (x - b.mean) / std
+}
+
+func (b *baseline) update(x, alpha float64) {
+	if b.n == 0 {
+		b.mean = x
+	} else {
+		diff := x - b.mean
+		incr := alpha * diff
+		b.mean += incr
+		b.variance = (1 - alpha) * (b.variance + diff*incr)
+	}
+	b.n++
+}
+
+type channelBaselines struct {
+	events baseline
+	items  map[int]*baseline
+}
+
+// AnomalyDetector keeps a baseline per channel and per top item. Counts are
+// scaled to the configured aggregation interval, so early aggregations
+// triggered by a full buffer do not look like drops.
+type AnomalyDetector struct {
+	mu        sync.Mutex
+	conf      structures.AnomaliesConfig
+	interval  time.Duration
+	channels  map[string]*channelBaselines
+	lastAlert map[string]time.Time // dedup key -> last alert
+	recent    []Alert              // oldest first
+	now       func() time.Time
+}
+
+type noopAnomalyDetector struct{}
+
+func (noopAnomalyDetector) Detect(map[string]BatchStats, time.Duration) []Alert { return nil }
+func (noopAnomalyDetector) Alerts(string) []Alert                               { return nil }
+
+func NewAnomalyDetector(conf *structures.Config) AnomalyDetectorInterface {
+	if !conf.Anomalies.Enabled {
+		return noopAnomalyDetector{}
+	}
+	return newAnomalyDetector(conf)
+}
+
+func newAnomalyDetector(conf *structures.Config) *AnomalyDetector {
+	d := &AnomalyDetector{
+		conf:      conf.Anomalies,
+		interval:  conf.Statistic.Interval,
+		channels:  make(map[string]*channelBaselines),
+		lastAlert: make(map[string]time.Time),
+		now:       time.Now,
+	}
+	if d.conf.Alpha <= 0 || d.conf.Alpha >= 1 {
+		d.conf.Alpha = defaultAnomalyAlpha
+	}
+	if d.conf.Threshold <= 0 {
+		d.conf.Threshold = defaultAnomalyThreshold
+	}
+	if d.conf.Warmup <= 0 {
+		d.conf.Warmup = defaultAnomalyWarmup
+	}
+	if d.conf.MinVolume <= 0 {
+		d.conf.MinVolume = defaultAnomalyMinVolume
+	}
+	if d.conf.TopItems <= 0 {
+		d.conf.TopItems = defaultAnomalyTopItems
+	}
+	if d.conf.Cooldown <= 0 {
+		d.conf.Cooldown = defaultAnomalyCooldown
+	}
+	if d.conf.MaxAlerts <= 0 {
+		d.conf.MaxAlerts = defaultMaxAlerts
+	}
+	return d
+}
+
+// Detect checks the batch of every known channel, a missing batch counting
+// as zero events, and returns the new alerts. Items are tracked once they
+// are among a channel's topItems most viewed in a batch and dropped when
+// they have left the top and their baseline fell below minVolume.
+func (d *AnomalyDetector) Detect(batches map[string]BatchStats, elapsed time.Duration) []Alert {
+	scale := 1.0
+	if elapsed > 0 && d.interval > 0 {
+		scale = d.interval.Seconds() / elapsed.Seconds()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	for key, at := range d.lastAlert {
+		if now.Sub(at) >= d.conf.Cooldown {
+			delete(d.lastAlert, key)
+		}
+	}
+	for name := range batches {
+		if _, ok := d.channels[name]; !ok {
+			d.channels[name] = &channelBaselines{items: make(map[int]*baseline)}
+		}
+	}
+
+	var alerts []Alert
+	for name, cb := range d.channels {
+		batch := batches[name]
+		if a, ok := d.check(name, nil, &cb.events, float64(batch.Events)*scale, now); ok {
+			alerts = append(alerts, a)
+		}
+		top := topBatchItems(batch.Items, d.conf.TopItems)
+		for id := range top {
+			if _, ok := cb.items[id]; !ok {
+				cb.items[id] = &baseline{}
+			}
+		}
+		for id, b := range cb.items {
+			if a, ok := d.check(name, &id, b, float64(batch.Items[id])*scale, now); ok {
+				alerts = append(alerts, a)
+			}
+			if _, ok := top[id]; !ok && b.mean < float64(d.conf.MinVolume) {
+				delete(cb.items, id)
+			}
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].ID < alerts[j].ID })
+
+	d.recent = append(d.recent, alerts...)
+	if over := len(d.recent) - d.conf.MaxAlerts; over > 0 {
+		d.recent = append(d.recent[:0:0], d.recent[over:]...)
+	}
+	return alerts
+}
+
+// check scores x against b once b is warmed up and either side reaches
+// minVolume, then folds x into b. Alerts for the same series and direction
+// are suppressed for cooldown.
+func (d *AnomalyDetector) check(channel string, item *int, b *baseline, x float64, now time.Time) (Alert, bool) {
+	defer b.update(x, d.conf.Alpha)
+	if b.n < d.conf.Warmup || max(x, b.mean) < float64(d.conf.MinVolume) {
+		return Alert{}, false
+	}
+	z := b.score(x)
+	if math.Abs(z) < d.conf.Threshold {
+		return Alert{}, false
+	}
+	kind := AlertSpike
+	if z < 0 {
+		kind = AlertDrop
+	}
+	key := channel + ":" + kind
+	if item != nil {
+		key = channel + ":" + strconv.Itoa(*item) + ":" + kind
+		id := *item
+		item = &id
+	}
+	if _, dup := d.lastAlert[key]; dup {
+		return Alert{}, false
+	}
+	d.lastAlert[key] = now
+	return Alert{
+		ID:       key + ":" + strconv.FormatInt(now.Unix(), 10),
+		Channel:  channel,
+		Item:     item,
+		Kind:     kind,
+		Value:    x,
+		Baseline: b.mean,
+		ZScore:   z,
+		Time:     now,
+	}, true
+}
+
+// topBatchItems returns the n most viewed items of a batch.
+func topBatchItems(items map[int]int, n int) map[int]struct{} {
+	ids := make([]int, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if items[ids[i]] != items[ids[j]] {
+			return items[ids[i]] > items[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	top := make(map[int]struct{}, min(n, len(ids)))
+	for _, id := range ids[:min(n, len(ids))] {
+		top[id] = struct{}{}
+	}
+	return top
+}
+
+// Alerts returns the recent alerts of a channel, or of all channels for an
+// empty channel, newest first.
+func (d *AnomalyDetector) Alerts(channel string) []Alert {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]Alert, 0, len(d.recent))
+	for i := len(d.recent) - 1; i >= 0; i-- {
+		if channel == "" || d.recent[i].Channel == channel {
+			out = append(out, d.recent[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDetector(conf structures.AnomaliesConfig) (*AnomalyDetector, *time.Time) {
+	conf.Enabled = true
+	d := newAnomalyDetector(&structures.Config{
+		Statistic: structures.StatisticConfig{Interval: time.Minute},
+		Anomalies: conf,
+	})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+func warmUp(d *AnomalyDetector, batches map[string]BatchStats) {
+	for i := 0; i < d.conf.Warmup; i++ {
+		d.Detect(batches, time.Minute)
+	}
+}
+
+func TestAnomalyDetector_Spike(t *testing.T) {
+	d, _ := newTestDetector(structures.AnomaliesConfig{})
+	warmUp(d, map[string]BatchStats{"news": {Events: 100}})
+
+	assert.Empty(t, d.Detect(map[string]BatchStats{"news": {Events: 110}}, time.Minute), "within the noise of a steady series")
+
+	alerts := d.Detect(map[string]BatchStats{"news": {Events: 1000}}, time.Minute)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertSpike, alerts[0].Kind)
+	assert.Equal(t, "news", alerts[0].Channel)
+	assert.Nil(t, alerts[0].Item)
+	assert.Equal(t, 1000.0, alerts[0].Value)
+	assert.Greater(t, alerts[0].ZScore, d.conf.Threshold)
+
+}
+
+func TestAnomalyDetector_Drop(t *testing.T) {
+	d, _ := newTestDetector(structures.AnomaliesConfig{})
+	warmUp(d, map[string]BatchStats{"news": {Events: 100}})
+
+	// A missing batch counts as zero events.
+	alerts := d.Detect(map[string]BatchStats{}, time.Minute)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, AlertDrop, alerts[0].Kind)
+	assert.Less(t, alerts[0].ZScore, -d.conf.Threshold)
+}
+
+func TestAnomalyDetector_Warmup(t *testing.T) {
+	d, _ := newTestDetector(structures.AnomaliesConfig{Warmup: 3})
+	d.Detect(map[string]BatchStats{"news": {Events: 100}}, time.Minute)
+	d.Detect(map[string]BatchStats{"news": {Events: 100}}, time.Minute)
+
+	assert.Empty(t, d.Detect(map[string]BatchStats{"news": {Events: 5000}}, time.Minute))
+}
+
+func TestAnomalyDetector_MinVolume(t *testing.T) {
+	d, _ := newTestDetector(structures.AnomaliesConfig{})
+	warmUp(d, map[string]BatchStats{"news": {Events: 1}})
+
+	assert.Empty(t, d.Detect(map[string]BatchStats{"news": {Events: 40}}, time.Minute))
+}
+
+func TestAnomalyDetector_Cooldown(t *testing.T) {
+	d, now := newTestDetector(structures.AnomaliesConfig{Cooldown: 5 * time.Minute})
+	warmUp(d, map[string]BatchStats{"news": {Events: 100}})
+
+	require.Len(t, d.Detect(map[string]BatchStats{"news": {Events: 10000}}, time.Minute), 1)
+	*now = now.Add(time.Minute)
+	assert.Empty(t, d.Detect(map[string]BatchStats{"news": {Events: 100000}}, time.Minute), "suppressed within the cooldown")
+
+	*now = now.Add(5 * time.Minute)
+	assert.Len(t, d.Detect(map[string]BatchStats{"news": {Events: 1000000}}, time.Minute), 1)
+}
+
+func TestAnomalyDetector_ScalesToInterval(t *testing.T) {
+	d, _ := newTestDetector(structures.AnomaliesConfig{})
+	warmUp(d, map[string]BatchStats{"news": {Events: 100}})
+
+	// An early aggregation after 10s with a proportional count is no drop.
+	assert.Empty(t, d.Detect(map[string]BatchStats{"news": {Events: 17}}, 10*time.Second))
+}
+
+func TestAnomalyDetector_Items(t *testing.T) {
+	d, _ := newTestDetector(structures.AnomaliesConfig{TopItems: 1})
+	warmUp(d, map[string]BatchStats{"news": {Events: 1000, Items: map[int]int{1: 100, 2: 60}}})
+
+	require.Contains(t, d.channels["news"].items, 1)
+	assert.NotContains(t, d.channels["news"].items, 2, "not among the top items")
+
+	alerts := d.Detect(map[string]BatchStats{"news": {Events: 1000, Items: map[int]int{1: 1000}}}, time.Minute)
+	require.Len(t, alerts, 1)
+	require.NotNil(t, alerts[0].Item)
+	assert.Equal(t, 1, *alerts[0].Item)
+	assert.Equal(t, AlertSpike, alerts[0].Kind)
+	assert.Contains(t, alerts[0].ID, "news:1:spike")
+
+	// Item 1 leaves the top, but keeps its baseline until it fades.
+	for i := 0; i < 10; i++ {
+		d.Detect(map[string]BatchStats{"news": {Events: 1000, Items: map[int]int{3: 500}}}, time.Minute)
+	}
+	assert.NotContains(t, d.channels["news"].items, 1)
+	assert.Contains(t, d.channels["news"].items, 3)
+}
+
+func TestAnomalyDetector_Alerts(t *testing.T) {
+	d, now := newTestDetector(structures.AnomaliesConfig{MaxAlerts: 2})
+	warmUp(d, map[string]BatchStats{"a": {Events: 100}, "b": {Events: 100}, "c": {Events: 100}})
+
+	d.Detect(map[string]BatchStats{"a": {Events: 10000}, "b": {Events: 10000}, "c": {Events: 100}}, time.Minute)
+	*now = now.Add(time.Minute)
+	d.Detect(map[string]BatchStats{"a": {Events: 10000}, "b": {Events: 10000}, "c": {Events: 10000}}, time.Minute)
+
+	all := d.Alerts("")
+	require.Len(t, all, 2, "limited to maxAlerts")
+	assert.Equal(t, "c", all[0].Channel, "newest first")
+	assert.Equal(t, "b", all[1].Channel)
+
+	assert.Empty(t, d.Alerts("a"))
+	assert.Len(t, d.Alerts("b"), 1)
+}
+
+func TestAnomalyDetector_Disabled(t *testing.T) {
+	d := NewAnomalyDetector(&structures.Config{})
+
+	assert.Nil(t, d.Detect(map[string]BatchStats{"news": {Events: 1}}, time.Minute))
+	assert.Nil(t, d.Alerts(""))
+}
+
+func TestStatisticService_GetLastBatch(t *testing.T) {
+	ss := NewStatisticService(&structures.Config{Anomalies: structures.AnomaliesConfig{Enabled: true}})
+	assert.Nil(t, ss.GetLastBatch())
+
+	ss.AddStats(&models.InputStats{Views: []string{"1", "2"}, Channel: "news"})
+	ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: "news"})
+	ss.AddStats(&models.InputStats{Clicks: []string{"1"}})
+	ss.AggregateStats()
+
+	batches := ss.GetLastBatch()
+	assert.Equal(t, BatchStats{Events: 2, Items: map[int]int{1: 2, 2: 1}}, batches["news"])
+	assert.Equal(t, 1, batches[DefaultChannel].Events)
+	assert.Empty(t, batches[DefaultChannel].Items)
+
+	ss.AggregateStats()
+	assert.Empty(t, ss.GetLastBatch(), "channels without events are missing")
+}
@@ -0,0 +1,39 @@
+package services
+
+import "ssd/internal/models"
+
+// GetFilteredStatistic returns the channel's trend records whose catalog
+// metadata matches filter. The records are shared with the read view.
+func (ss *StatisticService) GetFilteredStatistic(channel string, filter models.CatalogFilter) map[int]*models.StatRecord {
+	v := ss.channelView(channel)
+	if v == nil {
+		return nil
+	}
+	return v.catalog.FilterRecords(filter, v.trend)
+}
+
+func (ss *StatisticService) GetCatalogItem(channel string, id int) (*models.CatalogItem, bool) {
+	if v := ss.channelView(channel); v != nil {
+		return v.catalog.Get(id)
+	}
+	return nil, false
+}
+
+// UpdateCatalog upserts and removes catalog items of a channel. The catalog is
+// synchronized itself and shared with the read view, so only a newly created channel is published.
+func (ss *StatisticService) UpdateCatalog(channel string, upsert map[int]*models.CatalogItem, remove []int) {
+	ss.writeMu.Lock()
+	defer ss.writeMu.Unlock()
+
+	ch := ss.getOrCreateChannel(channel)
+	if ch == nil {
+		return
+	}
+	ch.catalog.Update(upsert, remove)
+
+	changes := map[string]*channelChanges{}
+	if ss.channelView(channel) == nil {
+		changes[channel] = &channelChanges{full: true}
+	}
+	ss.publishWithinBudget(changes)
+}
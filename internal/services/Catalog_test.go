@@ -0,0 +1,108 @@
+package services
+
+import (
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedCatalog(ss *StatisticService) {
+	published := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	ss.UpdateCatalog(DefaultChannel, map[int]*models.CatalogItem{
+		1: {Category: "sports", Tags: []string{"football"}, PublishedAt: &published},
+		2: {Category: "politics"},
+		3: {Category: "sports", Tags: []string{"tennis"}},
+	}, nil)
+}
+
+func TestFilteredStatistic_ByCategoryAndTag(t *testing.T) {
+	ss := newService()
+	seedViews(ss, 1, 2, 3, 4)
+	ss.AggregateStats()
+	seedCatalog(ss)
+
+	sports := ss.GetFilteredStatistic(DefaultChannel, models.CatalogFilter{Category: "sports"})
+	assert.Len(t, sports, 2)
+	assert.Equal(t, 3, sports[3].Views)
+
+	tennis := ss.GetFilteredStatistic(DefaultChannel, models.CatalogFilter{Tag: "tennis"})
+	assert.Len(t, tennis, 1)
+	assert.Contains(t, tennis, 3)
+
+	recent := ss.GetFilteredStatistic(DefaultChannel, models.CatalogFilter{PublishedAfter: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)})
+	assert.Len(t, recent, 1)
+	assert.Contains(t, recent, 1)
+
+	// item 4 has no catalog entry and only shows up unfiltered
+	assert.Len(t, ss.GetFilteredStatistic(DefaultChannel, models.CatalogFilter{}), 4)
+	assert.Nil(t, ss.GetFilteredStatistic("missing", models.CatalogFilter{Category: "sports"}))
+}
+
+func TestFilteredStatistic_SharesRecordsWithView(t *testing.T) {
+	ss := newService()
+	seedViews(ss, 1)
+	ss.AggregateStats()
+	seedCatalog(ss)
+
+	filtered := ss.GetFilteredStatistic(DefaultChannel, models.CatalogFilter{Category: "sports"})
+	assert.Same(t, ss.GetStatistic(DefaultChannel)[1], filtered[1])
+}
+
+func TestFeed_CatalogFilter(t *testing.T) {
+	ss := newService()
+	seedViews(ss, 1, 5, 3)
+	ss.AggregateStats()
+	seedCatalog(ss)
+
+	feed := ss.GetFeed(DefaultChannel, "fp1", 10, models.CatalogFilter{Category: "sports"})
+	assert.Equal(t, []int{3, 1}, feedIDs(feed))
+}
+
+func TestUpdateCatalog_UpsertAndRemove(t *testing.T) {
+	ss := newService()
+	seedCatalog(ss)
+	ss.UpdateCatalog(DefaultChannel, map[int]*models.CatalogItem{2: {Category: "tech"}}, []int{3})
+
+	item, ok := ss.GetCatalogItem(DefaultChannel, 2)
+	require.True(t, ok)
+	assert.Equal(t, "tech", item.Category)
+	_, ok = ss.GetCatalogItem(DefaultChannel, 3)
+	assert.False(t, ok)
+}
+
+func TestUpdateCatalog_NewChannelPublished(t *testing.T) {
+	ss := newService()
+	ss.UpdateCatalog("news", map[int]*models.CatalogItem{1: {Category: "sports"}}, nil)
+
+	assert.Contains(t, ss.GetChannels(), "news")
+	_, ok := ss.GetCatalogItem("news", 1)
+	assert.True(t, ok)
+	_, ok = ss.GetCatalogItem("missing", 1)
+	assert.False(t, ok)
+}
+
+func TestCatalog_SnapshotRoundTrip(t *testing.T) {
+	ss := newService()
+	seedCatalog(ss)
+
+	data := ss.GetSnapshot().Channels[DefaultChannel]
+	require.Len(t, data.Catalog, 3)
+
+	restored := NewStatisticService(&structures.Config{})
+	restored.PutChannelData(DefaultChannel, data)
+	item, ok := restored.GetCatalogItem(DefaultChannel, 1)
+	require.True(t, ok)
+	assert.Equal(t, []string{"football"}, item.Tags)
+}
+
+func TestCatalog_CountedInMemoryStats(t *testing.T) {
+	ss := newService()
+	before := ss.GetMemoryStats().UsedBytes
+	seedCatalog(ss)
+
+	assert.Greater(t, ss.GetMemoryStats().UsedBytes, before)
+}
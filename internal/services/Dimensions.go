@@ -0,0 +1,45 @@
+package services
+
+import "ssd/internal/models"
+
+// allowedDimensions returns the dimension whitelist of a channel: its own
+// entry in dimensions.channels, or dimensions.allowed otherwise.
+func (ss *StatisticService) allowedDimensions(channel string) []string {
+	if allowed, ok := ss.dimensions.Channels[channel]; ok {
+		return allowed
+	}
+	return ss.dimensions.Allowed
+}
+
+// GetDimensionStatistic returns the trend records counted under one dimension
+// value, optionally narrowed by a catalog filter.
+func (ss *StatisticService) GetDimensionStatistic(channel string, dim models.DimKey, filter models.CatalogFilter) map[int]*models.StatRecord {
+	v := ss.channelView(channel)
+	if v == nil {
+		return nil
+	}
+	records := v.dims[dim.Name][dim.Value]
+	if records == nil {
+		return nil
+	}
+	return v.catalog.FilterRecords(filter, records)
+}
+
+// GetBreakdown returns an item's record under every value of a dimension.
+func (ss *StatisticService) GetBreakdown(channel string, id int, dim string) map[string]*models.StatRecord {
+	v := ss.channelView(channel)
+	if v == nil {
+		return nil
+	}
+	values := v.dims[dim]
+	if values == nil {
+		return nil
+	}
+	out := make(map[string]*models.StatRecord, len(values))
+	for value, records := range values {
+		if rec, ok := records[id]; ok {
+			out[value] = rec
+		}
+	}
+	return out
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"fmt"
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDimensionService(dims structures.DimensionsConfig) *StatisticService {
+	return NewStatisticService(&structures.Config{Dimensions: dims}).(*StatisticService)
+}
+
+func addDimView(ss *StatisticService, channel, id string, dims map[string]string) {
+	ss.AddStats(&models.InputStats{Views: []string{id}, Channel: channel, Dims: dims})
+}
+
+func TestDimensions_DisabledWithoutWhitelist(t *testing.T) {
+	ss := newService()
+	addDimView(ss, "", "1", map[string]string{"device": "mobile"})
+	ss.AggregateStats()
+
+	assert.Nil(t, ss.GetDimensionStatistic(DefaultChannel, models.DimKey{Name: "device", Value: "mobile"}, models.CatalogFilter{}))
+	assert.Nil(t, ss.GetBreakdown(DefaultChannel, 1, "device"))
+	assert.Equal(t, 1, ss.GetStatistic(DefaultChannel)[1].Views)
+}
+
+func TestDimensions_ListAndBreakdown(t *testing.T) {
+	ss := newDimensionService(structures.DimensionsConfig{Allowed: []string{"device"}})
+	addDimView(ss, "", "1", map[string]string{"device": "mobile"})
+	addDimView(ss, "", "1", map[string]string{"device": "mobile"})
+	addDimView(ss, "", "1", map[string]string{"device": "desktop"})
+	addDimView(ss, "", "2", map[string]string{"device": "mobile"})
+	ss.AggregateStats()
+
+	mobile := ss.GetDimensionStatistic(DefaultChannel, models.DimKey{Name: "device", Value: "mobile"}, models.CatalogFilter{})
+	require.Len(t, mobile, 2)
+	assert.Equal(t, 2, mobile[1].Views)
+
+	breakdown := ss.GetBreakdown(DefaultChannel, 1, "device")
+	assert.Equal(t, 2, breakdown["mobile"].Views)
+	assert.Equal(t, 1, breakdown["desktop"].Views)
+	assert.Equal(t, 3, ss.GetStatistic(DefaultChannel)[1].Views)
+}
+
+func TestDimensions_PerChannelWhitelist(t *testing.T) {
+	ss := newDimensionService(structures.DimensionsConfig{
+		Allowed:  []string{"device"},
+		Channels: map[string][]string{"news": {"country"}, "raw": {}},
+	})
+	dims := map[string]string{"device": "mobile", "country": "de"}
+	addDimView(ss, "news", "1", dims)
+	addDimView(ss, "blog", "1", dims)
+	addDimView(ss, "raw", "1", dims)
+	ss.AggregateStats()
+
+	assert.NotNil(t, ss.GetBreakdown("news", 1, "country")["de"])
+	assert.Empty(t, ss.GetBreakdown("news", 1, "device"))
+	assert.NotNil(t, ss.GetBreakdown("blog", 1, "device")["mobile"])
+	assert.Nil(t, ss.GetBreakdown("raw", 1, "device"))
+}
+
+func TestDimensions_CatalogFilter(t *testing.T) {
+	ss := newDimensionService(structures.DimensionsConfig{Allowed: []string{"device"}})
+	addDimView(ss, "", "1", map[string]string{"device": "mobile"})
+	addDimView(ss, "", "2", map[string]string{"device": "mobile"})
+	ss.AggregateStats()
+	ss.UpdateCatalog(DefaultChannel, map[int]*models.CatalogItem{2: {Category: "sports"}}, nil)
+
+	got := ss.GetDimensionStatistic(DefaultChannel, models.DimKey{Name: "device", Value: "mobile"}, models.CatalogFilter{Category: "sports"})
+	assert.Len(t, got, 1)
+	assert.Contains(t, got, 2)
+}
+
+func TestDimensions_IncrementalViewSharesUntouchedValues(t *testing.T) {
+	ss := newDimensionService(structures.DimensionsConfig{Allowed: []string{"device", "country"}})
+	addDimView(ss, "", "1", map[string]string{"device": "mobile", "country": "de"})
+	ss.AggregateStats()
+	before := ss.GetDimensionStatistic(DefaultChannel, models.DimKey{Name: "country", Value: "de"}, models.CatalogFilter{})
+
+	addDimView(ss, "", "1", map[string]string{"device": "mobile"})
+	ss.AggregateStats()
+
+	after := ss.GetDimensionStatistic(DefaultChannel, models.DimKey{Name: "country", Value: "de"}, models.CatalogFilter{})
+	assert.Same(t, before[1], after[1])
+	assert.Equal(t, 2, ss.GetBreakdown(DefaultChannel, 1, "device")["mobile"].Views)
+}
+
+func TestDimensions_SnapshotRoundTrip(t *testing.T) {
+	conf := structures.DimensionsConfig{Allowed: []string{"device"}}
+	ss := newDimensionService(conf)
+	addDimView(ss, "", "1", map[string]string{"device": "mobile"})
+	ss.AggregateStats()
+
+	data := ss.GetSnapshot().Channels[DefaultChannel]
+	require.NotNil(t, data.Dims)
+
+	restored := newDimensionService(conf)
+	restored.PutChannelData(DefaultChannel, data)
+	assert.Equal(t, 1, restored.GetBreakdown(DefaultChannel, 1, "device")["mobile"].Views)
+}
+
+func TestDimensions_EvictedWithItems(t *testing.T) {
+	ss := NewStatisticService(&structures.Config{
+		Dimensions: structures.DimensionsConfig{Allowed: []string{"device"}},
+		Memory:     structures.MemoryConfig{MaxBytes: 4 * 1024, Eviction: EvictItems},
+	}).(*StatisticService)
+	for i := 0; i < 200; i++ {
+		addDimView(ss, "", fmt.Sprint(i), map[string]string{"device": "mobile"})
+	}
+	ss.AggregateStats()
+
+	mem := ss.GetMemoryStats()
+	assert.LessOrEqual(t, mem.UsedBytes, mem.MaxBytes)
+	assert.Positive(t, mem.EvictedItems)
+}
@@ -0,0 +1,211 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"ssd/internal/models"
+)
+
+// controlVariant is treated as the baseline when an experiment has it;
+// otherwise the alphabetically first variant is.
+const controlVariant = "control"
+
+// ExperimentReport compares the variants of one experiment, either over all
+// items or over the requested ones.
+type ExperimentReport struct {
+	ID         string          `json:"id"`
+	Control    string          `json:"control"`
+	Confidence float64         `json:"confidence"`
+	Variants   []VariantReport `json:"variants"`
+}
+
+// VariantReport holds a variant's click-through rate with its Wilson score
+// interval and, for non-control variants, a two-proportion z-test against
+// the control.
+type VariantReport struct {
+	Variant     string  `json:"variant"`
+	Impressions int64   `json:"impressions"`
+	Clicks      int64   `json:"clicks"`
+	CTR         float64 `json:"ctr"`
+	CILow       float64 `json:"ci_low"`
+	CIHigh      float64 `json:"ci_high"`
+	Lift        float64 `json:"lift,omitempty"`
+	ZScore      float64 `json:"z_score,omitempty"`
+	PValue      float64 `json:"p_value,omitempty"`
+	Significant bool    `json:"significant"`
+}
+
+func updateExperimentsView(ch *channelData, old map[string]map[string]map[int]models.VariantCounts, touched map[string]struct{}) map[string]map[string]map[int]models.VariantCounts {
+	if ch.experiments == nil || len(touched) == 0 {
+		return old
+	}
+	experiments := make(map[string]map[string]map[int]models.VariantCounts, len(old)+len(touched))
+	for id, variants := range old {
+		experiments[id] = variants
+	}
+	for id := range touched {
+		if variants, ok := ch.experiments.Get(id); ok {
+			experiments[id] = variants
+		} else {
+			delete(experiments, id)
+		}
+	}
+	return experiments
+}
+
+// expireExperiments retires experiments past experiments.ttl and marks them
+// for removal from the read view. Callers must hold writeMu.
+func (ss *StatisticService) expireExperiments(changes map[string]*channelChanges) {
+	ss.chMu.RLock()
+	defer ss.chMu.RUnlock()
+	for name, ch := range ss.channels {
+		if ch.experiments == nil {
+			continue
+		}
+		retired := ch.experiments.Expire()
+		if len(retired) == 0 {
+			continue
+		}
+		c, ok := changes[name]
+		if !ok {
+			c = newChannelChanges()
+			changes[name] = c
+		}
+		if c.experiments == nil {
+			c.experiments = make(map[string]struct{}, len(retired))
+		}
+		for _, id := range retired {
+			c.experiments[id] = struct{}{}
+		}
+	}
+}
+
+// experimentsSeen returns when a channel's experiments last had events, for
+// persistence. Like journeys they are not part of the read view.
+func (ss *StatisticService) experimentsSeen(channel string) map[string]uint32 {
+	ss.chMu.RLock()
+	ch, ok := ss.channels[channel]
+	ss.chMu.RUnlock()
+	if !ok || ch.experiments == nil {
+		return nil
+	}
+	return ch.experiments.Seen()
+}
+
+// GetExperimentRejects returns the experiment assignments not counted in any
+// channel because of the experiment or variant caps.
+func (ss *StatisticService) GetExperimentRejects() int64 {
+	ss.chMu.RLock()
+	defer ss.chMu.RUnlock()
+	var n int64
+	for _, ch := range ss.channels {
+		if ch.experiments != nil {
+			n += ch.experiments.Rejected()
+		}
+	}
+	return n
+}
+
+// GetExperiment reports an experiment's variants, summing the counts of the
+// given items or of all items when items is empty. It returns nil for an
+// unknown experiment.
+func (ss *StatisticService) GetExperiment(channel, id string, items []int) *ExperimentReport {
+	v := ss.channelView(channel)
+	if v == nil {
+		return nil
+	}
+	variants, ok := v.experiments[id]
+	if !ok {
+		return nil
+	}
+
+	totals := make(map[string]models.VariantCounts, len(variants))
+	names := make([]string, 0, len(variants))
+	for name, counts := range variants {
+		var sum models.VariantCounts
+		if len(items) == 0 {
+			for _, c := range counts {
+				sum.Views += c.Views
+				sum.Clicks += c.Clicks
+			}
+		} else {
+			for _, item := range items {
+				sum.Views += counts[item].Views
+				sum.Clicks += counts[item].Clicks
+			}
+		}
+		totals[name] = sum
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	control := names[0]
+	if _, ok := totals[controlVariant]; ok {
+		control = controlVariant
+	}
+
+	alpha := ss.experiments.Alpha
+	z := math.Sqrt2 * math.Erfinv(1-alpha)
+	report := &ExperimentReport{
+		ID:         id,
+		Control:    control,
+		Confidence: 1 - alpha,
+		Variants:   make([]VariantReport, 0, len(names)),
+	}
+	base := totals[control]
+	for _, name := range names {
+		c := totals[name]
+		vr := VariantReport{Variant: name, Impressions: c.Views, Clicks: c.Clicks}
+		vr.CTR, vr.CILow, vr.CIHigh = wilsonInterval(c, z)
+		if name != control {
+			var tested bool
+			vr.ZScore, vr.PValue, tested = twoProportionTest(base, c)
+			vr.Significant = tested && vr.PValue < alpha
+			if baseCTR, _, _ := wilsonInterval(base, z); baseCTR > 0 {
+				vr.Lift = (vr.CTR - baseCTR) / baseCTR
+			}
+		}
+		report.Variants = append(report.Variants, vr)
+	}
+	return report
+}
+
+// ctr returns clicks per impression, capped at 1: clicks may be reported
+// without a matching view.
+func ctr(c models.VariantCounts) float64 {
+	if c.Views <= 0 {
+		return 0
+	}
+	return min(float64(c.Clicks)/float64(c.Views), 1)
+}
+
+// wilsonInterval returns the CTR and its Wilson score interval for the
+// critical value z. Unlike the normal approximation it stays within [0, 1]
+// and behaves for small samples and rates near 0.
+func wilsonInterval(c models.VariantCounts, z float64) (p, low, high float64) {
+	if c.Views <= 0 {
+		return 0, 0, 0
+	}
+	n := float64(c.Views)
+	p = ctr(c)
+	z2 := z * z
+	center := (p + z2/(2*n)) / (1 + z2/n)
+	margin := z / (1 + z2/n) * math.Sqrt(p*(1-p)/n+z2/(4*n*n))
+	return p, max(center-margin, 0), min(center+margin, 1)
+}
+
+// twoProportionTest returns the pooled two-proportion z statistic of b
+// against a and its two-sided p-value. It reports false when the test is
+// undefined: either sample is empty or the pooled rate is 0 or 1.
+func twoProportionTest(a, b models.VariantCounts) (z, p float64, ok bool) {
+	if a.Views <= 0 || b.Views <= 0 {
+		return 0, 0, false
+	}
+	na, nb := float64(a.Views), float64(b.Views)
+	pooled := (ctr(a)*na + ctr(b)*nb) / (na + nb)
+	se := math.Sqrt(pooled * (1 - pooled) * (1/na + 1/nb))
+	if se == 0 {
+		return 0, 0, false
+	}
+	z = (ctr(b) - ctr(a)) / se
+	return z, math.Erfc(math.Abs(z) / math.Sqrt2), true
+}
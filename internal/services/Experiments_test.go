@@ -0,0 +1,187 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newExperimentService() *StatisticService {
+	return NewStatisticService(&structures.Config{Experiments: structures.ExperimentsConfig{Enabled: true}}).(*StatisticService)
+}
+
+// addExperimentEvents records views of item with the given number of clicks
+// under one experiment variant.
+func addExperimentEvents(ss *StatisticService, channel, variant string, item, views, clicks int) {
+	id := fmt.Sprint(item)
+	for i := range views {
+		ev := &models.InputStats{Views: []string{id}, Channel: channel, Experiments: map[string]string{"headline": variant}}
+		if i < clicks {
+			ev.Clicks = []string{id}
+		}
+		ss.AddStats(ev)
+	}
+}
+
+func TestExperiments_DisabledByDefault(t *testing.T) {
+	ss := newService()
+	addExperimentEvents(ss, "", "a", 1, 10, 1)
+	ss.AggregateStats()
+
+	assert.Nil(t, ss.GetExperiment(DefaultChannel, "headline", nil))
+}
+
+func TestExperiments_Report(t *testing.T) {
+	ss := newExperimentService()
+	addExperimentEvents(ss, "", "control", 1, 1000, 50)
+	addExperimentEvents(ss, "", "b", 1, 1000, 90)
+	addExperimentEvents(ss, "", "c", 1, 1000, 52)
+	ss.AggregateStats()
+
+	report := ss.GetExperiment(DefaultChannel, "headline", nil)
+	require.NotNil(t, report)
+	assert.Equal(t, "control", report.Control)
+	assert.InDelta(t, 0.95, report.Confidence, 1e-9)
+	require.Len(t, report.Variants, 3)
+
+	byName := make(map[string]VariantReport)
+	for _, v := range report.Variants {
+		byName[v.Variant] = v
+	}
+	control := byName["control"]
+	assert.Equal(t, int64(1000), control.Impressions)
+	assert.Equal(t, int64(50), control.Clicks)
+	assert.InDelta(t, 0.05, control.CTR, 1e-9)
+	assert.Less(t, control.CILow, 0.05)
+	assert.Greater(t, control.CIHigh, 0.05)
+	assert.False(t, control.Significant)
+
+	b := byName["b"]
+	assert.True(t, b.Significant)
+	assert.InDelta(t, 0.8, b.Lift, 1e-9)
+	assert.Greater(t, b.ZScore, 2.0)
+	assert.Less(t, b.PValue, 0.05)
+
+	assert.False(t, byName["c"].Significant, "a 4% lift on this sample is noise")
+}
+
+func TestExperiments_WilsonIntervalMatchesReference(t *testing.T) {
+	// 10 clicks out of 100 at 95%: Wilson interval [0.0552, 0.1744].
+	p, low, high := wilsonInterval(models.VariantCounts{Views: 100, Clicks: 10}, 1.959963984540054)
+	assert.InDelta(t, 0.1, p, 1e-9)
+	assert.InDelta(t, 0.0552, low, 1e-4)
+	assert.InDelta(t, 0.1744, high, 1e-4)
+
+	_, low, high = wilsonInterval(models.VariantCounts{}, 1.96)
+	assert.Zero(t, low)
+	assert.Zero(t, high)
+}
+
+func TestExperiments_TwoProportionTest(t *testing.T) {
+	z, p, ok := twoProportionTest(models.VariantCounts{Views: 1000, Clicks: 50}, models.VariantCounts{Views: 1000, Clicks: 90})
+	require.True(t, ok)
+	assert.InDelta(t, 3.5055, z, 1e-3)
+	assert.InDelta(t, math.Erfc(z/math.Sqrt2), p, 1e-12)
+
+	_, _, ok = twoProportionTest(models.VariantCounts{Views: 10}, models.VariantCounts{Views: 10})
+	assert.False(t, ok, "zero pooled rate")
+	_, _, ok = twoProportionTest(models.VariantCounts{}, models.VariantCounts{Views: 10, Clicks: 1})
+	assert.False(t, ok, "empty control")
+}
+
+func TestExperiments_PerItemAndControlFallback(t *testing.T) {
+	ss := newExperimentService()
+	addExperimentEvents(ss, "", "b", 1, 10, 5)
+	addExperimentEvents(ss, "", "a", 1, 10, 1)
+	addExperimentEvents(ss, "", "a", 2, 30, 0)
+	ss.AggregateStats()
+
+	all := ss.GetExperiment(DefaultChannel, "headline", nil)
+	require.NotNil(t, all)
+	assert.Equal(t, "a", all.Control, "without a control variant the first name is the baseline")
+	assert.Equal(t, int64(40), all.Variants[0].Impressions)
+
+	item := ss.GetExperiment(DefaultChannel, "headline", []int{1})
+	assert.Equal(t, int64(10), item.Variants[0].Impressions)
+	assert.Equal(t, int64(1), item.Variants[0].Clicks)
+
+	assert.Nil(t, ss.GetExperiment(DefaultChannel, "missing", nil))
+}
+
+func TestExperiments_ChannelIsolationAndPersistence(t *testing.T) {
+	ss := newExperimentService()
+	addExperimentEvents(ss, "news", "a", 1, 5, 1)
+	ss.AggregateStats()
+	assert.Nil(t, ss.GetExperiment("blog", "headline", nil))
+
+	snap := ss.GetSnapshot()
+	require.NotNil(t, snap.Channels["news"].Experiments)
+
+	restored := newExperimentService()
+	restored.PutChannelData("news", snap.Channels["news"])
+	report := restored.GetExperiment("news", "headline", nil)
+	require.NotNil(t, report)
+	assert.Equal(t, int64(5), report.Variants[0].Impressions)
+
+	// Unchanged experiments are shared with the next view.
+	addExperimentEvents(restored, "news", "a", 2, 1, 0)
+	restored.AggregateStats()
+	assert.Equal(t, int64(6), restored.GetExperiment("news", "headline", nil).Variants[0].Impressions)
+}
+
+func TestExperiments_IdleExperimentsRetired(t *testing.T) {
+	ss := newExperimentService()
+	addExperimentEvents(ss, "", "a", 1, 10, 1)
+	ss.AggregateStats()
+	snap := ss.GetSnapshot().Channels[DefaultChannel]
+	require.Contains(t, snap.ExpSeen, "headline")
+
+	restored := NewStatisticService(&structures.Config{Experiments: structures.ExperimentsConfig{Enabled: true, TTL: time.Hour}}).(*StatisticService)
+	restored.PutChannelData(DefaultChannel, &models.ChannelData{
+		Experiments: snap.Experiments,
+		ExpSeen:     map[string]uint32{"headline": 1},
+	})
+	require.NotNil(t, restored.GetExperiment(DefaultChannel, "headline", nil))
+
+	restored.AggregateStats()
+	assert.Nil(t, restored.GetExperiment(DefaultChannel, "headline", nil))
+	assert.Empty(t, restored.GetSnapshot().Channels[DefaultChannel].Experiments)
+}
+
+func TestExperiments_RetiredWhileRisingTicks(t *testing.T) {
+	ss := newExperimentService()
+	addExperimentEvents(ss, "", "a", 1, 10, 1)
+	ss.AggregateStats()
+	snap := ss.GetSnapshot().Channels[DefaultChannel]
+
+	restored := NewStatisticService(&structures.Config{
+		Experiments: structures.ExperimentsConfig{Enabled: true, TTL: time.Hour},
+		Rising:      structures.RisingConfig{Enabled: true, Ticks: 3},
+	}).(*StatisticService)
+	restored.PutChannelData(DefaultChannel, &models.ChannelData{
+		Experiments: snap.Experiments,
+		ExpSeen:     map[string]uint32{"headline": 1},
+	})
+	restored.channels[DefaultChannel].rising.Observe(&models.InputStats{Views: []string{"1"}})
+
+	// No events in the batch, but the rising tick holds views, so the
+	// channel is republished for its tick and its experiment expires in the
+	// same run.
+	assert.NotPanics(t, func() { restored.AggregateStats() })
+	assert.Nil(t, restored.GetExperiment(DefaultChannel, "headline", nil))
+}
+
+func TestExperiments_RejectsCounted(t *testing.T) {
+	ss := NewStatisticService(&structures.Config{Experiments: structures.ExperimentsConfig{Enabled: true, MaxVariants: 1}}).(*StatisticService)
+	addExperimentEvents(ss, "", "a", 1, 1, 0)
+	addExperimentEvents(ss, "", "b", 1, 2, 0)
+	ss.AggregateStats()
+
+	assert.Equal(t, int64(2), ss.GetExperimentRejects())
+}
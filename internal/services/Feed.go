@@ -0,0 +1,94 @@
+package services
+
+import (
+	"sort"
+	"ssd/internal/models"
+)
+
+// FeedItem is one entry of a personalized trending feed.
+type FeedItem struct {
+	ID    int     `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// feedRanker scores a channel's trending items for one fingerprint. The base
+// score is the decayed view count; items the fingerprint already touched are
+// multiplied by seenWeight (0 drops them) and items related to its history
+// get up to relatedBoost added as a multiplier.
+type feedRanker struct {
+	seenWeight   float64
+	relatedBoost float64
+}
+
+// rank walks the view's items in descending view order and keeps the n best
+// scores among items matching filter. Boosts are bounded, so the walk stops as
+// soon as no remaining item can beat the current n-th score. Nothing in the
+// view is copied.
+func (fr feedRanker) rank(v *channelView, seen map[int]*models.StatRecord, n int, filter models.CatalogFilter) []FeedItem {
+	if n <= 0 {
+		return nil
+	}
+	affinity := fr.affinity(v, seen)
+	ceiling := 1.0
+	if len(affinity) > 0 {
+		ceiling += fr.relatedBoost
+	}
+
+	top := make([]FeedItem, 0, n)
+	for _, id := range v.rankedIDs() {
+		views := float64(v.trend[id].Views)
+		if len(top) == n && views*ceiling <= top[n-1].Score {
+			break
+		}
+		if !v.catalog.Matches(filter, id) {
+			continue
+		}
+		score := views * (1 + fr.relatedBoost*affinity[id])
+		if _, ok := seen[id]; ok {
+			score *= fr.seenWeight
+		}
+		if score <= 0 || (len(top) == n && score <= top[n-1].Score) {
+			continue
+		}
+		i := sort.Search(len(top), func(i int) bool { return top[i].Score < score })
+		if len(top) < n {
+			top = append(top, FeedItem{})
+		}
+		copy(top[i+1:], top[i:])
+		top[i] = FeedItem{ID: id, Score: score}
+	}
+	return top
+}
+
+// affinity sums the neighbor weights of every item in the fingerprint's
+// history and scales the sums into [0, 1].
+func (fr feedRanker) affinity(v *channelView, seen map[int]*models.StatRecord) map[int]float64 {
+	if fr.relatedBoost <= 0 || len(v.related) == 0 || len(seen) == 0 {
+		return nil
+	}
+	sums := make(map[int]float64)
+	peak := 0.0
+	for id := range seen {
+		for _, nb := range v.related[id] {
+			sums[nb.ID] += float64(nb.Weight)
+			peak = max(peak, sums[nb.ID])
+		}
+	}
+	for id := range sums {
+		sums[id] /= peak
+	}
+	return sums
+}
+
+// GetFeed returns up to n trending items of a channel ranked for fp.
+func (ss *StatisticService) GetFeed(channel, fp string, n int, filter models.CatalogFilter) []FeedItem {
+	v := ss.channelView(channel)
+	if v == nil {
+		return nil
+	}
+	var seen map[int]*models.StatRecord
+	if stat, ok := v.personal[fp]; ok && fp != "" {
+		seen = stat.Data
+	}
+	return ss.feed.rank(v, seen, n, filter)
+}
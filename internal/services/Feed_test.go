@@ -0,0 +1,99 @@
+package services
+
+import (
+	"fmt"
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFeedService(feed structures.FeedConfig, related bool) *StatisticService {
+	return NewStatisticService(&structures.Config{
+		Feed:    feed,
+		Related: structures.RelatedConfig{Enabled: related},
+	}).(*StatisticService)
+}
+
+// seedViews adds views[i] anonymous views of item i+1.
+func seedViews(ss *StatisticService, views ...int) {
+	for i, n := range views {
+		for j := 0; j < n; j++ {
+			ss.AddStats(&models.InputStats{Views: []string{fmt.Sprint(i + 1)}})
+		}
+	}
+}
+
+func feedIDs(items []FeedItem) []int {
+	ids := make([]int, len(items))
+	for i, v := range items {
+		ids[i] = v.ID
+	}
+	return ids
+}
+
+func TestFeed_RanksByViews(t *testing.T) {
+	ss := newFeedService(structures.FeedConfig{}, false)
+	seedViews(ss, 1, 5, 3)
+	ss.AggregateStats()
+
+	feed := ss.GetFeed(DefaultChannel, "fp1", 10, models.CatalogFilter{})
+	assert.Equal(t, []int{2, 3, 1}, feedIDs(feed))
+	assert.Equal(t, 5.0, feed[0].Score)
+	assert.Equal(t, []int{2, 3}, feedIDs(ss.GetFeed(DefaultChannel, "fp1", 2, models.CatalogFilter{})))
+}
+
+func TestFeed_ExcludesSeenItems(t *testing.T) {
+	ss := newFeedService(structures.FeedConfig{}, false)
+	seedViews(ss, 1, 5, 3)
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"2"}})
+	ss.AggregateStats()
+
+	assert.Equal(t, []int{3, 1}, feedIDs(ss.GetFeed(DefaultChannel, "fp1", 10, models.CatalogFilter{})))
+	assert.Equal(t, []int{2, 3, 1}, feedIDs(ss.GetFeed(DefaultChannel, "fp2", 10, models.CatalogFilter{})))
+}
+
+func TestFeed_DownWeightsSeenItems(t *testing.T) {
+	ss := newFeedService(structures.FeedConfig{SeenWeight: 0.25}, false)
+	seedViews(ss, 1, 5, 3)
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"2"}})
+	ss.AggregateStats()
+
+	feed := ss.GetFeed(DefaultChannel, "fp1", 10, models.CatalogFilter{})
+	assert.Equal(t, []int{3, 2, 1}, feedIDs(feed))
+	assert.Equal(t, 1.5, feed[1].Score)
+}
+
+func TestFeed_BoostsRelatedItems(t *testing.T) {
+	ss := newFeedService(structures.FeedConfig{RelatedBoost: 1}, true)
+	seedViews(ss, 0, 0, 4, 3)
+	// other readers of item 1 also read item 4
+	ss.AddStats(&models.InputStats{Fingerprint: "a", Views: []string{"1", "4"}})
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"1"}})
+	ss.AggregateStats()
+
+	feed := ss.GetFeed(DefaultChannel, "fp1", 10, models.CatalogFilter{})
+	require.Len(t, feed, 2)
+	assert.Equal(t, 4, feed[0].ID)
+	assert.Equal(t, 8.0, feed[0].Score)
+	assert.Equal(t, 3, feed[1].ID)
+}
+
+func TestFeed_AnonymousHasNoHistory(t *testing.T) {
+	ss := newFeedService(structures.FeedConfig{}, false)
+	seedViews(ss, 2, 1)
+	ss.AggregateStats()
+
+	assert.Equal(t, []int{1, 2}, feedIDs(ss.GetFeed(DefaultChannel, "", 10, models.CatalogFilter{})))
+}
+
+func TestFeed_UnknownChannelAndZeroLimit(t *testing.T) {
+	ss := newFeedService(structures.FeedConfig{}, false)
+	seedViews(ss, 1)
+	ss.AggregateStats()
+
+	assert.Nil(t, ss.GetFeed("missing", "fp1", 10, models.CatalogFilter{}))
+	assert.Nil(t, ss.GetFeed(DefaultChannel, "fp1", 0, models.CatalogFilter{}))
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"ssd/internal/models"
+	"time"
+)
+
+const (
+	defaultFunnelWindow = 30 * time.Minute
+	// maxFunnelSteps bounds funnel length; journeys store their step in a byte.
+	maxFunnelSteps = 32
+)
+
+// FunnelReport gives the step counts and conversion rates of a funnel over
+// all items, and per item for the requested ones.
+type FunnelReport struct {
+	Name   string               `json:"name"`
+	Window string               `json:"window"`
+	Steps  []FunnelStep         `json:"steps"`
+	Items  map[int][]FunnelStep `json:"items,omitempty"`
+}
+
+// FunnelStep is the number of journeys that reached a step. Conversion is
+// relative to the previous step, Overall to the first.
+type FunnelStep struct {
+	Step       string  `json:"step"`
+	Count      int64   `json:"count"`
+	Conversion float64 `json:"conversion"`
+	Overall    float64 `json:"overall"`
+}
+
+// newChannelFunnels creates the funnels configured for a channel. Funnels
+// without a name, with fewer than two or more than maxFunnelSteps steps, or
+// with a duplicate name are skipped.
+func (ss *StatisticService) newChannelFunnels(channel string) map[string]*models.FunnelStats {
+	var funnels map[string]*models.FunnelStats
+	for _, conf := range ss.funnels[channel] {
+		if conf.Name == "" || len(conf.Steps) < 2 || len(conf.Steps) > maxFunnelSteps {
+			continue
+		}
+		if _, dup := funnels[conf.Name]; dup {
+			continue
+		}
+		window := conf.Window
+		if window <= 0 {
+			window = defaultFunnelWindow
+		}
+		if funnels == nil {
+			funnels = make(map[string]*models.FunnelStats)
+		}
+		funnels[conf.Name] = models.NewFunnelStats(conf.Steps, window)
+	}
+	return funnels
+}
+
+// expireFunnels drops journeys past their completion window. Callers must
+// hold writeMu.
+func (ss *StatisticService) expireFunnels() {
+	ss.chMu.RLock()
+	defer ss.chMu.RUnlock()
+	for _, ch := range ss.channels {
+		for _, f := range ch.funnels {
+			f.Expire()
+		}
+	}
+}
+
+// funnelSnapshot returns a channel's funnels for persistence. Journeys are
+// not part of the read view, so unlike the rest of the snapshot they are
+// copied from the model.
+func (ss *StatisticService) funnelSnapshot(channel string) map[string]*models.FunnelData {
+	ss.chMu.RLock()
+	ch, ok := ss.channels[channel]
+	ss.chMu.RUnlock()
+	if !ok || len(ch.funnels) == 0 {
+		return nil
+	}
+	out := make(map[string]*models.FunnelData, len(ch.funnels))
+	for name, f := range ch.funnels {
+		out[name] = f.GetData()
+	}
+	return out
+}
+
+func updateFunnelsView(ch *channelData, old map[string]map[int][]int64, touched map[string]struct{}) map[string]map[int][]int64 {
+	if len(touched) == 0 {
+		return old
+	}
+	funnels := make(map[string]map[int][]int64, len(ch.funnels))
+	for name, counts := range old {
+		funnels[name] = counts
+	}
+	for name := range touched {
+		funnels[name] = ch.funnels[name].Counts()
+	}
+	return funnels
+}
+
+// GetFunnel reports a funnel's step counts over all items and, for every
+// item in items, per item. It returns nil for an unknown funnel.
+func (ss *StatisticService) GetFunnel(channel, name string, items []int) *FunnelReport {
+	ss.chMu.RLock()
+	ch, ok := ss.channels[channel]
+	ss.chMu.RUnlock()
+	if !ok {
+		return nil
+	}
+	f, ok := ch.funnels[name]
+	if !ok {
+		return nil
+	}
+	v := ss.channelView(channel)
+	if v == nil {
+		return nil
+	}
+	counts := v.funnels[name]
+
+	steps := f.Steps()
+	total := make([]int64, len(steps))
+	for _, c := range counts {
+		for i, n := range c {
+			total[i] += n
+		}
+	}
+	report := &FunnelReport{
+		Name:   name,
+		Window: f.Window().String(),
+		Steps:  funnelSteps(steps, total),
+	}
+	if len(items) > 0 {
+		report.Items = make(map[int][]FunnelStep, len(items))
+		for _, id := range items {
+			c := counts[id]
+			if c == nil {
+				c = make([]int64, len(steps))
+			}
+			report.Items[id] = funnelSteps(steps, c)
+		}
+	}
+	return report
+}
+
+func funnelSteps(names []string, counts []int64) []FunnelStep {
+	out := make([]FunnelStep, len(names))
+	for i, name := range names {
+		out[i] = FunnelStep{Step: name, Count: counts[i]}
+		if i == 0 {
+			if counts[0] > 0 {
+				out[i].Conversion, out[i].Overall = 1, 1
+			}
+			continue
+		}
+		if counts[i-1] > 0 {
+			out[i].Conversion = float64(counts[i]) / float64(counts[i-1])
+		}
+		if counts[0] > 0 {
+			out[i].Overall = float64(counts[i]) / float64(counts[0])
+		}
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFunnelService() *StatisticService {
+	return NewStatisticService(&structures.Config{Funnels: map[string][]structures.FunnelConfig{
+		"news": {
+			{Name: "subscribe", Steps: []string{"view", "click", "subscribe"}, Window: time.Hour},
+			{Name: "short", Steps: []string{"view"}},
+			{Name: "subscribe", Steps: []string{"view", "click"}},
+		},
+	}}).(*StatisticService)
+}
+
+func TestFunnels_Report(t *testing.T) {
+	ss := newFunnelService()
+	// All steps arrive within one aggregation interval.
+	for _, fp := range []string{"a", "b", "c", "d"} {
+		ss.AddStats(&models.InputStats{Fingerprint: fp, Channel: "news", Views: []string{"1"}})
+	}
+	ss.AddStats(&models.InputStats{Fingerprint: "e", Channel: "news", Views: []string{"2"}})
+	ss.AddStats(&models.InputStats{Fingerprint: "a", Channel: "news", Clicks: []string{"1"}})
+	ss.AddStats(&models.InputStats{Fingerprint: "b", Channel: "news", Clicks: []string{"1"}})
+	ss.AddStats(&models.InputStats{Fingerprint: "e", Channel: "news", Clicks: []string{"2"}})
+	ss.AddStats(&models.InputStats{Fingerprint: "a", Channel: "news", Events: map[string][]string{"subscribe": {"1"}}})
+	ss.AggregateStats()
+
+	report := ss.GetFunnel("news", "subscribe", []int{1, 3})
+	require.NotNil(t, report)
+	assert.Equal(t, "1h0m0s", report.Window)
+	assert.Equal(t, []FunnelStep{
+		{Step: "view", Count: 5, Conversion: 1, Overall: 1},
+		{Step: "click", Count: 3, Conversion: 0.6, Overall: 0.6},
+		{Step: "subscribe", Count: 1, Conversion: 1.0 / 3, Overall: 0.2},
+	}, report.Steps)
+	assert.Equal(t, []FunnelStep{
+		{Step: "view", Count: 4, Conversion: 1, Overall: 1},
+		{Step: "click", Count: 2, Conversion: 0.5, Overall: 0.5},
+		{Step: "subscribe", Count: 1, Conversion: 0.5, Overall: 0.25},
+	}, report.Items[1])
+	assert.Equal(t, int64(0), report.Items[3][0].Count)
+	assert.Zero(t, report.Items[3][1].Conversion)
+}
+
+func TestFunnels_InvalidAndUnknown(t *testing.T) {
+	ss := newFunnelService()
+	ss.AddStats(&models.InputStats{Fingerprint: "a", Channel: "news", Views: []string{"1"}, Clicks: []string{"1"}})
+	ss.AddStats(&models.InputStats{Fingerprint: "a", Channel: "blog", Views: []string{"1"}})
+	ss.AggregateStats()
+
+	assert.Nil(t, ss.GetFunnel("news", "short", nil), "single-step funnels are skipped")
+	assert.Nil(t, ss.GetFunnel("news", "missing", nil))
+	assert.Nil(t, ss.GetFunnel("blog", "subscribe", nil), "funnels are per channel")
+	assert.Nil(t, ss.GetFunnel("nope", "subscribe", nil))
+	// The first definition of a duplicate name wins.
+	assert.Len(t, ss.GetFunnel("news", "subscribe", nil).Steps, 3)
+}
+
+func TestFunnels_Persistence(t *testing.T) {
+	ss := newFunnelService()
+	ss.AddStats(&models.InputStats{Fingerprint: "a", Channel: "news", Views: []string{"1"}})
+	ss.AggregateStats()
+
+	snap := ss.GetSnapshot()
+	require.NotNil(t, snap.Channels["news"].Funnels["subscribe"])
+	assert.Nil(t, snap.Channels[DefaultChannel].Funnels)
+
+	restored := newFunnelService()
+	restored.PutChannelData("news", snap.Channels["news"])
+	restored.AddStats(&models.InputStats{Fingerprint: "a", Channel: "news", Clicks: []string{"1"}})
+	restored.AggregateStats()
+
+	steps := restored.GetFunnel("news", "subscribe", nil).Steps
+	assert.Equal(t, int64(1), steps[0].Count)
+	assert.Equal(t, int64(1), steps[1].Count, "the restored journey advanced")
+}
+
+func TestFunnels_CountTowardsMemory(t *testing.T) {
+	ss := newFunnelService()
+	before := ss.GetMemoryStats().Channels["news"]
+	ss.AddStats(&models.InputStats{Fingerprint: "a", Channel: "news", Views: []string{"1"}})
+	ss.AggregateStats()
+
+	ch := ss.channels["news"]
+	assert.Greater(t, ss.GetMemoryStats().Channels["news"], before+ch.statistic.MemoryUsage()+ch.personalStats.MemoryUsage())
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"net/http"
+	"ssd/internal/models"
+)
+
+// Source describes the client an event came from, as far as its transport
+// knows it. Enrichers read it instead of a transport's request type, so every
+// transport is enriched the same way.
+type Source struct {
+	UserAgent    string
+	RemoteAddr   string   // direct peer as host:port or a bare address
+	ForwardedFor []string // X-Forwarded-For values of a proxied request
+	NoUserAgent  bool     // the transport cannot carry a user agent, like UDP
+}
+
+// RequestSource describes the client of an HTTP request.
+func RequestSource(r *http.Request) Source {
+	return Source{
+		UserAgent:    r.UserAgent(),
+		RemoteAddr:   r.RemoteAddr,
+		ForwardedFor: r.Header.Values("X-Forwarded-For"),
+	}
+}
+
+// Enricher inspects an event before it is recorded. Enrich reports false
+// when the event must be dropped.
+type Enricher interface {
+	Enrich(src Source, payload *models.InputStats) bool
+}
+
+// IngestServiceInterface is the one entry point of events from all
+// transports.
+type IngestServiceInterface interface {
+	Ingest(src Source, payload *models.InputStats) bool
+}
+
+type IngestService struct {
+	service   StatisticServiceInterface
+	enrichers []Enricher
+}
+
+// Ingest applies the channel default and the enrichers in order and records
+// the event. It reports false when an enricher dropped it.
+func (is *IngestService) Ingest(src Source, payload *models.InputStats) bool {
+	if payload.Channel == "" {
+		payload.Channel = DefaultChannel
+	}
+	for _, e := range is.enrichers {
+		if !e.Enrich(src, payload) {
+			return false
+		}
+	}
+	is.service.AddStats(payload)
+	return true
+}
+
+func NewIngestService(service StatisticServiceInterface, enrichers []Enricher) IngestServiceInterface {
+	return &IngestService{service: service, enrichers: enrichers}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"net/http/httptest"
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dropEnricher drops events of the user agent drop and records the sources
+// it saw.
+type dropEnricher struct {
+	drop string
+	seen []Source
+}
+
+func (e *dropEnricher) Enrich(src Source, _ *models.InputStats) bool {
+	e.seen = append(e.seen, src)
+	return src.UserAgent != e.drop
+}
+
+func TestIngest_DefaultsChannelAndRunsEnrichersInOrder(t *testing.T) {
+	ss := NewStatisticService(&structures.Config{})
+	first, second := &dropEnricher{drop: "bot"}, &dropEnricher{}
+	is := NewIngestService(ss, []Enricher{first, second})
+
+	payload := &models.InputStats{Views: []string{"1"}}
+	assert.True(t, is.Ingest(Source{UserAgent: "browser"}, payload))
+	assert.Equal(t, DefaultChannel, payload.Channel)
+
+	assert.False(t, is.Ingest(Source{UserAgent: "bot"}, &models.InputStats{Views: []string{"1"}}))
+	assert.Len(t, first.seen, 2)
+	assert.Len(t, second.seen, 1, "a dropped event skips later enrichers")
+	assert.Equal(t, 1, ss.GetBufferSize())
+}
+
+func TestRequestSource(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", nil)
+	req.RemoteAddr = "10.0.0.1:80"
+	req.Header.Set("User-Agent", "browser")
+	req.Header.Add("X-Forwarded-For", "81.2.3.4")
+	req.Header.Add("X-Forwarded-For", "10.1.1.1")
+
+	src := RequestSource(req)
+	require.Equal(t, "browser", src.UserAgent)
+	assert.Equal(t, "10.0.0.1:80", src.RemoteAddr)
+	assert.Equal(t, []string{"81.2.3.4", "10.1.1.1"}, src.ForwardedFor)
+	assert.False(t, src.NoUserAgent)
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"math"
+	"slices"
+	"ssd/internal/structures"
+	"sync/atomic"
+)
+
+const (
+	EvictItems        = "items"
+	EvictFingerprints = "fingerprints"
+
+	// evictionTarget is the fraction of memory.maxBytes eviction shrinks
+	// usage to, so that a store hovering at the limit is not evicted from
+	// on every aggregation.
+	evictionTarget = 0.9
+)
+
+// MemoryStats reports accounted memory and eviction totals. UsedBytes and
+// Channels include FixedBytes, held by stores eviction cannot shrink, and
+// ViewBytes, held by the published read views.
+type MemoryStats struct {
+	UsedBytes           int64            `json:"used_bytes"`
+	FixedBytes          int64            `json:"fixed_bytes"`
+	ViewBytes           int64            `json:"view_bytes"`
+	MaxBytes            int64            `json:"max_bytes"`
+	Channels            map[string]int64 `json:"channels"`
+	EvictedItems        int64            `json:"evicted_items"`
+	EvictedFingerprints int64            `json:"evicted_fingerprints"`
+}
+
+// memoryBudget enforces memory.maxBytes across all channels.
+type memoryBudget struct {
+	maxBytes            int64
+	order               []string
+	evictedItems        atomic.Int64
+	evictedFingerprints atomic.Int64
+}
+
+func newMemoryBudget(conf structures.MemoryConfig) *memoryBudget {
+	order := []string{EvictFingerprints, EvictItems}
+	if conf.Eviction == EvictItems {
+		order = []string{EvictItems, EvictFingerprints}
+	}
+	return &memoryBudget{maxBytes: conf.MaxBytes, order: order}
+}
+
+// evictable is the eviction surface shared by TrendStats and PersonalStats.
+type evictable interface {
+	AgeHistogram() map[uint32]int64
+	EvictBefore(stamp uint32, limit int64) (int, int64)
+}
+
+// evictableUsage returns the bytes of the stores eviction can shrink: the
+// trend, its dimension breakdowns and the fingerprints.
+func (cd *channelData) evictableUsage() int64 {
+	used := cd.statistic.MemoryUsage() + cd.personalStats.MemoryUsage()
+	if cd.dims != nil {
+		used += cd.dims.MemoryUsage()
+	}
+	return used
+}
+
+// fixedUsage returns the bytes of the stores that are bounded by their own
+// caps or expiry instead of eviction.
+func (cd *channelData) fixedUsage() int64 {
+	used := cd.catalog.MemoryUsage()
+	if cd.related != nil {
+		used += cd.related.MemoryUsage()
+	}
+	if cd.experiments != nil {
+		used += cd.experiments.MemoryUsage()
+	}
+	if cd.rising != nil {
+		used += cd.rising.MemoryUsage()
+	}
+	for _, f := range cd.funnels {
+		used += f.MemoryUsage()
+	}
+	return used
+}
+
+func (cd *channelData) store(kind string) evictable {
+	if kind != EvictItems {
+		return cd.personalStats
+	}
+	if cd.dims != nil {
+		return evictables{cd.statistic, cd.dims}
+	}
+	return cd.statistic
+}
+
+// evictables presents several stores of one kind as a single store.
+type evictables []evictable
+
+func (es evictables) AgeHistogram() map[uint32]int64 {
+	hist := make(map[uint32]int64)
+	for _, e := range es {
+		for stamp, size := range e.AgeHistogram() {
+			hist[stamp] += size
+		}
+	}
+	return hist
+}
+
+func (es evictables) EvictBefore(stamp uint32, limit int64) (int, int64) {
+	evicted := 0
+	var freed int64
+	for _, e := range es {
+		if freed >= limit {
+			break
+		}
+		n, f := e.EvictBefore(stamp, limit-freed)
+		evicted += n
+		freed += f
+	}
+	return evicted, freed
+}
+
+// viewBytes returns the bytes held by the published view of a channel.
+func (ss *StatisticService) viewBytes(name string) int64 {
+	if state := ss.view.Load(); state != nil {
+		if v, ok := state.channels[name]; ok {
+			return v.bytes
+		}
+	}
+	return 0
+}
+
+// publishWithinBudget publishes changes and, when that takes accounted usage
+// over memory.maxBytes, evicts and republishes the channels that lost data.
+// The views are measured after publishing because they are rebuilt from
+// the models. Callers must hold writeMu.
+func (ss *StatisticService) publishWithinBudget(changes map[string]*channelChanges) {
+	ss.publish(changes)
+	if evicted := ss.enforceMemoryBudget(); len(evicted) > 0 {
+		ss.publish(evicted)
+	}
+}
+
+// enforceMemoryBudget evicts least-recently-updated data once accounted usage
+// exceeds memory.maxBytes and returns the channels that lost data, marked
+// for a full read view rebuild. The read views shrink with the evicted data,
+// so each evicted byte is taken to free its share of them too. Eviction
+// stops once only fixed stores remain.
+func (ss *StatisticService) enforceMemoryBudget() map[string]*channelChanges {
+	if ss.memory.maxBytes <= 0 {
+		return nil
+	}
+
+	ss.chMu.RLock()
+	channels := make(map[string]*channelData, len(ss.channels))
+	for name, ch := range ss.channels {
+		channels[name] = ch
+	}
+	ss.chMu.RUnlock()
+
+	var evictable, fixed, views int64
+	for name, ch := range channels {
+		evictable += ch.evictableUsage()
+		fixed += ch.fixedUsage()
+		views += ss.viewBytes(name)
+	}
+	used := evictable + fixed + views
+	if used <= ss.memory.maxBytes || evictable == 0 {
+		return nil
+	}
+
+	scale := float64(evictable+views) / float64(evictable)
+	need := int64(float64(used-int64(float64(ss.memory.maxBytes)*evictionTarget)) / scale)
+	need = min(need, evictable)
+	changes := make(map[string]*channelChanges)
+	for _, kind := range ss.memory.order {
+		if need <= 0 {
+			break
+		}
+		need -= ss.evict(channels, kind, need, changes)
+	}
+	return changes
+}
+
+// evict frees about need bytes of the given kind, oldest update stamps first,
+// and returns the number of bytes freed.
+func (ss *StatisticService) evict(channels map[string]*channelData, kind string, need int64, changes map[string]*channelChanges) int64 {
+	ages := make(map[uint32]int64)
+	for _, ch := range channels {
+		for stamp, size := range ch.store(kind).AgeHistogram() {
+			ages[stamp] += size
+		}
+	}
+	if len(ages) == 0 {
+		return 0
+	}
+
+	// Find the newest stamp that still has to go: everything older is
+	// removed outright, the cutoff stamp itself only until need is met.
+	stamps := make([]uint32, 0, len(ages))
+	for stamp := range ages {
+		stamps = append(stamps, stamp)
+	}
+	slices.Sort(stamps)
+	cutoff := stamps[len(stamps)-1]
+	var older int64
+	for _, stamp := range stamps {
+		if older+ages[stamp] >= need {
+			cutoff = stamp
+			break
+		}
+		older += ages[stamp]
+	}
+
+	var freed int64
+	count := 0
+	evictFrom := func(name string, ch *channelData, stamp uint32, limit int64) {
+		n, f := ch.store(kind).EvictBefore(stamp, limit)
+		if n > 0 {
+			changes[name] = &channelChanges{full: true}
+			count += n
+			freed += f
+		}
+	}
+	for name, ch := range channels {
+		evictFrom(name, ch, cutoff, math.MaxInt64)
+	}
+	for name, ch := range channels {
+		if freed >= need {
+			break
+		}
+		evictFrom(name, ch, cutoff+1, need-freed)
+	}
+
+	if kind == EvictItems {
+		ss.memory.evictedItems.Add(int64(count))
+	} else {
+		ss.memory.evictedFingerprints.Add(int64(count))
+	}
+	return freed
+}
+
+func (ss *StatisticService) GetMemoryStats() MemoryStats {
+	ss.chMu.RLock()
+	defer ss.chMu.RUnlock()
+
+	stats := MemoryStats{
+		MaxBytes:            ss.memory.maxBytes,
+		Channels:            make(map[string]int64, len(ss.channels)),
+		EvictedItems:        ss.memory.evictedItems.Load(),
+		EvictedFingerprints: ss.memory.evictedFingerprints.Load(),
+	}
+	for name, ch := range ss.channels {
+		fixed, view := ch.fixedUsage(), ss.viewBytes(name)
+		usage := ch.evictableUsage() + fixed + view
+		stats.Channels[name] = usage
+		stats.UsedBytes += usage
+		stats.FixedBytes += fixed
+		stats.ViewBytes += view
+	}
+	return stats
+}
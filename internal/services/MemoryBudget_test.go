@@ -0,0 +1,130 @@
+package services
+
+import (
+	"fmt"
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBudgetService(maxBytes int64, eviction string) *StatisticService {
+	conf := &structures.Config{Memory: structures.MemoryConfig{MaxBytes: maxBytes, Eviction: eviction}}
+	return NewStatisticService(conf).(*StatisticService)
+}
+
+func TestMemoryStats_Unlimited(t *testing.T) {
+	ss := newBudgetService(0, "")
+	for i := 0; i < 100; i++ {
+		ss.AddStats(&models.InputStats{Fingerprint: fmt.Sprintf("fp%d", i), Views: []string{"1", "2"}, Channel: "news"})
+	}
+	ss.AggregateStats()
+
+	mem := ss.GetMemoryStats()
+	assert.Equal(t, int64(0), mem.MaxBytes)
+	assert.Positive(t, mem.Channels["news"])
+	assert.Equal(t, mem.Channels["news"]+mem.Channels[DefaultChannel], mem.UsedBytes)
+	assert.Zero(t, mem.EvictedFingerprints+mem.EvictedItems)
+	assert.Len(t, ss.GetPersonalStatistic("news"), 100)
+}
+
+func TestMemoryBudget_EvictsFingerprintsFirst(t *testing.T) {
+	ss := newBudgetService(8*1024, EvictFingerprints)
+	for i := 0; i < 200; i++ {
+		ss.AddStats(&models.InputStats{Fingerprint: fmt.Sprintf("fp%d", i), Views: []string{"1", "2", "3"}})
+	}
+	ss.AggregateStats()
+
+	mem := ss.GetMemoryStats()
+	assert.LessOrEqual(t, mem.UsedBytes, mem.MaxBytes)
+	assert.Positive(t, mem.EvictedFingerprints)
+	assert.Zero(t, mem.EvictedItems)
+	assert.Len(t, ss.GetStatistic(DefaultChannel), 3, "trend items are kept")
+
+	// The read view is rebuilt, evicted fingerprints disappear from it.
+	ch := ss.channels[DefaultChannel]
+	assert.Len(t, ss.GetPersonalStatistic(DefaultChannel), ch.personalStats.Len())
+}
+
+func TestMemoryBudget_EvictsItemsFirst(t *testing.T) {
+	ss := newBudgetService(4*1024, EvictItems)
+	views := make([]string, 200)
+	for i := range views {
+		views[i] = fmt.Sprint(i)
+	}
+	ss.AddStats(&models.InputStats{Views: views, Channel: "news"})
+	ss.AggregateStats()
+
+	mem := ss.GetMemoryStats()
+	assert.LessOrEqual(t, mem.UsedBytes, mem.MaxBytes)
+	assert.Positive(t, mem.EvictedItems)
+	assert.Less(t, len(ss.GetStatistic("news")), 200)
+}
+
+func TestMemoryBudget_FallsBackToOtherKind(t *testing.T) {
+	ss := newBudgetService(1024, EvictItems)
+	views := make([]string, 100)
+	for i := range views {
+		views[i] = fmt.Sprint(i)
+	}
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: views})
+	ss.AggregateStats()
+
+	mem := ss.GetMemoryStats()
+	assert.LessOrEqual(t, mem.UsedBytes, mem.MaxBytes)
+	assert.Positive(t, mem.EvictedItems)
+	assert.Equal(t, int64(1), mem.EvictedFingerprints)
+}
+
+func TestMemoryBudget_AppliedOnRestore(t *testing.T) {
+	ss := newBudgetService(2*1024, "")
+	personal := make(map[string]*models.Statistic)
+	for i := 0; i < 100; i++ {
+		personal[fmt.Sprintf("fp%d", i)] = &models.Statistic{Data: map[int]*models.StatRecord{1: {Views: 1}}}
+	}
+	ss.PutChannelData("restored", &models.ChannelData{
+		TrendStats:    map[int]*models.StatRecord{1: {Views: 100}},
+		PersonalStats: personal,
+	})
+
+	mem := ss.GetMemoryStats()
+	require.LessOrEqual(t, mem.UsedBytes, mem.MaxBytes)
+	assert.Less(t, len(ss.GetPersonalStatistic("restored")), 100)
+}
+
+func TestMemoryStats_ReportsFixedAndViewBytes(t *testing.T) {
+	ss := newBudgetService(0, "")
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"1", "2"}, Channel: "news"})
+	ss.AggregateStats()
+	ss.UpdateCatalog("news", map[int]*models.CatalogItem{1: {Category: "sport"}}, nil)
+
+	mem := ss.GetMemoryStats()
+	ch := ss.channels["news"]
+	assert.Equal(t, ch.catalog.MemoryUsage(), mem.FixedBytes)
+	assert.Positive(t, mem.ViewBytes, "the published trend and fingerprint copies are counted")
+	assert.Equal(t, ch.evictableUsage()+mem.FixedBytes+ss.viewBytes("news"), mem.Channels["news"])
+}
+
+func TestMemoryBudget_StopsWhenOnlyFixedStoresRemain(t *testing.T) {
+	ss := newBudgetService(1024, "")
+	catalog := make(map[int]*models.CatalogItem)
+	for i := 0; i < 100; i++ {
+		catalog[i] = &models.CatalogItem{Category: "sport", Tags: []string{"a", "b"}}
+	}
+	ss.UpdateCatalog("news", catalog, nil)
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"1"}, Channel: "news"})
+	ss.AggregateStats()
+
+	mem := ss.GetMemoryStats()
+	require.Greater(t, mem.FixedBytes, mem.MaxBytes)
+	evicted := mem.EvictedItems + mem.EvictedFingerprints
+	assert.Positive(t, evicted)
+
+	ss.AggregateStats()
+	mem = ss.GetMemoryStats()
+	assert.Equal(t, evicted, mem.EvictedItems+mem.EvictedFingerprints, "nothing evictable is left to evict")
+	_, ok := ss.GetCatalogItem("news", 99)
+	assert.True(t, ok, "the catalog is never evicted")
+}
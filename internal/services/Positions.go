@@ -0,0 +1,14 @@
+package services
+
+// learnPositions refreshes the slot propensities of every channel the batch
+// touched. Views counted in the batch used the propensities learned at the
+// previous aggregation. Callers must hold writeMu.
+func (ss *StatisticService) learnPositions(changes map[string]*channelChanges) {
+	ss.chMu.RLock()
+	defer ss.chMu.RUnlock()
+	for name := range changes {
+		if ch, ok := ss.channels[name]; ok && ch.positions != nil {
+			ch.positions.Learn()
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPositionService() *StatisticService {
+	conf := &structures.Config{Positions: structures.PositionsConfig{Enabled: true, MaxPositions: 3, MinViews: 10}}
+	return NewStatisticService(conf).(*StatisticService)
+}
+
+// addSlotEvents records views of item in slot pos with the given number of
+// clicks.
+func addSlotEvents(ss *StatisticService, item, pos, views, clicks int) {
+	id := fmt.Sprint(item)
+	for i := range views {
+		ev := &models.InputStats{Views: []string{id}, Positions: map[string]int{id: pos}}
+		if i < clicks {
+			ev.Clicks = []string{id}
+		}
+		ss.AddStats(ev)
+	}
+}
+
+func TestPositions_DisabledByDefault(t *testing.T) {
+	ss := newService()
+	addSlotEvents(ss, 1, 2, 10, 1)
+	ss.AggregateStats()
+
+	assert.Zero(t, ss.GetStatistic(DefaultChannel)[1].Exposure)
+	assert.Nil(t, ss.GetSnapshot().Channels[DefaultChannel].Positions)
+}
+
+func TestPositions_CorrectedCtr(t *testing.T) {
+	ss := newPositionService()
+	addSlotEvents(ss, 1, 1, 100, 20)
+	addSlotEvents(ss, 2, 2, 100, 5)
+	ss.AggregateStats()
+
+	// Nothing was learned yet when the first batch was counted.
+	assert.InDelta(t, 0.05, ss.GetStatistic(DefaultChannel)[2].CorrectedCtr, 1e-9)
+
+	addSlotEvents(ss, 3, 2, 20, 1)
+	ss.AggregateStats()
+	rec := ss.GetStatistic(DefaultChannel)[3]
+	assert.Equal(t, 5.0, rec.Exposure, "slot 2 is looked at a quarter as often as slot 1")
+	assert.InDelta(t, 0.2, rec.CorrectedCtr, 1e-9)
+	assert.Contains(t, string(ss.GetStatisticJSON(DefaultChannel)), `"CorrectedCtr":0.2`)
+}
+
+func TestPositions_Persistence(t *testing.T) {
+	ss := newPositionService()
+	addSlotEvents(ss, 1, 1, 100, 20)
+	addSlotEvents(ss, 2, 2, 100, 5)
+	ss.AggregateStats()
+
+	snap := ss.GetSnapshot().Channels[DefaultChannel]
+	require.NotNil(t, snap.Positions)
+	assert.Equal(t, []int64{100, 100, 0}, snap.Positions.Views)
+
+	restored := newPositionService()
+	restored.PutChannelData(DefaultChannel, snap)
+	addSlotEvents(restored, 3, 2, 20, 1)
+	restored.AggregateStats()
+	assert.Equal(t, 5.0, restored.GetStatistic(DefaultChannel)[3].Exposure, "propensities are relearned on restore")
+}
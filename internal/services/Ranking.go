@@ -0,0 +1,79 @@
+package services
+
+import (
+	"sort"
+	"ssd/internal/models"
+)
+
+// RankedRecord is one entry of a list sorted by a statistic.
+type RankedRecord struct {
+	ID int `json:"id"`
+	*models.StatRecord
+}
+
+// sortKeys maps the accepted sort names to the score they rank by. The
+// second result is false for records the key does not apply to, such as
+// items that never reported a value when sorting by sum.
+var sortKeys = map[string]func(r *models.StatRecord) (float64, bool){
+	"views":  func(r *models.StatRecord) (float64, bool) { return float64(r.Views), true },
+	"clicks": func(r *models.StatRecord) (float64, bool) { return float64(r.Clicks), true },
+	"sum": func(r *models.StatRecord) (float64, bool) {
+		if r.Value == nil || r.Value.Count == 0 {
+			return 0, false
+		}
+		return r.Value.Sum, true
+	},
+	"avg": func(r *models.StatRecord) (float64, bool) {
+		return r.Value.Avg(), r.Value != nil && r.Value.Count > 0
+	},
+	// ctr prefers the position-corrected rate and falls back to the raw one
+	// for items that were never shown with a position.
+	"ctr": func(r *models.StatRecord) (float64, bool) {
+		if r.Exposure > 0 {
+			return r.CorrectedCtr, true
+		}
+		if r.Views == 0 {
+			return 0, false
+		}
+		return float64(r.Clicks) / float64(r.Views), true
+	},
+}
+
+// ValidSortKey reports whether TopRecords accepts key.
+func ValidSortKey(key string) bool {
+	_, ok := sortKeys[key]
+	return ok
+}
+
+// TopRecords returns the n records with the highest score under key, ties
+// broken by ascending ID. Records the key does not apply to are left out.
+func TopRecords(records map[int]*models.StatRecord, key string, n int) []RankedRecord {
+	score, ok := sortKeys[key]
+	if !ok || n <= 0 {
+		return nil
+	}
+	type scored struct {
+		RankedRecord
+		score float64
+	}
+	list := make([]scored, 0, len(records))
+	for id, r := range records {
+		if r == nil {
+			continue
+		}
+		if s, ok := score(r); ok {
+			list = append(list, scored{RankedRecord{id, r}, s})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].score != list[j].score {
+			return list[i].score > list[j].score
+		}
+		return list[i].ID < list[j].ID
+	})
+	out := make([]RankedRecord, 0, min(n, len(list)))
+	for _, s := range list[:min(n, len(list))] {
+		out = append(out, s.RankedRecord)
+	}
+	return out
+}
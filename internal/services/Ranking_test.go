@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"ssd/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rankedIDs(list []RankedRecord) []int {
+	ids := make([]int, len(list))
+	for i, r := range list {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestTopRecords(t *testing.T) {
+	records := map[int]*models.StatRecord{
+		1: {Views: 10, Clicks: 1, Value: &models.ValueSummary{Count: 4, Sum: 40}},
+		2: {Views: 30, Clicks: 3},
+		3: {Views: 20, Clicks: 3, Value: &models.ValueSummary{Count: 1, Sum: 25}},
+		4: {Views: 5, Value: &models.ValueSummary{Count: 2, Sum: 40}},
+		5: nil,
+	}
+
+	assert.Equal(t, []int{2, 3, 1}, rankedIDs(TopRecords(records, "views", 3)))
+	assert.Equal(t, []int{2, 3, 1, 4}, rankedIDs(TopRecords(records, "clicks", 10)), "ties by ascending ID")
+	assert.Equal(t, []int{1, 4, 3}, rankedIDs(TopRecords(records, "sum", 10)), "items without values are left out")
+	assert.Equal(t, []int{3, 4, 1}, rankedIDs(TopRecords(records, "avg", 10)))
+	assert.Nil(t, TopRecords(records, "revenue", 10))
+	assert.Nil(t, TopRecords(records, "views", 0))
+}
+
+func TestTopRecords_CorrectedCtr(t *testing.T) {
+	records := map[int]*models.StatRecord{
+		1: {Views: 100, Clicks: 10, Exposure: 100, CorrectedCtr: 0.1},
+		2: {Views: 100, Clicks: 5, Exposure: 25, CorrectedCtr: 0.2},
+		3: {Views: 10, Clicks: 1},
+		4: {Clicks: 1},
+	}
+	assert.Equal(t, []int{2, 1, 3}, rankedIDs(TopRecords(records, "ctr", 10)),
+		"corrected rate first, raw rate without positions, nothing without views")
+}
+
+func TestValidSortKey(t *testing.T) {
+	for _, key := range []string{"views", "clicks", "sum", "avg", "ctr"} {
+		assert.True(t, ValidSortKey(key), key)
+	}
+	assert.False(t, ValidSortKey("revenue"))
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"sort"
+	"ssd/internal/models"
+)
+
+const (
+	defaultRisingTicks    = 6
+	defaultRisingMinViews = 10
+)
+
+// RisingItem describes the momentum of an item over the rising window.
+// Velocity is its rate in views per second over the latest aggregation tick,
+// Acceleration how far that exceeds its rate over the earlier ticks, also in
+// views per second, and RankDelta how many places it climbed in the per-tick
+// view ranking since the previous tick.
+type RisingItem struct {
+	ID           int     `json:"id"`
+	Velocity     float64 `json:"velocity"`
+	Acceleration float64 `json:"acceleration"`
+	Rank         int     `json:"rank"`
+	RankDelta    int     `json:"rankDelta"`
+}
+
+// tickRanks ranks the items of one tick by views, highest first, ties by
+// ascending ID.
+func tickRanks(tick map[int]int) map[int]int {
+	ids := make([]int, 0, len(tick))
+	for id := range tick {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if tick[ids[i]] != tick[ids[j]] {
+			return tick[ids[i]] > tick[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	ranks := make(map[int]int, len(ids))
+	for i, id := range ids {
+		ranks[id] = i + 1
+	}
+	return ranks
+}
+
+// risingItems lists the items of the latest tick that accelerate and have at
+// least minViews views over the whole window, fastest accelerating first.
+// Ticks differ in length, so the latest and the earlier ticks are compared
+// as rates per second, the way anomaly detection scales batches by the time
+// they cover. Items missing from the previous tick are ranked just
+// below its last item.
+func risingItems(ticks []models.RisingTick, minViews int) []RisingItem {
+	if len(ticks) < 2 {
+		return nil
+	}
+	latest, prev := ticks[len(ticks)-1], ticks[len(ticks)-2]
+	earlier := ticks[:len(ticks)-1]
+	var span float64
+	for _, tick := range earlier {
+		span += tick.Duration.Seconds()
+	}
+	seconds := latest.Duration.Seconds()
+	ranks, prevRanks := tickRanks(latest.Views), tickRanks(prev.Views)
+
+	var items []RisingItem
+	for id, views := range latest.Views {
+		before := 0
+		for _, tick := range earlier {
+			before += tick.Views[id]
+		}
+		if views+before < minViews {
+			continue
+		}
+		velocity := float64(views) / seconds
+		accel := velocity - float64(before)/span
+		if accel <= 0 {
+			continue
+		}
+		prevRank, ok := prevRanks[id]
+		if !ok {
+			prevRank = len(prev.Views) + 1
+		}
+		items = append(items, RisingItem{
+			ID:           id,
+			Velocity:     velocity,
+			Acceleration: accel,
+			Rank:         ranks[id],
+			RankDelta:    prevRank - ranks[id],
+		})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Acceleration != b.Acceleration {
+			return a.Acceleration > b.Acceleration
+		}
+		if a.Velocity != b.Velocity {
+			return a.Velocity > b.Velocity
+		}
+		return a.ID < b.ID
+	})
+	return items
+}
+
+// tickRising closes the current tick of every channel tracking rising items
+// with the time since the previous tick and marks channels whose window
+// changed, so that their view is rebuilt even without new events. Callers
+// must hold writeMu.
+func (ss *StatisticService) tickRising(changes map[string]*channelChanges) {
+	now := ss.now()
+	elapsed := now.Sub(ss.risingTick)
+	ss.risingTick = now
+
+	ss.chMu.RLock()
+	defer ss.chMu.RUnlock()
+	for name, ch := range ss.channels {
+		if ch.rising == nil || !ch.rising.Tick(elapsed) {
+			continue
+		}
+		if _, ok := changes[name]; !ok {
+			changes[name] = newChannelChanges()
+		}
+	}
+}
+
+// GetRising returns up to n items of the channel with the highest
+// acceleration over the last ticks, or nil unless rising.enabled.
+func (ss *StatisticService) GetRising(channel string, n int) []RisingItem {
+	v := ss.channelView(channel)
+	if v == nil {
+		return nil
+	}
+	items := v.risingItems(ss.rising.MinViews)
+	return items[:min(max(n, 0), len(items))]
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"reflect"
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// evenTicks gives every tick the same length.
+func evenTicks(views ...map[int]int) []models.RisingTick {
+	ticks := make([]models.RisingTick, len(views))
+	for i, v := range views {
+		ticks[i] = models.RisingTick{Views: v, Duration: 10 * time.Second}
+	}
+	return ticks
+}
+
+// assertRising compares rising items with a tolerance for their rates.
+func assertRising(t *testing.T, want, got RisingItem) {
+	t.Helper()
+	assert.InDelta(t, want.Velocity, got.Velocity, 1e-9)
+	assert.InDelta(t, want.Acceleration, got.Acceleration, 1e-9)
+	want.Velocity, want.Acceleration = got.Velocity, got.Acceleration
+	assert.Equal(t, want, got)
+}
+
+func TestRisingItems(t *testing.T) {
+	ticks := evenTicks(
+		map[int]int{1: 50, 2: 2, 3: 10},
+		map[int]int{1: 50, 2: 4, 3: 10},
+		map[int]int{1: 40, 2: 30, 3: 12, 4: 3},
+	)
+	items := risingItems(ticks, 10)
+
+	require.Len(t, items, 2, "item 1 slows down and item 4 is below the minimum volume")
+	assertRising(t, RisingItem{ID: 2, Velocity: 3, Acceleration: 2.7, Rank: 2, RankDelta: 1}, items[0])
+	assertRising(t, RisingItem{ID: 3, Velocity: 1.2, Acceleration: 0.2, Rank: 3, RankDelta: -1}, items[1])
+}
+
+func TestRisingItems_NewItemRanksBelowPreviousTick(t *testing.T) {
+	items := risingItems(evenTicks(map[int]int{1: 5, 2: 3}, map[int]int{3: 20, 1: 1}), 1)
+
+	require.Len(t, items, 1)
+	assertRising(t, RisingItem{ID: 3, Velocity: 2, Acceleration: 2, Rank: 1, RankDelta: 2}, items[0])
+	assert.Nil(t, risingItems(evenTicks(map[int]int{1: 5}), 1), "needs two ticks")
+}
+
+func TestRisingItems_ComparesRates(t *testing.T) {
+	ticks := []models.RisingTick{
+		{Views: map[int]int{1: 100, 2: 10}, Duration: 10 * time.Second},
+		{Views: map[int]int{1: 20, 2: 10}, Duration: 2 * time.Second},
+	}
+	items := risingItems(ticks, 1)
+
+	require.Len(t, items, 1, "item 1 keeps its rate over a short tick triggered by a full buffer")
+	assert.Equal(t, 2, items[0].ID)
+	assert.Equal(t, 5.0, items[0].Velocity, "10 views in 2s")
+	assert.Equal(t, 4.0, items[0].Acceleration, "5 views/s against 1 view/s before")
+}
+
+func TestRising_DisabledByDefault(t *testing.T) {
+	ss := newService()
+	ss.AddStats(&models.InputStats{Views: []string{"1"}})
+	ss.AggregateStats()
+	ss.AggregateStats()
+
+	assert.Empty(t, ss.GetRising(DefaultChannel, 10))
+}
+
+// withTickClock makes every read of the service clock advance by step.
+func withTickClock(ss *StatisticService, step time.Duration) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	ss.risingTick = now
+	ss.now = func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+func TestRising_AcrossAggregations(t *testing.T) {
+	ss := NewStatisticService(&structures.Config{Rising: structures.RisingConfig{Enabled: true, Ticks: 3, MinViews: 2}}).(*StatisticService)
+	withTickClock(ss, 10*time.Second)
+	views := func(ids ...string) {
+		ss.AddStats(&models.InputStats{Views: ids, Channel: "news"})
+		ss.AggregateStats()
+	}
+	views("1")
+	views("1", "2", "2", "2")
+
+	items := ss.GetRising("news", 10)
+	require.Len(t, items, 1)
+	assert.Equal(t, 2, items[0].ID)
+	assert.InDelta(t, 0.3, items[0].Velocity, 1e-9, "3 views in 10s")
+	assert.InDelta(t, 0.3, items[0].Acceleration, 1e-9)
+
+	// Aggregations without events still advance the window of the channel,
+	// but share its records and their JSON with the previous view.
+	before := ss.channelView("news")
+	ss.AggregateStats()
+	after := ss.channelView("news")
+	assert.NotSame(t, before, after)
+	assert.Equal(t, reflect.ValueOf(before.trend).Pointer(), reflect.ValueOf(after.trend).Pointer())
+	assert.Same(t, &before.trendJSON[0], &after.trendJSON[0])
+	assert.Empty(t, ss.GetRising("news", 10))
+	assert.Nil(t, ss.GetRising("unknown", 10))
+}
+
+func TestRising_ShortTickKeepsRate(t *testing.T) {
+	ss := NewStatisticService(&structures.Config{Rising: structures.RisingConfig{Enabled: true, Ticks: 3, MinViews: 2}}).(*StatisticService)
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	ss.risingTick = now
+	ss.now = func() time.Time { return now }
+
+	for _, tick := range []struct {
+		views int
+		d     time.Duration
+	}{{10, 10 * time.Second}, {1, time.Second}, {3, time.Second}} {
+		for i := 0; i < tick.views; i++ {
+			ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: "news"})
+		}
+		now = now.Add(tick.d)
+		ss.AggregateStats()
+		if tick.views == 1 {
+			assert.Empty(t, ss.GetRising("news", 10), "a buffer-triggered tick at the same rate is not rising")
+		}
+	}
+
+	items := ss.GetRising("news", 10)
+	require.Len(t, items, 1)
+	assert.InDelta(t, 2.0, items[0].Acceleration, 1e-9, "3 views in 1s against 11 views in 11s before")
+}
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"sync"
+	"time"
+)
+
+const (
+	// RuleItemViews fires when the views of an item, estimated before decay
+	// as Views << Ftr, reach the threshold.
+	RuleItemViews = "item_views"
+	// RuleBatchEvents fires when a channel, or all channels for an empty
+	// channel, received at least threshold events in one aggregation batch.
+	// Batches cut short by a full buffer count only the events they took.
+	RuleBatchEvents = "batch_events"
+	// RuleBuffer fires when at least threshold events wait in the ingest
+	// buffer of all channels. The scheduler polls it between aggregations.
+	RuleBuffer = "buffer"
+	// RulePersistFailures fires when persistence failed threshold times in
+	// a row.
+	RulePersistFailures = "persist_failures"
+
+	// RuleEventName is the webhook event name of fired rules.
+	RuleEventName = "rule"
+
+	maxRules = 1000
+)
+
+var (
+	errRuleName      = errors.New("rule name is required")
+	errRuleMetric    = errors.New("unknown rule metric")
+	errRuleThreshold = errors.New("rule threshold must be positive")
+	errRuleItem      = errors.New("item_views rule needs an item")
+	errTooManyRules  = errors.New("too many rules")
+)
+
+// Rule is a threshold notification. It fires once when its metric reaches
+// the threshold and is re-armed when the metric falls below it again.
+type Rule struct {
+	Name      string  `json:"name"`
+	Metric    string  `json:"metric"`
+	Channel   string  `json:"channel,omitempty"`
+	Item      *int    `json:"item,omitempty"` // nil = not set, 0 is a valid item
+	Threshold float64 `json:"threshold"`
+}
+
+// Validate reports whether the rule can be evaluated.
+func (r *Rule) Validate() error {
+	if r.Name == "" {
+		return errRuleName
+	}
+	if r.Threshold <= 0 {
+		return errRuleThreshold
+	}
+	switch r.Metric {
+	case RuleBatchEvents, RuleBuffer, RulePersistFailures:
+		return nil
+	case RuleItemViews:
+		if r.Item == nil {
+			return errRuleItem
+		}
+		return nil
+	}
+	return errRuleMetric
+}
+
+// RuleEvent reports a rule whose metric reached its threshold.
+type RuleEvent struct {
+	Rule      string    `json:"rule"`
+	Metric    string    `json:"metric"`
+	Channel   string    `json:"channel,omitempty"`
+	Item      *int      `json:"item,omitempty"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Time      time.Time `json:"time"`
+}
+
+// RuleEngineInterface keeps the threshold rules and evaluates them after
+// aggregation and persistence.
+type RuleEngineInterface interface {
+	AfterAggregate() []RuleEvent
+	CheckBuffer() []RuleEvent
+	AfterPersist(err error) []RuleEvent
+	Rules() []Rule
+	SetRule(rule Rule) error
+	DeleteRule(name string) bool
+	Update(set []Rule, del []string) error
+	Overrides() *models.RuleOverrides
+	Restore(overrides *models.RuleOverrides)
+}
+
+type ruleState struct {
+	rule   Rule
+	active bool
+}
+
+// RuleEngine evaluates rules against the published statistic, the batch of
+// the last aggregation and the persistence outcome.
+type RuleEngine struct {
+	mu             sync.Mutex
+	service        StatisticServiceInterface
+	rules          map[string]*ruleState
+	changed        map[string]*Rule // API changes over the config, nil = deleted
+	persistFailure int              // consecutive failed persists
+	now            func() time.Time
+}
+
+// NewRuleEngine creates an engine with the rules from the config. Invalid
+// rules, and rules repeating an earlier name, are skipped.
+func NewRuleEngine(conf *structures.Config, service StatisticServiceInterface) RuleEngineInterface {
+	return newRuleEngine(conf, service)
+}
+
+func newRuleEngine(conf *structures.Config, service StatisticServiceInterface) *RuleEngine {
+	e := &RuleEngine{
+		service: service,
+		rules:   make(map[string]*ruleState),
+		changed: make(map[string]*Rule),
+		now:     time.Now,
+	}
+	for _, rc := range conf.Rules {
+		rule := Rule{
+			Name:      rc.Name,
+			Metric:    rc.Metric,
+			Channel:   rc.Channel,
+			Item:      rc.Item,
+			Threshold: rc.Threshold,
+		}
+		if _, dup := e.rules[rc.Name]; dup || rule.Validate() != nil || len(e.rules) >= maxRules {
+			continue
+		}
+		e.rules[rule.Name] = &ruleState{rule: rule}
+	}
+	return e
+}
+
+// AfterAggregate evaluates the item_views and batch_events rules.
+func (e *RuleEngine) AfterAggregate() []RuleEvent {
+	batches := e.service.GetLastBatch()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	var events []RuleEvent
+	for _, st := range e.sortedRules() {
+		var value float64
+		switch st.rule.Metric {
+		case RuleItemViews:
+			channel := st.rule.Channel
+			if channel == "" {
+				channel = DefaultChannel
+			}
+			if rec := e.service.GetStatistic(channel)[*st.rule.Item]; rec != nil {
+				value = math.Ldexp(float64(rec.Views), rec.Ftr)
+			}
+		case RuleBatchEvents:
+			if st.rule.Channel != "" {
+				value = float64(batches[st.rule.Channel].Events)
+			} else {
+				for _, b := range batches {
+					value += float64(b.Events)
+				}
+			}
+		default:
+			continue
+		}
+		if ev, ok := st.check(value, now); ok {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+// CheckBuffer evaluates the buffer rules against the events waiting in the
+// ingest buffer. The buffer is only measured while such a rule exists.
+func (e *RuleEngine) CheckBuffer() []RuleEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var rules []*ruleState
+	for _, st := range e.sortedRules() {
+		if st.rule.Metric == RuleBuffer {
+			rules = append(rules, st)
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	size := float64(e.service.GetBufferSize())
+	now := e.now()
+	var events []RuleEvent
+	for _, st := range rules {
+		if ev, ok := st.check(size, now); ok {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+// AfterPersist counts consecutive persistence failures and evaluates the
+// persist_failures rules.
+func (e *RuleEngine) AfterPersist(err error) []RuleEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err != nil {
+		e.persistFailure++
+	} else {
+		e.persistFailure = 0
+	}
+	now := e.now()
+	var events []RuleEvent
+	for _, st := range e.sortedRules() {
+		if st.rule.Metric != RulePersistFailures {
+			continue
+		}
+		if ev, ok := st.check(float64(e.persistFailure), now); ok {
+			events = append(events, ev)
+		}
+	}
+	return events
+}
+
+// check fires when value reaches the threshold while the rule is armed.
+func (st *ruleState) check(value float64, now time.Time) (RuleEvent, bool) {
+	if value < st.rule.Threshold {
+		st.active = false
+		return RuleEvent{}, false
+	}
+	if st.active {
+		return RuleEvent{}, false
+	}
+	st.active = true
+	return RuleEvent{
+		Rule:      st.rule.Name,
+		Metric:    st.rule.Metric,
+		Channel:   st.rule.Channel,
+		Item:      st.rule.Item,
+		Value:     value,
+		Threshold: st.rule.Threshold,
+		Time:      now,
+	}, true
+}
+
+// sortedRules returns the rule states by name, so events come out in a
+// stable order.
+func (e *RuleEngine) sortedRules() []*ruleState {
+	out := make([]*ruleState, 0, len(e.rules))
+	for _, st := range e.rules {
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].rule.Name < out[j].rule.Name })
+	return out
+}
+
+// Rules returns all rules sorted by name.
+func (e *RuleEngine) Rules() []Rule {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Rule, 0, len(e.rules))
+	for _, st := range e.sortedRules() {
+		out = append(out, st.rule)
+	}
+	return out
+}
+
+// SetRule adds a rule or replaces the rule with the same name. A replaced
+// rule is re-armed.
+func (e *RuleEngine) SetRule(rule Rule) error {
+	return e.Update([]Rule{rule}, nil)
+}
+
+// DeleteRule removes a rule and reports whether it existed.
+func (e *RuleEngine) DeleteRule(name string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, ok := e.rules[name]
+	e.apply(nil, []string{name})
+	return ok
+}
+
+// Update deletes the rules named in del, then adds or replaces the rules in
+// set. Either all changes apply or, when a rule is invalid or the rules
+// would exceed the limit, none.
+func (e *RuleEngine) Update(set []Rule, del []string) error {
+	for i := range set {
+		if err := set[i].Validate(); err != nil {
+			return err
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	names := make(map[string]bool, len(e.rules))
+	for name := range e.rules {
+		names[name] = true
+	}
+	for _, name := range del {
+		delete(names, name)
+	}
+	for _, rule := range set {
+		names[rule.Name] = true
+	}
+	if len(names) > maxRules {
+		return errTooManyRules
+	}
+	e.apply(set, del)
+	return nil
+}
+
+// apply makes validated changes and records them as overrides of the
+// config. Callers must hold mu.
+func (e *RuleEngine) apply(set []Rule, del []string) {
+	for _, name := range del {
+		if _, ok := e.rules[name]; ok {
+			delete(e.rules, name)
+			e.changed[name] = nil
+		}
+	}
+	for _, rule := range set {
+		e.rules[rule.Name] = &ruleState{rule: rule}
+		e.changed[rule.Name] = &rule
+	}
+}
+
+// Overrides returns the changes made through Update since the config was
+// loaded, for the snapshot, or nil without changes.
+func (e *RuleEngine) Overrides() *models.RuleOverrides {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.changed) == 0 {
+		return nil
+	}
+	out := &models.RuleOverrides{}
+	for name, rule := range e.changed {
+		if rule == nil {
+			out.Deleted = append(out.Deleted, name)
+		} else {
+			out.Set = append(out.Set, models.RuleData(*rule))
+		}
+	}
+	sort.Strings(out.Deleted)
+	sort.Slice(out.Set, func(i, j int) bool { return out.Set[i].Name < out.Set[j].Name })
+	return out
+}
+
+// Restore applies the overrides of a snapshot over the config rules. Invalid
+// rules are skipped, and rules beyond the limit are dropped.
+func (e *RuleEngine) Restore(overrides *models.RuleOverrides) {
+	if overrides == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.apply(nil, overrides.Deleted)
+	for _, data := range overrides.Set {
+		rule := Rule(data)
+		if _, ok := e.rules[rule.Name]; rule.Validate() != nil || !ok && len(e.rules) >= maxRules {
+			continue
+		}
+		e.apply([]Rule{rule}, nil)
+	}
+}
@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"ssd/internal/models"
+	"ssd/internal/structures"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func itemID(id int) *int { return &id }
+
+func TestRule_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		err  error
+	}{
+		{"item views", Rule{Name: "a", Metric: RuleItemViews, Item: itemID(1), Threshold: 10}, nil},
+		{"item zero", Rule{Name: "a", Metric: RuleItemViews, Item: itemID(0), Threshold: 10}, nil},
+		{"batch events", Rule{Name: "a", Metric: RuleBatchEvents, Threshold: 10}, nil},
+		{"buffer", Rule{Name: "a", Metric: RuleBuffer, Threshold: 10}, nil},
+		{"persist failures", Rule{Name: "a", Metric: RulePersistFailures, Threshold: 2}, nil},
+		{"no name", Rule{Metric: RuleBatchEvents, Threshold: 10}, errRuleName},
+		{"no threshold", Rule{Name: "a", Metric: RuleBatchEvents}, errRuleThreshold},
+		{"unknown metric", Rule{Name: "a", Metric: "cpu", Threshold: 1}, errRuleMetric},
+		{"no item", Rule{Name: "a", Metric: RuleItemViews, Threshold: 1}, errRuleItem},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.err, tt.rule.Validate())
+		})
+	}
+}
+
+func TestRuleEngine_ConfigRules(t *testing.T) {
+	e := newRuleEngine(&structures.Config{Rules: []structures.RuleConfig{
+		{Name: "b", Metric: RuleBatchEvents, Threshold: 100},
+		{Name: "a", Metric: RulePersistFailures, Threshold: 2},
+		{Name: "b", Metric: RuleBatchEvents, Threshold: 5},
+		{Name: "bad", Metric: RuleItemViews, Threshold: 5},
+	}}, newService())
+
+	assert.Equal(t, []Rule{
+		{Name: "a", Metric: RulePersistFailures, Threshold: 2},
+		{Name: "b", Metric: RuleBatchEvents, Threshold: 100},
+	}, e.Rules())
+}
+
+func TestRuleEngine_ItemViews(t *testing.T) {
+	ss := newService()
+	e := newRuleEngine(&structures.Config{}, ss)
+	require.NoError(t, e.SetRule(Rule{Name: "hit", Metric: RuleItemViews, Item: itemID(7), Threshold: 600}))
+
+	views := func(n int) {
+		ids := make([]string, n)
+		for i := range ids {
+			ids[i] = "7"
+		}
+		ss.AddStats(&models.InputStats{Views: ids})
+		ss.AggregateStats()
+	}
+	views(500)
+	assert.Empty(t, e.AfterAggregate())
+
+	// Past 512 views the record is halved; the estimate still counts them.
+	views(100)
+	events := e.AfterAggregate()
+	require.Len(t, events, 1)
+	assert.Equal(t, "hit", events[0].Rule)
+	assert.Equal(t, itemID(7), events[0].Item)
+	assert.GreaterOrEqual(t, events[0].Value, 600.0)
+
+	views(1)
+	assert.Empty(t, e.AfterAggregate(), "fires once while above the threshold")
+}
+
+func TestRuleEngine_BatchEvents(t *testing.T) {
+	ss := newService()
+	e := newRuleEngine(&structures.Config{}, ss)
+	require.NoError(t, e.SetRule(Rule{Name: "news", Metric: RuleBatchEvents, Channel: "news", Threshold: 2}))
+	require.NoError(t, e.SetRule(Rule{Name: "total", Metric: RuleBatchEvents, Threshold: 3}))
+
+	batch := func(channels ...string) []RuleEvent {
+		for _, ch := range channels {
+			ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: ch})
+		}
+		ss.AggregateStats()
+		return e.AfterAggregate()
+	}
+	events := batch("news", "news", "blog")
+	require.Len(t, events, 2)
+	assert.Equal(t, "news", events[0].Rule)
+	assert.Equal(t, 2.0, events[0].Value)
+	assert.Equal(t, "total", events[1].Rule)
+	assert.Equal(t, 3.0, events[1].Value)
+
+	assert.Empty(t, batch("news", "news", "news"), "still above")
+	assert.Empty(t, batch("blog"), "re-armed")
+	events = batch("news", "news")
+	require.Len(t, events, 1)
+	assert.Equal(t, "news", events[0].Rule)
+}
+
+func TestRuleEngine_ItemZero(t *testing.T) {
+	ss := newService()
+	e := newRuleEngine(&structures.Config{}, ss)
+	require.NoError(t, e.SetRule(Rule{Name: "zero", Metric: RuleItemViews, Item: itemID(0), Threshold: 2}))
+
+	ss.AddStats(&models.InputStats{Views: []string{"0", "0"}})
+	ss.AggregateStats()
+	events := e.AfterAggregate()
+	require.Len(t, events, 1)
+	assert.Equal(t, itemID(0), events[0].Item)
+}
+
+func TestRuleEngine_Buffer(t *testing.T) {
+	ss := newService()
+	e := newRuleEngine(&structures.Config{}, ss)
+	assert.Empty(t, e.CheckBuffer(), "nothing to check without buffer rules")
+	require.NoError(t, e.SetRule(Rule{Name: "backlog", Metric: RuleBuffer, Threshold: 3}))
+
+	add := func(n int) {
+		for i := 0; i < n; i++ {
+			ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: "news"})
+		}
+	}
+	add(2)
+	assert.Empty(t, e.CheckBuffer())
+	add(1)
+	events := e.CheckBuffer()
+	require.Len(t, events, 1)
+	assert.Equal(t, "backlog", events[0].Rule)
+	assert.Equal(t, 3.0, events[0].Value)
+	assert.Empty(t, e.CheckBuffer(), "fires once while above the threshold")
+	assert.Empty(t, e.AfterAggregate(), "not evaluated after aggregation")
+
+	ss.AggregateStats()
+	assert.Empty(t, e.CheckBuffer(), "re-armed once drained")
+	add(3)
+	assert.Len(t, e.CheckBuffer(), 1)
+}
+
+func TestRuleEngine_PersistFailures(t *testing.T) {
+	e := newRuleEngine(&structures.Config{}, newService())
+	require.NoError(t, e.SetRule(Rule{Name: "disk", Metric: RulePersistFailures, Threshold: 2}))
+	fail := errors.New("disk full")
+
+	assert.Empty(t, e.AfterPersist(fail))
+	events := e.AfterPersist(fail)
+	require.Len(t, events, 1)
+	assert.Equal(t, 2.0, events[0].Value)
+	assert.Empty(t, e.AfterPersist(fail))
+
+	assert.Empty(t, e.AfterPersist(nil))
+	assert.Empty(t, e.AfterPersist(fail), "the count restarts after a success")
+	assert.Len(t, e.AfterPersist(fail), 1)
+	assert.Empty(t, e.AfterAggregate(), "not evaluated after aggregation")
+}
+
+func TestRuleEngine_SetAndDelete(t *testing.T) {
+	e := newRuleEngine(&structures.Config{}, newService())
+	assert.Equal(t, errRuleThreshold, e.SetRule(Rule{Name: "a", Metric: RuleBatchEvents}))
+
+	require.NoError(t, e.SetRule(Rule{Name: "a", Metric: RuleBatchEvents, Threshold: 1}))
+	require.NoError(t, e.SetRule(Rule{Name: "a", Metric: RuleBatchEvents, Threshold: 9}))
+	assert.Equal(t, []Rule{{Name: "a", Metric: RuleBatchEvents, Threshold: 9}}, e.Rules())
+
+	assert.True(t, e.DeleteRule("a"))
+	assert.False(t, e.DeleteRule("a"))
+	assert.Empty(t, e.Rules())
+
+	for i := 0; i < maxRules; i++ {
+		require.NoError(t, e.SetRule(Rule{Name: strconv.Itoa(i), Metric: RuleBatchEvents, Threshold: 1}))
+	}
+	assert.Equal(t, errTooManyRules, e.SetRule(Rule{Name: "extra", Metric: RuleBatchEvents, Threshold: 1}))
+}
+
+func TestRuleEngine_UpdateIsAtomic(t *testing.T) {
+	e := newRuleEngine(&structures.Config{}, newService())
+	for i := 0; i < maxRules; i++ {
+		require.NoError(t, e.SetRule(Rule{Name: strconv.Itoa(i), Metric: RuleBatchEvents, Threshold: 1}))
+	}
+
+	extra := []Rule{{Name: "x", Metric: RuleBatchEvents, Threshold: 1}, {Name: "y", Metric: RuleBatchEvents, Threshold: 1}}
+	assert.Equal(t, errTooManyRules, e.Update(extra, []string{"0"}))
+	assert.Len(t, e.Rules(), maxRules, "a rejected update deletes nothing")
+
+	require.NoError(t, e.Update(extra, []string{"0", "1"}), "deletes make room first")
+	assert.Len(t, e.Rules(), maxRules)
+}
+
+func TestRuleEngine_OverridesRestoreOverConfig(t *testing.T) {
+	conf := &structures.Config{Rules: []structures.RuleConfig{
+		{Name: "disk", Metric: RulePersistFailures, Threshold: 2},
+		{Name: "busy", Metric: RuleBatchEvents, Threshold: 100},
+	}}
+	e := newRuleEngine(conf, newService())
+	assert.Nil(t, e.Overrides(), "config rules are not overrides")
+
+	require.NoError(t, e.Update([]Rule{{Name: "busy", Metric: RuleBatchEvents, Threshold: 50}, {Name: "hit", Metric: RuleItemViews, Item: itemID(7), Threshold: 10}}, []string{"disk", "unknown"}))
+	overrides := e.Overrides()
+	assert.Equal(t, &models.RuleOverrides{
+		Set:     []models.RuleData{{Name: "busy", Metric: RuleBatchEvents, Threshold: 50}, {Name: "hit", Metric: RuleItemViews, Item: itemID(7), Threshold: 10}},
+		Deleted: []string{"disk"},
+	}, overrides)
+
+	restarted := newRuleEngine(conf, newService())
+	restarted.Restore(overrides)
+	assert.Equal(t, e.Rules(), restarted.Rules())
+	assert.Equal(t, overrides, restarted.Overrides(), "restored overrides are saved again")
+}
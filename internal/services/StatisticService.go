@@ -1,40 +1,221 @@
 package services
 
 import (
+	json "github.com/goccy/go-json"
+	"hash/maphash"
+	"math/rand/v2"
+	"runtime"
 	"sort"
 	"ssd/internal/models"
+	"ssd/internal/structures"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const DefaultChannel = "default"
 const maxChannels = 1000
 
+const (
+	defaultRelatedNeighbors = 50
+	defaultRelatedHistory   = 50
+	defaultDimensionValues  = 100
+	defaultMaxExperiments   = 100
+	defaultMaxVariants      = 10
+	defaultExperimentItems  = 10000
+	defaultExperimentTTL    = 30 * 24 * time.Hour
+	defaultExperimentAlpha  = 0.05
+	defaultMaxPositions     = 20
+	defaultPositionMinViews = 1000
+)
+
+// StatisticServiceInterface is the ingestion and query core. Maps returned by
+// the Get* methods belong to the published read view and must not be mutated.
 type StatisticServiceInterface interface {
 	AddStats(data *models.InputStats)
-	AggregateStats()
+	AggregateStats() int
 	GetStatistic(channel string) map[int]*models.StatRecord
+	GetStatisticJSON(channel string) []byte
+	GetFilteredStatistic(channel string, filter models.CatalogFilter) map[int]*models.StatRecord
+	GetDimensionStatistic(channel string, dim models.DimKey, filter models.CatalogFilter) map[int]*models.StatRecord
+	GetBreakdown(channel string, id int, dim string) map[string]*models.StatRecord
+	GetExperiment(channel, id string, items []int) *ExperimentReport
+	GetFunnel(channel, name string, items []int) *FunnelReport
+	GetRising(channel string, n int) []RisingItem
+	GetLastBatch() map[string]BatchStats
+	GetExperimentRejects() int64
 	GetPersonalStatistic(channel string) map[string]*models.Statistic
+	GetPersonalStatisticJSON(channel string) []byte
 	GetByFingerprint(channel, fp string) map[int]*models.StatRecord
-	PutChannelData(channel string, trend map[int]*models.StatRecord, personal map[string]*models.Statistic)
+	GetRelated(channel string, id, n int) []models.RelatedItem
+	GetFeed(channel, fp string, n int, filter models.CatalogFilter) []FeedItem
+	GetCatalogItem(channel string, id int) (*models.CatalogItem, bool)
+	UpdateCatalog(channel string, upsert map[int]*models.CatalogItem, remove []int)
+	PutChannelData(channel string, data *models.ChannelData)
 	GetChannels() []string
 	GetSnapshot() *models.Storage
 	GetBufferSize() int
 	GetRecordCount(channel string) int
+	GetMemoryStats() MemoryStats
 }
 
+var nullJSON = []byte("null")
+
 type channelData struct {
-	statistic     *models.Statistic
+	statistic     *models.TrendStats
 	personalStats *models.PersonalStats
+	related       *models.RelatedStats // nil unless related.enabled
+	catalog       *models.Catalog
+	dims          *models.DimensionStats  // nil unless the channel has whitelisted dimensions
+	experiments   *models.ExperimentStats // nil unless experiments.enabled
+	positions     *models.PositionStats   // nil unless positions.enabled
+	rising        *models.RisingStats     // nil unless rising.enabled
+	funnels       map[string]*models.FunnelStats
+}
+
+// channelView is an immutable copy of a channel published after every write.
+// Nothing in it is modified once it has been stored in readState.
+type channelView struct {
+	trend        map[int]*models.StatRecord
+	trendJSON    []byte
+	personal     map[string]*models.Statistic
+	personalOnce sync.Once
+	personalJSON []byte
+	related      map[int][]models.RelatedItem
+	rankedOnce   sync.Once
+	ranked       []int
+	catalog      *models.Catalog // synchronized itself, so shared with the model
+	dims         map[string]map[string]map[int]*models.StatRecord
+	experiments  map[string]map[string]map[int]models.VariantCounts
+	funnels      map[string]map[int][]int64
+	positions    *models.PositionData
+	ticks        []models.RisingTick // rising window, shared with the model
+	risingOnce   sync.Once
+	rising       []RisingItem
+	bytes        int64 // trend copy, trend JSON and fingerprint copies
+}
+
+// personalBytes serializes the fingerprint map on first use. Unlike the trend
+// data it is not encoded eagerly: with many fingerprints the encoding is
+// costly and most aggregation ticks never see a /fingerprints request.
+func (v *channelView) personalBytes() []byte {
+	v.personalOnce.Do(func() {
+		gson, err := json.Marshal(v.personal)
+		if err != nil {
+			gson = nullJSON
+		}
+		v.personalJSON = gson
+	})
+	return v.personalJSON
+}
+
+// rankedIDs returns the trend item IDs ordered by views, highest first,
+// sorting them on first use.
+func (v *channelView) rankedIDs() []int {
+	v.rankedOnce.Do(func() {
+		ids := make([]int, 0, len(v.trend))
+		for id := range v.trend {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool {
+			a, b := v.trend[ids[i]], v.trend[ids[j]]
+			if a.Views != b.Views {
+				return a.Views > b.Views
+			}
+			return ids[i] < ids[j]
+		})
+		v.ranked = ids
+	})
+	return v.ranked
+}
+
+// risingItems ranks the rising window on first use.
+func (v *channelView) risingItems(minViews int) []RisingItem {
+	v.risingOnce.Do(func() {
+		v.rising = risingItems(v.ticks, minViews)
+	})
+	return v.rising
+}
+
+// readState is the set of channel views readers see, swapped atomically.
+type readState struct {
+	channels map[string]*channelView
+	names    []string
+}
+
+// channelChanges records what a write touched in one channel, so that
+// publish only rebuilds those parts of the read view.
+type channelChanges struct {
+	full         bool // the whole channel was replaced
+	fingerprints map[string]struct{}
+	related      map[int]struct{} // items whose neighbor lists changed
+	dims         map[models.DimKey]struct{}
+	experiments  map[string]struct{}
+	funnels      map[string]struct{}
+	batch        BatchStats
+}
+
+func newChannelChanges() *channelChanges {
+	return &channelChanges{
+		fingerprints: make(map[string]struct{}),
+		related:      make(map[int]struct{}),
+		dims:         make(map[models.DimKey]struct{}),
+		experiments:  make(map[string]struct{}),
+		funnels:      make(map[string]struct{}),
+	}
+}
+
+// BatchStats is what one aggregation folded into a channel: the number of
+// events and, with anomalies.enabled, the views per item.
+type BatchStats struct {
+	Events int
+	Items  map[int]int
+}
+
+// ingestShard is one stripe of the ingestion buffer. Every shard keeps its
+// own active/inactive pair so that concurrent writers rarely share a lock.
+type ingestShard struct {
+	mu          sync.Mutex
+	activeIdx   int
+	buffers     [2][]*models.InputStats
+	prevBufSize int
+	_           [64]byte // keeps neighbouring shard locks off one cache line
+}
+
+// swap makes the inactive buffer active and returns the drained one.
+func (sh *ingestShard) swap() []*models.InputStats {
+	sh.mu.Lock()
+	sh.activeIdx = 1 - sh.activeIdx
+	inactiveIdx := 1 - sh.activeIdx
+	data := sh.buffers[inactiveIdx]
+	sh.buffers[inactiveIdx] = nil
+	if len(data) > 0 {
+		sh.prevBufSize = len(data)
+	}
+	sh.mu.Unlock()
+	return data
 }
 
 type StatisticService struct {
-	mu             sync.Mutex
-	activeIdx      int
-	buffers        [2][]*models.InputStats
-	prevBufSize    int
+	shards         []ingestShard
+	seed           maphash.Seed
 	chMu           sync.RWMutex
 	channels       map[string]*channelData
 	cachedChannels []string
+	writeMu        sync.Mutex // serializes model writes with publishing
+	view           atomic.Pointer[readState]
+	memory         *memoryBudget
+	related        structures.RelatedConfig
+	feed           feedRanker
+	dimensions     structures.DimensionsConfig
+	experiments    structures.ExperimentsConfig
+	positions      structures.PositionsConfig
+	rising         structures.RisingConfig
+	risingTick     time.Time // start of the current rising tick
+	now            func() time.Time
+	batchItems     bool // count views per item in BatchStats
+	lastBatch      atomic.Pointer[map[string]BatchStats]
+	funnels        map[string][]structures.FunnelConfig
 }
 
 func (ss *StatisticService) getOrCreateChannel(name string) *channelData {
@@ -56,13 +237,26 @@ func (ss *StatisticService) getOrCreateChannel(name string) *channelData {
 		return nil
 	}
 	ch := &channelData{
-		statistic: &models.Statistic{
-			Data: make(map[int]*models.StatRecord),
-		},
-		personalStats: &models.PersonalStats{
-			Data: make(map[string]*models.Statistic),
-		},
+		statistic:     models.NewTrendStats(),
+		personalStats: models.NewPersonalStats(),
+		catalog:       models.NewCatalog(),
 	}
+	if ss.related.Enabled {
+		ch.related = models.NewRelatedStats(ss.related.MaxNeighbors)
+	}
+	if allowed := ss.allowedDimensions(name); len(allowed) > 0 {
+		ch.dims = models.NewDimensionStats(allowed, ss.dimensions.MaxValues)
+	}
+	if ss.experiments.Enabled {
+		ch.experiments = models.NewExperimentStats(ss.experiments.MaxExperiments, ss.experiments.MaxVariants, ss.experiments.MaxItems, ss.experiments.TTL)
+	}
+	if ss.positions.Enabled {
+		ch.positions = models.NewPositionStats(ss.positions.MaxPositions, ss.positions.MinViews)
+	}
+	if ss.rising.Enabled {
+		ch.rising = models.NewRisingStats(ss.rising.Ticks)
+	}
+	ch.funnels = ss.newChannelFunnels(name)
 	ss.channels[name] = ch
 	ss.rebuildChannelCache()
 	return ch
@@ -77,27 +271,61 @@ func (ss *StatisticService) rebuildChannelCache() {
 	ss.cachedChannels = channels
 }
 
+// AddStats appends to the shard of the event's fingerprint, so the events of
+// one fingerprint keep their order through aggregation; first-touch pairing
+// and funnels depend on it. Anonymous events go to a random shard; the
+// runtime-backed generator has per-thread state, so picking one costs no
+// shared memory traffic.
 func (ss *StatisticService) AddStats(data *models.InputStats) {
-	ss.mu.Lock()
-	idx := ss.activeIdx
-	if ss.buffers[idx] == nil && ss.prevBufSize > 0 {
-		ss.buffers[idx] = make([]*models.InputStats, 0, ss.prevBufSize)
+	data.MarkReceived()
+	sh := &ss.shards[ss.shardFor(data.Fingerprint)]
+	sh.mu.Lock()
+	idx := sh.activeIdx
+	if sh.buffers[idx] == nil && sh.prevBufSize > 0 {
+		sh.buffers[idx] = make([]*models.InputStats, 0, sh.prevBufSize)
 	}
-	ss.buffers[idx] = append(ss.buffers[idx], data)
-	ss.mu.Unlock()
+	sh.buffers[idx] = append(sh.buffers[idx], data)
+	sh.mu.Unlock()
 }
 
-func (ss *StatisticService) AggregateStats() {
-	ss.mu.Lock()
-	ss.activeIdx = 1 - ss.activeIdx
-	inactiveIdx := 1 - ss.activeIdx
-	data := ss.buffers[inactiveIdx]
-	ss.buffers[inactiveIdx] = nil
-	if len(data) > 0 {
-		ss.prevBufSize = len(data)
+func (ss *StatisticService) shardFor(fp string) int {
+	if fp == "" || len(ss.shards) == 1 {
+		return rand.IntN(len(ss.shards))
+	}
+	return int(maphash.String(ss.seed, fp) % uint64(len(ss.shards)))
+}
+
+// AggregateStats swaps the buffers of every shard, folds the drained batches
+// into the channel models and returns the number of processed items.
+func (ss *StatisticService) AggregateStats() int {
+	batches := make([][]*models.InputStats, len(ss.shards))
+	total := 0
+	for i := range ss.shards {
+		batches[i] = ss.shards[i].swap()
+		total += len(batches[i])
 	}
-	ss.mu.Unlock()
 
+	ss.writeMu.Lock()
+	defer ss.writeMu.Unlock()
+
+	changes := make(map[string]*channelChanges)
+	for _, data := range batches {
+		ss.foldBatch(data, changes)
+	}
+	ss.learnPositions(changes)
+	ss.recordBatch(changes)
+	ss.tickRising(changes)
+	ss.expireFunnels()
+	ss.expireExperiments(changes)
+	ss.publishWithinBudget(changes)
+	return total
+}
+
+// foldBatch applies buffered items to the channel models and records the
+// touched channels, fingerprints and related items in changes. Items a
+// fingerprint touches for the first time are paired with its recent history
+// before the fingerprint's records are updated.
+func (ss *StatisticService) foldBatch(data []*models.InputStats, changes map[string]*channelChanges) {
 	for _, v := range data {
 		chName := v.Channel
 		if chName == "" {
@@ -107,96 +335,426 @@ func (ss *StatisticService) AggregateStats() {
 		if ch == nil {
 			continue
 		}
-		ch.statistic.IncStats(v)
+		c, ok := changes[chName]
+		if !ok {
+			c = newChannelChanges()
+			changes[chName] = c
+		}
+
+		if ch.related != nil && v.Fingerprint != "" {
+			if fresh, history := ch.personalStats.Partition(v.Fingerprint, v.ItemIDs(), ss.related.HistorySize); len(fresh) > 0 {
+				ch.related.Observe(fresh, history, c.related)
+			}
+		}
+		if ch.positions != nil {
+			ch.positions.Observe(v)
+			ch.statistic.IncStatsCorrected(v, ch.positions.Propensity)
+		} else {
+			ch.statistic.IncStats(v)
+		}
 		ch.personalStats.IncStats(v)
+		if ch.rising != nil {
+			ch.rising.Observe(v)
+		}
+		c.fingerprints[v.Fingerprint] = struct{}{}
+		c.batch.Events++
+		if ss.batchItems {
+			if c.batch.Items == nil {
+				c.batch.Items = make(map[int]int)
+			}
+			v.EachView(func(id int) { c.batch.Items[id]++ })
+		}
+		if ch.dims != nil {
+			ch.dims.IncStats(v, c.dims)
+		}
+		if ch.experiments != nil {
+			ch.experiments.IncStats(v, c.experiments)
+		}
+		for name, f := range ch.funnels {
+			if f.Observe(v) {
+				c.funnels[name] = struct{}{}
+			}
+		}
 	}
 }
 
-func (ss *StatisticService) GetStatistic(channel string) map[int]*models.StatRecord {
+// publish builds a new read state from the models and swaps it in. Channels
+// listed in changes get a fresh trend view and only their touched
+// fingerprints, related items, dimension values, experiments and funnels are
+// re-copied; a nil changes map rebuilds every channel.
+// Untouched channels keep their previous view. Callers must hold writeMu
+// (or own the service exclusively, as the constructor does).
+func (ss *StatisticService) publish(changes map[string]*channelChanges) {
+	prev := ss.view.Load()
+
 	ss.chMu.RLock()
-	ch, ok := ss.channels[channel]
+	next := &readState{
+		channels: make(map[string]*channelView, len(ss.channels)),
+		names:    ss.cachedChannels,
+	}
+	for name, ch := range ss.channels {
+		var old *channelView
+		if prev != nil {
+			old = prev.channels[name]
+		}
+		c, changed := changes[name]
+		if old != nil && changes != nil && !changed {
+			next.channels[name] = old
+			continue
+		}
+		if old == nil || changes == nil || c.full {
+			next.channels[name] = buildChannelView(ch, nil, nil)
+			continue
+		}
+		next.channels[name] = buildChannelView(ch, old, c)
+	}
 	ss.chMu.RUnlock()
-	if ok {
-		return ch.statistic.GetData()
+
+	ss.view.Store(next)
+}
+
+// buildChannelView copies a channel's models into a new view. With a previous
+// view and its changes only the touched fingerprints, neighbor lists,
+// dimension values, experiments and funnels are copied; every other entry is
+// shared with the previous view.
+func buildChannelView(ch *channelData, old *channelView, c *channelChanges) *channelView {
+	if old != nil && c.batch.Events == 0 {
+		return refreshChannelView(ch, old, c)
+	}
+	v := &channelView{trend: ch.statistic.GetData(), catalog: ch.catalog}
+	if ch.positions != nil {
+		v.positions = ch.positions.GetData()
+	}
+	if ch.rising != nil {
+		v.ticks = ch.rising.Ticks()
+	}
+	if gson, err := json.Marshal(v.trend); err == nil {
+		v.trendJSON = gson
+	} else {
+		v.trendJSON = nullJSON
+	}
+
+	v.bytes = int64(len(v.trendJSON)) + models.RecordMapBytes(len(v.trend))
+	if old == nil {
+		v.personal = ch.personalStats.GetData()
+		for fp, stat := range v.personal {
+			v.bytes += models.StatisticBytes(fp, stat)
+		}
+		if ch.related != nil {
+			v.related = ch.related.GetData()
+		}
+		if ch.dims != nil {
+			v.dims = ch.dims.GetData()
+		}
+		if ch.experiments != nil {
+			v.experiments = ch.experiments.GetData()
+		}
+		v.funnels = make(map[string]map[int][]int64, len(ch.funnels))
+		for name, f := range ch.funnels {
+			v.funnels[name] = f.Counts()
+		}
+		return v
+	}
+	v.personal = make(map[string]*models.Statistic, len(old.personal)+len(c.fingerprints))
+	for fp, stat := range old.personal {
+		v.personal[fp] = stat
+	}
+	for fp := range c.fingerprints {
+		if stat, ok := ch.personalStats.Get(fp); ok {
+			v.personal[fp] = stat
+		}
+	}
+	for fp, stat := range v.personal {
+		v.bytes += models.StatisticBytes(fp, stat)
+	}
+
+	v.related = updateRelatedView(ch, old.related, c.related)
+	v.dims = updateDimsView(ch, old.dims, c.dims)
+	v.experiments = updateExperimentsView(ch, old.experiments, c.experiments)
+	v.funnels = updateFunnelsView(ch, old.funnels, c.funnels)
+	return v
+}
+
+// refreshChannelView builds the view of a channel that folded no events,
+// like on an empty aggregation: only its rising ticks, experiments and
+// funnels can have moved, so the records and their JSON are shared with the
+// previous view instead of being copied and encoded again.
+func refreshChannelView(ch *channelData, old *channelView, c *channelChanges) *channelView {
+	v := &channelView{
+		trend:     old.trend,
+		trendJSON: old.trendJSON,
+		personal:  old.personal,
+		related:   old.related,
+		catalog:   old.catalog,
+		dims:      old.dims,
+		positions: old.positions,
+		bytes:     old.bytes,
+	}
+	if ch.rising != nil {
+		v.ticks = ch.rising.Ticks()
+	}
+	v.experiments = updateExperimentsView(ch, old.experiments, c.experiments)
+	v.funnels = updateFunnelsView(ch, old.funnels, c.funnels)
+	return v
+}
+
+func updateRelatedView(ch *channelData, old map[int][]models.RelatedItem, touched map[int]struct{}) map[int][]models.RelatedItem {
+	if ch.related == nil || len(touched) == 0 {
+		return old
+	}
+	related := make(map[int][]models.RelatedItem, len(old)+len(touched))
+	for id, items := range old {
+		related[id] = items
+	}
+	for id := range touched {
+		if items := ch.related.Get(id); items != nil {
+			related[id] = items
+		} else {
+			delete(related, id)
+		}
+	}
+	return related
+}
+
+func updateDimsView(ch *channelData, old map[string]map[string]map[int]*models.StatRecord, touched map[models.DimKey]struct{}) map[string]map[string]map[int]*models.StatRecord {
+	if ch.dims == nil || len(touched) == 0 {
+		return old
+	}
+	dims := make(map[string]map[string]map[int]*models.StatRecord, len(old))
+	for name, values := range old {
+		dims[name] = values
+	}
+	copied := make(map[string]bool)
+	for key := range touched {
+		if !copied[key.Name] {
+			values := make(map[string]map[int]*models.StatRecord, len(dims[key.Name])+1)
+			for value, records := range dims[key.Name] {
+				values[value] = records
+			}
+			dims[key.Name] = values
+			copied[key.Name] = true
+		}
+		if records, ok := ch.dims.Get(key); ok {
+			dims[key.Name][key.Value] = records
+		}
+	}
+	return dims
+}
+
+// recordBatch publishes the batch statistics of the channels the last
+// aggregation folded events into.
+func (ss *StatisticService) recordBatch(changes map[string]*channelChanges) {
+	batches := make(map[string]BatchStats, len(changes))
+	for name, c := range changes {
+		if c.batch.Events > 0 {
+			batches[name] = c.batch
+		}
+	}
+	ss.lastBatch.Store(&batches)
+}
+
+// GetLastBatch returns the batch statistics of the last aggregation by
+// channel; channels without events are missing.
+func (ss *StatisticService) GetLastBatch() map[string]BatchStats {
+	if batches := ss.lastBatch.Load(); batches != nil {
+		return *batches
 	}
 	return nil
 }
 
+func (ss *StatisticService) channelView(channel string) *channelView {
+	return ss.view.Load().channels[channel]
+}
+
+func (ss *StatisticService) GetStatistic(channel string) map[int]*models.StatRecord {
+	if v := ss.channelView(channel); v != nil {
+		return v.trend
+	}
+	return nil
+}
+
+func (ss *StatisticService) GetStatisticJSON(channel string) []byte {
+	if v := ss.channelView(channel); v != nil {
+		return v.trendJSON
+	}
+	return nullJSON
+}
+
 func (ss *StatisticService) GetPersonalStatistic(channel string) map[string]*models.Statistic {
-	ss.chMu.RLock()
-	ch, ok := ss.channels[channel]
-	ss.chMu.RUnlock()
-	if ok {
-		return ch.personalStats.GetData()
+	if v := ss.channelView(channel); v != nil {
+		return v.personal
 	}
 	return nil
 }
 
+func (ss *StatisticService) GetPersonalStatisticJSON(channel string) []byte {
+	if v := ss.channelView(channel); v != nil {
+		return v.personalBytes()
+	}
+	return nullJSON
+}
+
 func (ss *StatisticService) GetByFingerprint(channel, fp string) map[int]*models.StatRecord {
-	ss.chMu.RLock()
-	ch, ok := ss.channels[channel]
-	ss.chMu.RUnlock()
-	if ok {
-		if val, ok := ch.personalStats.Get(fp); ok {
-			return val.GetData()
+	if v := ss.channelView(channel); v != nil {
+		if val, ok := v.personal[fp]; ok {
+			return val.Data
 		}
 	}
 	return nil
 }
 
-func (ss *StatisticService) PutChannelData(channel string, trend map[int]*models.StatRecord, personal map[string]*models.Statistic) {
+// GetRelated returns up to n items most often touched by the same
+// fingerprints as id, heaviest first.
+func (ss *StatisticService) GetRelated(channel string, id, n int) []models.RelatedItem {
+	if v := ss.channelView(channel); v != nil {
+		items := v.related[id]
+		return items[:min(max(n, 0), len(items))]
+	}
+	return nil
+}
+
+func (ss *StatisticService) PutChannelData(channel string, data *models.ChannelData) {
+	ss.writeMu.Lock()
+	defer ss.writeMu.Unlock()
+
 	ch := ss.getOrCreateChannel(channel)
 	if ch == nil {
 		return
 	}
-	ch.statistic.PutData(trend)
-	ch.personalStats.PutData(personal)
+	ch.statistic.PutData(data.TrendStats)
+	ch.personalStats.PutData(data.PersonalStats)
+	if ch.related != nil && data.Related != nil {
+		ch.related.PutData(data.Related)
+	}
+	if data.Catalog != nil {
+		ch.catalog.PutData(data.Catalog)
+	}
+	if ch.dims != nil && data.Dims != nil {
+		ch.dims.PutData(data.Dims)
+	}
+	if ch.experiments != nil && data.Experiments != nil {
+		ch.experiments.PutData(data.Experiments, data.ExpSeen)
+	}
+	if ch.positions != nil && data.Positions != nil {
+		ch.positions.PutData(data.Positions)
+	}
+	for name, f := range ch.funnels {
+		if fd, ok := data.Funnels[name]; ok && fd != nil {
+			f.PutData(fd)
+		}
+	}
+
+	changes := map[string]*channelChanges{channel: {full: true}}
+	ss.publishWithinBudget(changes)
 }
 
 func (ss *StatisticService) GetChannels() []string {
-	ss.chMu.RLock()
-	defer ss.chMu.RUnlock()
-	return ss.cachedChannels
+	return ss.view.Load().names
 }
 
+// GetSnapshot returns the published read state as a Storage. The snapshot
+// shares its maps with the read view and must be treated as read-only.
 func (ss *StatisticService) GetSnapshot() *models.Storage {
-	ss.chMu.RLock()
-	defer ss.chMu.RUnlock()
-
+	state := ss.view.Load()
 	storage := &models.Storage{
-		Channels: make(map[string]*models.ChannelData, len(ss.channels)),
+		Channels: make(map[string]*models.ChannelData, len(state.channels)),
 	}
-	for name, ch := range ss.channels {
+	for name, v := range state.channels {
 		storage.Channels[name] = &models.ChannelData{
-			TrendStats:    ch.statistic.GetData(),
-			PersonalStats: ch.personalStats.GetData(),
+			TrendStats:    v.trend,
+			PersonalStats: v.personal,
+			Related:       v.related,
+			Catalog:       v.catalog.Data(),
+			Dims:          v.dims,
+			Experiments:   v.experiments,
+			ExpSeen:       ss.experimentsSeen(name),
+			Funnels:       ss.funnelSnapshot(name),
+			Positions:     v.positions,
 		}
 	}
 	return storage
 }
 
 func (ss *StatisticService) GetBufferSize() int {
-	ss.mu.Lock()
-	n := len(ss.buffers[ss.activeIdx])
-	ss.mu.Unlock()
+	n := 0
+	for i := range ss.shards {
+		sh := &ss.shards[i]
+		sh.mu.Lock()
+		n += len(sh.buffers[sh.activeIdx])
+		sh.mu.Unlock()
+	}
 	return n
 }
 
 func (ss *StatisticService) GetRecordCount(channel string) int {
-	ss.chMu.RLock()
-	ch, ok := ss.channels[channel]
-	ss.chMu.RUnlock()
-	if ok {
-		return ch.statistic.Len()
+	if v := ss.channelView(channel); v != nil {
+		return len(v.trend)
 	}
 	return 0
 }
 
-func NewStatisticService() StatisticServiceInterface {
+// NewStatisticService creates the service with one ingestion shard per P.
+func NewStatisticService(conf *structures.Config) StatisticServiceInterface {
+	return newStatisticService(conf, runtime.GOMAXPROCS(0))
+}
+
+func newStatisticService(conf *structures.Config, shards int) *StatisticService {
 	ss := &StatisticService{
-		activeIdx: 0,
-		channels:  make(map[string]*channelData),
+		shards:      make([]ingestShard, max(shards, 1)),
+		seed:        maphash.MakeSeed(),
+		channels:    make(map[string]*channelData),
+		memory:      newMemoryBudget(conf.Memory),
+		related:     conf.Related,
+		dimensions:  conf.Dimensions,
+		experiments: conf.Experiments,
+		positions:   conf.Positions,
+		rising:      conf.Rising,
+		risingTick:  time.Now(),
+		now:         time.Now,
+		batchItems:  conf.Anomalies.Enabled,
+		funnels:     conf.Funnels,
+		feed: feedRanker{
+			seenWeight:   min(max(conf.Feed.SeenWeight, 0), 1),
+			relatedBoost: max(conf.Feed.RelatedBoost, 0),
+		},
+	}
+	if ss.related.MaxNeighbors <= 0 {
+		ss.related.MaxNeighbors = defaultRelatedNeighbors
+	}
+	if ss.related.HistorySize <= 0 {
+		ss.related.HistorySize = defaultRelatedHistory
+	}
+	if ss.dimensions.MaxValues <= 0 {
+		ss.dimensions.MaxValues = defaultDimensionValues
+	}
+	if ss.experiments.MaxExperiments <= 0 {
+		ss.experiments.MaxExperiments = defaultMaxExperiments
+	}
+	if ss.experiments.MaxVariants <= 0 {
+		ss.experiments.MaxVariants = defaultMaxVariants
+	}
+	if ss.experiments.MaxItems <= 0 {
+		ss.experiments.MaxItems = defaultExperimentItems
+	}
+	if ss.experiments.TTL == 0 {
+		ss.experiments.TTL = defaultExperimentTTL
+	}
+	if ss.experiments.Alpha <= 0 || ss.experiments.Alpha >= 1 {
+		ss.experiments.Alpha = defaultExperimentAlpha
+	}
+	if ss.positions.MaxPositions <= 0 {
+		ss.positions.MaxPositions = defaultMaxPositions
+	}
+	if ss.positions.MinViews <= 0 {
+		ss.positions.MinViews = defaultPositionMinViews
+	}
+	if ss.rising.Ticks < 2 {
+		ss.rising.Ticks = defaultRisingTicks
+	}
+	if ss.rising.MinViews <= 0 {
+		ss.rising.MinViews = defaultRisingMinViews
 	}
 	ss.getOrCreateChannel(DefaultChannel)
+	ss.publish(nil)
 	return ss
 }
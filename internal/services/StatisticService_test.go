@@ -1,9 +1,12 @@
 package services
 
 import (
+	"encoding/json"
 	"fmt"
+	"runtime"
 	"sort"
 	"ssd/internal/models"
+	"ssd/internal/structures"
 	"sync"
 	"testing"
 
@@ -12,7 +15,7 @@ import (
 )
 
 func newService() *StatisticService {
-	return NewStatisticService().(*StatisticService)
+	return NewStatisticService(&structures.Config{}).(*StatisticService)
 }
 
 func TestNewStatisticService_DefaultChannel(t *testing.T) {
@@ -26,7 +29,7 @@ func TestAddStats_BuffersSingleItem(t *testing.T) {
 	input := &models.InputStats{Views: []string{"1"}, Channel: DefaultChannel}
 	ss.AddStats(input)
 
-	assert.Len(t, ss.buffers[ss.activeIdx], 1)
+	assert.Equal(t, 1, ss.GetBufferSize())
 }
 
 func TestAddStats_MultipleItems(t *testing.T) {
@@ -34,20 +37,82 @@ func TestAddStats_MultipleItems(t *testing.T) {
 	for i := 0; i < 5; i++ {
 		ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: DefaultChannel})
 	}
-	assert.Len(t, ss.buffers[ss.activeIdx], 5)
+	assert.Equal(t, 5, ss.GetBufferSize())
+}
+
+func TestAddStats_SpreadsAcrossShards(t *testing.T) {
+	ss := newStatisticService(&structures.Config{}, 4)
+	for i := 0; i < 400; i++ {
+		ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: DefaultChannel})
+	}
+
+	used := 0
+	for i := range ss.shards {
+		if len(ss.shards[i].buffers[ss.shards[i].activeIdx]) > 0 {
+			used++
+		}
+	}
+	assert.Greater(t, used, 1)
+	assert.Equal(t, 400, ss.GetBufferSize())
+}
+
+func TestAddStats_FingerprintKeepsOrder(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	ss := newStatisticService(&structures.Config{Funnels: map[string][]structures.FunnelConfig{
+		DefaultChannel: {{Name: "click", Steps: []string{"view", "click"}}},
+	}}, 8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(fp string) {
+			defer wg.Done()
+			ss.AddStats(&models.InputStats{Fingerprint: fp, Views: []string{"1"}})
+			ss.AddStats(&models.InputStats{Fingerprint: fp, Clicks: []string{"1"}})
+		}(fmt.Sprintf("fp%d", i))
+	}
+	wg.Wait()
+	ss.AggregateStats()
+
+	steps := ss.GetFunnel(DefaultChannel, "click", nil).Steps
+	assert.Equal(t, int64(200), steps[0].Count)
+	assert.Equal(t, int64(200), steps[1].Count, "every click is folded after its view")
 }
 
 func TestAggregateStats_SwapsBuffers(t *testing.T) {
-	ss := newService()
+	ss := newStatisticService(&structures.Config{}, 4)
 	ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: DefaultChannel})
 
-	initialIdx := ss.activeIdx
+	initialIdx := make([]int, len(ss.shards))
+	for i := range ss.shards {
+		initialIdx[i] = ss.shards[i].activeIdx
+	}
 	ss.AggregateStats()
 
-	assert.NotEqual(t, initialIdx, ss.activeIdx)
-	// Inactive buffer should be cleared
-	inactiveIdx := 1 - ss.activeIdx
-	assert.Nil(t, ss.buffers[inactiveIdx])
+	for i := range ss.shards {
+		sh := &ss.shards[i]
+		assert.NotEqual(t, initialIdx[i], sh.activeIdx)
+		// Inactive buffer should be cleared
+		assert.Nil(t, sh.buffers[1-sh.activeIdx])
+	}
+	assert.Equal(t, 0, ss.GetBufferSize())
+}
+
+func TestAggregateStats_MergesAllShards(t *testing.T) {
+	ss := newStatisticService(&structures.Config{}, 8)
+	for i := 0; i < 100; i++ {
+		ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: DefaultChannel})
+	}
+
+	assert.Equal(t, 100, ss.AggregateStats())
+	assert.Equal(t, 100, ss.GetStatistic(DefaultChannel)[1].Views)
+}
+
+func TestNewStatisticService_AtLeastOneShard(t *testing.T) {
+	ss := newStatisticService(&structures.Config{}, 0)
+	require.Len(t, ss.shards, 1)
+	ss.AddStats(&models.InputStats{Views: []string{"1"}})
+	assert.Equal(t, 1, ss.AggregateStats())
 }
 
 func TestAggregateStats_ProcessesData(t *testing.T) {
@@ -136,7 +201,7 @@ func TestPutChannelData(t *testing.T) {
 	personal := map[string]*models.Statistic{
 		"fp1": {Data: map[int]*models.StatRecord{1: {Views: 50}}},
 	}
-	ss.PutChannelData("restored", trend, personal)
+	ss.PutChannelData("restored", &models.ChannelData{TrendStats: trend, PersonalStats: personal})
 
 	data := ss.GetStatistic("restored")
 	require.NotNil(t, data)
@@ -227,3 +292,208 @@ func TestConcurrent_AddAndAggregate(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestReadView_StableUntilNextAggregation(t *testing.T) {
+	ss := newService()
+	ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: DefaultChannel})
+	ss.AggregateStats()
+
+	before := ss.GetStatistic(DefaultChannel)
+	ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: DefaultChannel})
+	assert.Equal(t, 1, before[1].Views, "buffered data must not leak into the view")
+
+	ss.AggregateStats()
+	assert.Equal(t, 1, before[1].Views, "published views are never mutated")
+	assert.Equal(t, 2, ss.GetStatistic(DefaultChannel)[1].Views)
+}
+
+func TestGetStatisticJSON_MatchesView(t *testing.T) {
+	ss := newService()
+	ss.AddStats(&models.InputStats{Views: []string{"1", "2"}, Clicks: []string{"2"}, Channel: DefaultChannel})
+	ss.AggregateStats()
+
+	var decoded map[int]*models.StatRecord
+	require.NoError(t, json.Unmarshal(ss.GetStatisticJSON(DefaultChannel), &decoded))
+	assert.Equal(t, ss.GetStatistic(DefaultChannel), decoded)
+}
+
+func TestGetStatisticJSON_NonexistentChannel(t *testing.T) {
+	ss := newService()
+	assert.Equal(t, "null", string(ss.GetStatisticJSON("nonexistent")))
+	assert.Equal(t, "null", string(ss.GetPersonalStatisticJSON("nonexistent")))
+}
+
+func TestGetPersonalStatisticJSON(t *testing.T) {
+	ss := newService()
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"1"}, Channel: DefaultChannel})
+	ss.AggregateStats()
+
+	var decoded map[string]*models.Statistic
+	require.NoError(t, json.Unmarshal(ss.GetPersonalStatisticJSON(DefaultChannel), &decoded))
+	require.Contains(t, decoded, "fp1")
+	assert.Equal(t, 1, decoded["fp1"].Data[1].Views)
+}
+
+func TestPublish_SharesUntouchedFingerprints(t *testing.T) {
+	ss := newService()
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"1"}, Channel: DefaultChannel})
+	ss.AddStats(&models.InputStats{Fingerprint: "fp2", Views: []string{"1"}, Channel: DefaultChannel})
+	ss.AggregateStats()
+	first := ss.GetPersonalStatistic(DefaultChannel)
+
+	ss.AddStats(&models.InputStats{Fingerprint: "fp2", Views: []string{"2"}, Channel: DefaultChannel})
+	ss.AggregateStats()
+	second := ss.GetPersonalStatistic(DefaultChannel)
+
+	assert.Same(t, first["fp1"], second["fp1"])
+	assert.NotSame(t, first["fp2"], second["fp2"])
+	assert.Len(t, second["fp2"].Data, 2)
+}
+
+func TestPublish_UntouchedChannelKeepsView(t *testing.T) {
+	ss := newService()
+	ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: "news"})
+	ss.AggregateStats()
+	newsJSON := ss.GetStatisticJSON("news")
+
+	ss.AddStats(&models.InputStats{Views: []string{"1"}, Channel: DefaultChannel})
+	ss.AggregateStats()
+
+	assert.Same(t, &newsJSON[0], &ss.GetStatisticJSON("news")[0])
+}
+
+func TestConcurrent_ReadViewsDuringAggregation(t *testing.T) {
+	ss := newService()
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for _, rec := range ss.GetStatistic(DefaultChannel) {
+					_ = rec.Views
+				}
+				_ = ss.GetPersonalStatisticJSON(DefaultChannel)
+				_ = ss.GetByFingerprint(DefaultChannel, "fp1")
+			}
+		}()
+	}
+
+	for i := 0; i < 50; i++ {
+		ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"1"}, Channel: DefaultChannel})
+		ss.AggregateStats()
+	}
+	close(stop)
+	wg.Wait()
+
+	assert.Equal(t, 50, ss.GetStatistic(DefaultChannel)[1].Views)
+}
+
+func benchmarkAddStats(b *testing.B, ss *StatisticService) {
+	input := &models.InputStats{
+		Fingerprint: "fp1",
+		Views:       []string{"1", "2", "3"},
+		Clicks:      []string{"1"},
+		Channel:     DefaultChannel,
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			ss.AddStats(input)
+		}
+	})
+	b.StopTimer()
+	ss.AggregateStats()
+}
+
+// BenchmarkAddStats_Sharded measures write throughput with one shard per P.
+// Compare scaling with: go test -run '^$' -bench AddStats -cpu 1,2,4,8 ./internal/services/
+func BenchmarkAddStats_Sharded(b *testing.B) {
+	benchmarkAddStats(b, newService())
+}
+
+// BenchmarkAddStats_SingleShard is the single-mutex baseline.
+func BenchmarkAddStats_SingleShard(b *testing.B) {
+	benchmarkAddStats(b, newStatisticService(&structures.Config{}, 1))
+}
+
+func newRelatedService() *StatisticService {
+	return NewStatisticService(&structures.Config{
+		Related: structures.RelatedConfig{Enabled: true},
+	}).(*StatisticService)
+}
+
+func TestRelated_DisabledByDefault(t *testing.T) {
+	ss := newService()
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"1", "2"}})
+	ss.AggregateStats()
+
+	assert.Nil(t, ss.GetRelated(DefaultChannel, 1, 10))
+	assert.Nil(t, ss.GetSnapshot().Channels[DefaultChannel].Related)
+}
+
+func TestRelated_BuiltFromFingerprintHistory(t *testing.T) {
+	ss := newRelatedService()
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"1"}})
+	ss.AddStats(&models.InputStats{Fingerprint: "fp2", Views: []string{"1"}})
+	ss.AggregateStats()
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"2"}})
+	ss.AddStats(&models.InputStats{Fingerprint: "fp2", Views: []string{"2"}, Clicks: []string{"3"}})
+	ss.AggregateStats()
+
+	assert.Equal(t, []models.RelatedItem{{ID: 2, Weight: 2}, {ID: 3, Weight: 1}}, ss.GetRelated(DefaultChannel, 1, 10))
+	assert.Equal(t, []models.RelatedItem{{ID: 2, Weight: 2}}, ss.GetRelated(DefaultChannel, 1, 1))
+	assert.Empty(t, ss.GetRelated(DefaultChannel, 1, 0))
+}
+
+func TestRelated_RepeatVisitsCountOnce(t *testing.T) {
+	ss := newRelatedService()
+	for i := 0; i < 5; i++ {
+		ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"1", "2"}})
+		ss.AggregateStats()
+	}
+
+	assert.Equal(t, []models.RelatedItem{{ID: 2, Weight: 1}}, ss.GetRelated(DefaultChannel, 1, 10))
+}
+
+func TestRelated_AnonymousEventsIgnored(t *testing.T) {
+	ss := newRelatedService()
+	ss.AddStats(&models.InputStats{Views: []string{"1", "2"}})
+	ss.AggregateStats()
+
+	assert.Nil(t, ss.GetRelated(DefaultChannel, 1, 10))
+}
+
+func TestRelated_PreviousViewUnchanged(t *testing.T) {
+	ss := newRelatedService()
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"1", "2"}})
+	ss.AggregateStats()
+	before := ss.GetRelated(DefaultChannel, 1, 10)
+
+	ss.AddStats(&models.InputStats{Fingerprint: "fp2", Views: []string{"1", "2"}})
+	ss.AggregateStats()
+
+	assert.Equal(t, 1, before[0].Weight)
+	assert.Equal(t, 2, ss.GetRelated(DefaultChannel, 1, 10)[0].Weight)
+}
+
+func TestRelated_SnapshotRoundTrip(t *testing.T) {
+	ss := newRelatedService()
+	ss.AddStats(&models.InputStats{Fingerprint: "fp1", Views: []string{"1", "2"}, Channel: "news"})
+	ss.AggregateStats()
+
+	data := ss.GetSnapshot().Channels["news"]
+	require.NotNil(t, data.Related)
+
+	restored := newRelatedService()
+	restored.PutChannelData("news", data)
+	assert.Equal(t, ss.GetRelated("news", 1, 10), restored.GetRelated("news", 1, 10))
+}
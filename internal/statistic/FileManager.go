@@ -11,20 +11,25 @@ import (
 
 type FileManager struct {
 	service    services.StatisticServiceInterface
+	rules      services.RuleEngineInterface
 	compressor interfaces.CompressorInterface
 	logger     providers.Logger
 }
 
-func NewFileManager(compressor interfaces.CompressorInterface, service services.StatisticServiceInterface, logger providers.Logger) *FileManager {
+func NewFileManager(compressor interfaces.CompressorInterface, service services.StatisticServiceInterface, rules services.RuleEngineInterface, logger providers.Logger) *FileManager {
 	return &FileManager{
 		compressor: compressor,
 		service:    service,
+		rules:      rules,
 		logger:     logger,
 	}
 }
 
+// SaveToFile writes the snapshot of the statistic and the rules changed
+// through the admin API.
 func (f *FileManager) SaveToFile(fileName string) error {
 	storage := f.service.GetSnapshot()
+	storage.Rules = f.rules.Overrides()
 
 	jsonData, err := json.Marshal(storage)
 	if err != nil {
@@ -90,8 +95,9 @@ func (f *FileManager) LoadFromFile(fileName string) error {
 			if cd.PersonalStats == nil {
 				cd.PersonalStats = make(map[string]*models.Statistic)
 			}
-			f.service.PutChannelData(ch, cd.TrendStats, cd.PersonalStats)
+			f.service.PutChannelData(ch, cd)
 		}
+		f.rules.Restore(storage.Rules)
 		return nil
 	}
 
@@ -103,7 +109,10 @@ func (f *FileManager) LoadFromFile(fileName string) error {
 	}
 	if err := json.Unmarshal(decompressedData, &oldStorage); err == nil && oldStorage.TrendStats != nil && oldStorage.PersonalStats != nil {
 		f.logger.Warnf(providers.TypeApp, "Migration from v2 format successful")
-		f.service.PutChannelData(services.DefaultChannel, oldStorage.TrendStats, oldStorage.PersonalStats)
+		f.service.PutChannelData(services.DefaultChannel, &models.ChannelData{
+			TrendStats:    oldStorage.TrendStats,
+			PersonalStats: oldStorage.PersonalStats,
+		})
 		return nil
 	}
 
@@ -114,7 +123,10 @@ func (f *FileManager) LoadFromFile(fileName string) error {
 		return err
 	}
 	f.logger.Warnf(providers.TypeApp, "Migration from v1 format successful")
-	f.service.PutChannelData(services.DefaultChannel, stats, make(map[string]*models.Statistic))
+	f.service.PutChannelData(services.DefaultChannel, &models.ChannelData{
+		TrendStats:    stats,
+		PersonalStats: make(map[string]*models.Statistic),
+	})
 
 	return nil
 }
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"ssd/internal/models"
 	"ssd/internal/services"
+	"ssd/internal/structures"
 	"ssd/internal/testutil"
 	"testing"
 
@@ -17,7 +18,7 @@ import (
 func newTestFileManager(compressor *testutil.MockCompressor) (*FileManager, *testutil.MockStatisticService) {
 	svc := &testutil.MockStatisticService{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(compressor, svc, logger)
+	fm := NewFileManager(compressor, svc, &testutil.MockRuleEngine{}, logger)
 	return fm, svc
 }
 
@@ -25,13 +26,13 @@ func TestFileManager_SaveToFile_CreatesFile(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "test.dat")
 
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	svc.AddStats(&models.InputStats{Views: []string{"1"}, Channel: "default"})
 	svc.AggregateStats()
 
 	comp := &testutil.MockCompressor{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 
 	err := fm.SaveToFile(path)
 	require.NoError(t, err)
@@ -48,10 +49,10 @@ func TestFileManager_SaveToFile_AtomicWrite(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "data.dat")
 
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	comp := &testutil.MockCompressor{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 
 	require.NoError(t, fm.SaveToFile(path))
 
@@ -79,6 +80,7 @@ func TestFileManager_LoadFromFile_V3Format(t *testing.T) {
 			"news": {
 				TrendStats:    map[int]*models.StatRecord{2: {Views: 20}},
 				PersonalStats: map[string]*models.Statistic{},
+				Related:       map[int][]models.RelatedItem{2: {{ID: 3, Weight: 4}}},
 			},
 		},
 	}
@@ -90,6 +92,11 @@ func TestFileManager_LoadFromFile_V3Format(t *testing.T) {
 	require.NoError(t, fm.LoadFromFile(path))
 
 	require.Len(t, svc.PutCalls, 2)
+	for _, call := range svc.PutCalls {
+		if call.Channel == "news" {
+			assert.Equal(t, 4, call.Data.Related[2][0].Weight)
+		}
+	}
 }
 
 func TestFileManager_LoadFromFile_V2Format(t *testing.T) {
@@ -151,9 +158,9 @@ func TestFileManager_CompressError(t *testing.T) {
 		},
 	}
 
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 
 	err := fm.SaveToFile(path)
 	assert.Error(t, err)
@@ -183,7 +190,7 @@ func TestFileManager_Roundtrip(t *testing.T) {
 	path := filepath.Join(dir, "roundtrip.dat")
 
 	// Save with real service
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	svc.AddStats(&models.InputStats{
 		Fingerprint: "fp1",
 		Views:       []string{"1", "2"},
@@ -199,12 +206,12 @@ func TestFileManager_Roundtrip(t *testing.T) {
 
 	comp := &testutil.MockCompressor{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 	require.NoError(t, fm.SaveToFile(path))
 
 	// Load into new service
-	svc2 := services.NewStatisticService()
-	fm2 := NewFileManager(comp, svc2, logger)
+	svc2 := services.NewStatisticService(&structures.Config{})
+	fm2 := NewFileManager(comp, svc2, &testutil.MockRuleEngine{}, logger)
 	require.NoError(t, fm2.LoadFromFile(path))
 
 	data := svc2.GetStatistic("default")
@@ -217,6 +224,25 @@ func TestFileManager_Roundtrip(t *testing.T) {
 	assert.Equal(t, 1, newsData[3].Views)
 }
 
+func TestFileManager_RoundtripRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.dat")
+	conf := &structures.Config{Rules: []structures.RuleConfig{
+		{Name: "disk", Metric: services.RulePersistFailures, Threshold: 2},
+	}}
+	svc := services.NewStatisticService(&structures.Config{})
+	rules := services.NewRuleEngine(conf, svc)
+	require.NoError(t, rules.Update([]services.Rule{{Name: "busy", Metric: services.RuleBatchEvents, Threshold: 50}}, []string{"disk"}))
+
+	comp := &testutil.MockCompressor{}
+	logger := &testutil.MockLogger{}
+	require.NoError(t, NewFileManager(comp, svc, rules, logger).SaveToFile(path))
+
+	restarted := services.NewRuleEngine(conf, svc)
+	require.NoError(t, NewFileManager(comp, services.NewStatisticService(&structures.Config{}), restarted, logger).LoadFromFile(path))
+
+	assert.Equal(t, []services.Rule{{Name: "busy", Metric: services.RuleBatchEvents, Threshold: 50}}, restarted.Rules())
+}
+
 func TestFileManager_V3NilFields(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "nil.dat")
@@ -230,10 +256,10 @@ func TestFileManager_V3NilFields(t *testing.T) {
 	jsonData, _ := json.Marshal(storage)
 	require.NoError(t, os.WriteFile(path, jsonData, 0644))
 
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	comp := &testutil.MockCompressor{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 
 	require.NoError(t, fm.LoadFromFile(path))
 
@@ -265,10 +291,10 @@ func TestFileManager_MultipleChannels(t *testing.T) {
 	jsonData, _ := json.Marshal(storage)
 	require.NoError(t, os.WriteFile(path, jsonData, 0644))
 
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	comp := &testutil.MockCompressor{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 
 	require.NoError(t, fm.LoadFromFile(path))
 
@@ -0,0 +1,109 @@
+package statistic
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	json "github.com/goccy/go-json"
+	"io"
+	"os"
+	"path/filepath"
+	"ssd/internal/models"
+	"ssd/internal/providers"
+	"ssd/internal/services"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// catalogColumns is the header a CSV catalog file must start with. Tags are
+// separated by "|", attributes are "key=value" pairs separated by ";".
+var catalogColumns = []string{"channel", "id", "category", "tags", "published_at", "attributes"}
+
+// LoadCatalog merges item metadata from a JSON or CSV file (chosen by
+// extension) into the channel catalogs. JSON files map channel names to
+// objects keyed by item ID.
+func (f *FileManager) LoadCatalog(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var catalog map[string]map[int]*models.CatalogItem
+	if strings.EqualFold(filepath.Ext(fileName), ".csv") {
+		catalog, err = parseCatalogCSV(file)
+	} else {
+		err = json.NewDecoder(file).Decode(&catalog)
+	}
+	if err != nil {
+		return fmt.Errorf("catalog %s: %w", fileName, err)
+	}
+
+	items := 0
+	for ch, data := range catalog {
+		f.service.UpdateCatalog(ch, data, nil)
+		items += len(data)
+	}
+	f.logger.Infof(providers.TypeApp, "Loaded %d catalog items from %s", items, fileName)
+	return nil
+}
+
+func parseCatalogCSV(r io.Reader) (map[string]map[int]*models.CatalogItem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = len(catalogColumns)
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	for i, col := range catalogColumns {
+		if strings.TrimSpace(header[i]) != col {
+			return nil, fmt.Errorf("unexpected column %q, want %q", header[i], col)
+		}
+	}
+
+	catalog := make(map[string]map[int]*models.CatalogItem)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return catalog, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		id, err := strconv.Atoi(row[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid id %q", line, row[1])
+		}
+		item := &models.CatalogItem{Category: row[2]}
+		if row[3] != "" {
+			item.Tags = strings.Split(row[3], "|")
+		}
+		if row[4] != "" {
+			t, err := time.Parse(time.RFC3339, row[4])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid published_at %q", line, row[4])
+			}
+			item.PublishedAt = &t
+		}
+		if row[5] != "" {
+			item.Attributes = make(map[string]string)
+			for _, pair := range strings.Split(row[5], ";") {
+				k, v, _ := strings.Cut(pair, "=")
+				item.Attributes[k] = v
+			}
+		}
+
+		ch := row[0]
+		if ch == "" {
+			ch = services.DefaultChannel
+		}
+		if catalog[ch] == nil {
+			catalog[ch] = make(map[int]*models.CatalogItem)
+		}
+		catalog[ch][id] = item
+	}
+}
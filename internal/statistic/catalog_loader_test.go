@@ -0,0 +1,90 @@
+package statistic
+
+import (
+	"os"
+	"path/filepath"
+	"ssd/internal/services"
+	"ssd/internal/structures"
+	"ssd/internal/testutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileManager_LoadCatalog_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	raw := `{"news":{"1":{"category":"sports","tags":["euro"]},"2":{"category":"tech"}}}`
+	require.NoError(t, os.WriteFile(path, []byte(raw), 0644))
+
+	fm, svc := newTestFileManager(&testutil.MockCompressor{})
+	require.NoError(t, fm.LoadCatalog(path))
+
+	require.Len(t, svc.CatalogData["news"], 2)
+	assert.Equal(t, []string{"euro"}, svc.CatalogData["news"][1].Tags)
+}
+
+func TestFileManager_LoadCatalog_CSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.csv")
+	raw := "channel,id,category,tags,published_at,attributes\n" +
+		"news,1,sports,euro|football,2026-05-01T10:00:00Z,author=kim;lang=en\n" +
+		",2,tech,,,\n"
+	require.NoError(t, os.WriteFile(path, []byte(raw), 0644))
+
+	fm, svc := newTestFileManager(&testutil.MockCompressor{})
+	require.NoError(t, fm.LoadCatalog(path))
+
+	item := svc.CatalogData["news"][1]
+	require.NotNil(t, item)
+	assert.Equal(t, "sports", item.Category)
+	assert.Equal(t, []string{"euro", "football"}, item.Tags)
+	assert.Equal(t, 2026, item.PublishedAt.Year())
+	assert.Equal(t, map[string]string{"author": "kim", "lang": "en"}, item.Attributes)
+
+	item = svc.CatalogData[services.DefaultChannel][2]
+	require.NotNil(t, item)
+	assert.Nil(t, item.Tags)
+	assert.Nil(t, item.PublishedAt)
+}
+
+func TestFileManager_LoadCatalog_CSVErrors(t *testing.T) {
+	cases := map[string]string{
+		"header": "channel,id,kind,tags,published_at,attributes\n",
+		"id":     "channel,id,category,tags,published_at,attributes\nnews,x,,,,\n",
+		"time":   "channel,id,category,tags,published_at,attributes\nnews,1,,,may,\n",
+		"fields": "channel,id,category,tags,published_at,attributes\nnews,1\n",
+	}
+	for name, raw := range cases {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "catalog.csv")
+			require.NoError(t, os.WriteFile(path, []byte(raw), 0644))
+
+			fm, _ := newTestFileManager(&testutil.MockCompressor{})
+			assert.Error(t, fm.LoadCatalog(path))
+		})
+	}
+}
+
+func TestFileManager_LoadCatalog_Missing(t *testing.T) {
+	fm, _ := newTestFileManager(&testutil.MockCompressor{})
+	assert.Error(t, fm.LoadCatalog("/nonexistent/catalog.json"))
+}
+
+func TestScheduler_Restore_LoadsCatalog(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "catalog.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"default":{"7":{"category":"sports"}}}`), 0644))
+
+	svc := services.NewStatisticService(&structures.Config{})
+	logger := &testutil.MockLogger{}
+	fm := NewFileManager(&testutil.MockCompressor{}, svc, &testutil.MockRuleEngine{}, logger)
+	conf := testConfig(filepath.Join(dir, "missing.dat"))
+	conf.Catalog.File = path
+
+	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{}, &testutil.MockAnomalyDetector{}, &testutil.MockRuleEngine{}, &testutil.MockWebhooks{}, &testutil.MockStream{})
+	require.NoError(t, s.Restore())
+
+	item, ok := svc.GetCatalogItem(services.DefaultChannel, 7)
+	require.True(t, ok)
+	assert.Equal(t, "sports", item.Category)
+}
@@ -9,14 +9,29 @@ import (
 	"time"
 )
 
+const (
+	TriggerInterval = "interval"
+	TriggerBuffer   = "buffer"
+
+	// bufferCheckInterval is how often the active buffer size is polled
+	// for the buffer rules and the adaptive trigger.
+	bufferCheckInterval     = 100 * time.Millisecond
+	defaultMinAggregateSpan = 1 * time.Second
+)
+
 type Scheduler struct {
-	config      *structures.Config
-	logger      providers.Logger
-	service     services.StatisticServiceInterface
-	fileManager *FileManager
-	metrics     providers.MetricsProviderInterface
-	opsMu       sync.Mutex
-	stopCh      chan struct{}
+	config        *structures.Config
+	logger        providers.Logger
+	service       services.StatisticServiceInterface
+	fileManager   *FileManager
+	metrics       providers.MetricsProviderInterface
+	anomalies     services.AnomalyDetectorInterface
+	rules         services.RuleEngineInterface
+	webhooks      providers.WebhookProviderInterface
+	stream        providers.StreamProviderInterface
+	opsMu         sync.Mutex
+	stopCh        chan struct{}
+	lastAggregate time.Time
 }
 
 func (s *Scheduler) Init() {
@@ -31,12 +46,22 @@ func (s *Scheduler) Init() {
 		defer persistTicker.Stop()
 		defer aggregateTicker.Stop()
 
+		// The buffer is polled for the buffer rules and, when enabled, the
+		// adaptive trigger.
+		bufferTicker := time.NewTicker(bufferCheckInterval)
+		defer bufferTicker.Stop()
+
 		for {
 			select {
 			case <-persistTicker.C:
 				s.doPersist()
 			case <-aggregateTicker.C:
-				s.doAggregate()
+				s.doAggregate(TriggerInterval)
+			case <-bufferTicker.C:
+				s.sendRuleEvents(s.rules.CheckBuffer())
+				if s.shouldAggregateEarly() {
+					s.doAggregate(TriggerBuffer)
+				}
 			case <-s.stopCh:
 				return
 			}
@@ -44,6 +69,23 @@ func (s *Scheduler) Init() {
 	}()
 }
 
+// shouldAggregateEarly reports whether the active buffer has outgrown
+// statistic.maxBufferSize and the minimum spacing since the previous run
+// has elapsed.
+func (s *Scheduler) shouldAggregateEarly() bool {
+	if s.config.Statistic.MaxBufferSize <= 0 || s.service.GetBufferSize() < s.config.Statistic.MaxBufferSize {
+		return false
+	}
+	minSpan := s.config.Statistic.MinInterval
+	if minSpan <= 0 {
+		minSpan = defaultMinAggregateSpan
+	}
+	s.opsMu.Lock()
+	last := s.lastAggregate
+	s.opsMu.Unlock()
+	return time.Since(last) >= minSpan
+}
+
 func (s *Scheduler) doPersist() {
 	s.opsMu.Lock()
 	defer s.opsMu.Unlock()
@@ -51,6 +93,7 @@ func (s *Scheduler) doPersist() {
 	start := time.Now()
 	err := s.fileManager.SaveToFile(s.config.Persistence.FilePath)
 	s.metrics.ObservePersistenceDuration(time.Since(start))
+	s.sendRuleEvents(s.rules.AfterPersist(err))
 	if err != nil {
 		s.logger.Errorf(providers.TypeApp, "Error while persisting data: %s", err)
 		return
@@ -58,33 +101,83 @@ func (s *Scheduler) doPersist() {
 	s.logger.Infof(providers.TypeApp, "Persisted data to file %s", s.config.Persistence.FilePath)
 }
 
-func (s *Scheduler) doAggregate() {
+func (s *Scheduler) doAggregate(trigger string) {
 	s.opsMu.Lock()
 	defer s.opsMu.Unlock()
 
-	s.logger.Infof(providers.TypeApp, "Aggregate statistic...")
-	s.service.AggregateStats()
+	start := time.Now()
+	batch := s.service.AggregateStats()
+	prev := s.lastAggregate
+	s.lastAggregate = time.Now()
+	s.metrics.ObserveAggregationDuration(trigger, s.lastAggregate.Sub(start))
+	s.metrics.ObserveAggregationBatchSize(trigger, batch)
+	if batch == 0 {
+		// An empty run only advanced the rising ticks and the funnel and
+		// experiment expiry, so the gauges and the live streams have nothing
+		// new. The detector still sees the empty batch, which is how a total
+		// loss of traffic reads as a drop, and rules still re-arm.
+		s.detectAnomalies(prev)
+		s.sendRuleEvents(s.rules.AfterAggregate())
+		return
+	}
 	for _, ch := range s.service.GetChannels() {
 		s.metrics.SetRecordsTotal(ch, s.service.GetRecordCount(ch))
 	}
-	s.logger.Infof(providers.TypeApp, "Statistic aggregated")
+	for ch, bytes := range s.service.GetMemoryStats().Channels {
+		s.metrics.SetMemoryBytes(ch, bytes)
+	}
+	s.logger.Infof(providers.TypeApp, "Statistic aggregated (%s): %d items", trigger, batch)
+	s.detectAnomalies(prev)
+	s.sendRuleEvents(s.rules.AfterAggregate())
+	s.stream.Publish()
+}
+
+// detectAnomalies checks the batches of the aggregation that just finished
+// and sends an event for every alert. prev is the end of the previous
+// aggregation, zero for the first one.
+func (s *Scheduler) detectAnomalies(prev time.Time) {
+	var elapsed time.Duration
+	if !prev.IsZero() {
+		elapsed = s.lastAggregate.Sub(prev)
+	}
+	for _, alert := range s.anomalies.Detect(s.service.GetLastBatch(), elapsed) {
+		s.logger.Warnf(providers.TypeApp, "Anomaly %s: %s %.1f vs baseline %.1f (z=%.1f)", alert.ID, alert.Kind, alert.Value, alert.Baseline, alert.ZScore)
+		s.webhooks.Send(services.AlertEvent, alert)
+	}
 }
 
+// sendRuleEvents logs the fired rules and sends an event for each.
+func (s *Scheduler) sendRuleEvents(events []services.RuleEvent) {
+	for _, ev := range events {
+		s.logger.Warnf(providers.TypeApp, "Rule %s fired: %s %g reached %g", ev.Rule, ev.Metric, ev.Value, ev.Threshold)
+		s.webhooks.Send(services.RuleEventName, ev)
+	}
+}
+
+// Stop ends the scheduling loop and closes the live streams, which would
+// otherwise keep the server from shutting down.
 func (s *Scheduler) Stop() {
 	if s.stopCh != nil {
 		close(s.stopCh)
 	}
+	s.stream.Close()
 }
 
 func (s *Scheduler) Close() {
+	s.webhooks.Close()
 	s.fileManager.Close()
 }
 
+// Restore loads the persisted snapshot, then merges the configured catalog
+// file on top of the restored catalogs.
 func (s *Scheduler) Restore() error {
 	err := s.fileManager.LoadFromFile(s.config.Persistence.FilePath)
 	if err != nil {
 		return err
 	}
+	if s.config.Catalog.File != "" {
+		return s.fileManager.LoadCatalog(s.config.Catalog.File)
+	}
 	return nil
 }
 
@@ -94,6 +187,7 @@ func (s *Scheduler) Persist() error {
 
 	s.logger.Infof(providers.TypeApp, "Persisting statistic to file...")
 	err := s.fileManager.SaveToFile(s.config.Persistence.FilePath)
+	s.sendRuleEvents(s.rules.AfterPersist(err))
 	if err != nil {
 		s.logger.Errorf(providers.TypeApp, "Error while persisting data: %s", err)
 		return err
@@ -101,12 +195,16 @@ func (s *Scheduler) Persist() error {
 	return nil
 }
 
-func NewScheduler(config *structures.Config, logger providers.Logger, service services.StatisticServiceInterface, fileManager *FileManager, metrics providers.MetricsProviderInterface) interfaces.SchedulerInterface {
+func NewScheduler(config *structures.Config, logger providers.Logger, service services.StatisticServiceInterface, fileManager *FileManager, metrics providers.MetricsProviderInterface, anomalies services.AnomalyDetectorInterface, rules services.RuleEngineInterface, webhooks providers.WebhookProviderInterface, stream providers.StreamProviderInterface) interfaces.SchedulerInterface {
 	return &Scheduler{
 		config:      config,
 		logger:      logger,
 		service:     service,
 		fileManager: fileManager,
 		metrics:     metrics,
+		anomalies:   anomalies,
+		rules:       rules,
+		webhooks:    webhooks,
+		stream:      stream,
 	}
 }
@@ -43,13 +43,13 @@ func TestScheduler_Restore_Success(t *testing.T) {
 	jsonData, _ := json.Marshal(storage)
 	require.NoError(t, os.WriteFile(path, jsonData, 0644))
 
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	comp := &testutil.MockCompressor{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 	conf := testConfig(path)
 
-	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{})
+	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{}, &testutil.MockAnomalyDetector{}, &testutil.MockRuleEngine{}, &testutil.MockWebhooks{}, &testutil.MockStream{})
 	require.NoError(t, s.Restore())
 
 	data := svc.GetStatistic("default")
@@ -57,13 +57,13 @@ func TestScheduler_Restore_Success(t *testing.T) {
 }
 
 func TestScheduler_Restore_FileNotExist(t *testing.T) {
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	comp := &testutil.MockCompressor{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 	conf := testConfig("/nonexistent/file.dat")
 
-	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{})
+	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{}, &testutil.MockAnomalyDetector{}, &testutil.MockRuleEngine{}, &testutil.MockWebhooks{}, &testutil.MockStream{})
 	err := s.Restore()
 	assert.NoError(t, err)
 }
@@ -73,13 +73,13 @@ func TestScheduler_Restore_CorruptedFile(t *testing.T) {
 	path := filepath.Join(dir, "corrupt.dat")
 	require.NoError(t, os.WriteFile(path, []byte("not json"), 0644))
 
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	comp := &testutil.MockCompressor{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 	conf := testConfig(path)
 
-	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{})
+	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{}, &testutil.MockAnomalyDetector{}, &testutil.MockRuleEngine{}, &testutil.MockWebhooks{}, &testutil.MockStream{})
 	err := s.Restore()
 	assert.Error(t, err)
 }
@@ -88,16 +88,16 @@ func TestScheduler_Persist_Success(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "persist.dat")
 
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	svc.AddStats(&models.InputStats{Views: []string{"1"}, Channel: "default"})
 	svc.AggregateStats()
 
 	comp := &testutil.MockCompressor{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 	conf := testConfig(path)
 
-	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{})
+	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{}, &testutil.MockAnomalyDetector{}, &testutil.MockRuleEngine{}, &testutil.MockWebhooks{}, &testutil.MockStream{})
 	require.NoError(t, s.Persist())
 
 	_, err := os.Stat(path)
@@ -110,24 +110,24 @@ func TestScheduler_Persist_WriteError(t *testing.T) {
 			return nil, errors.New("compress error")
 		},
 	}
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 	conf := testConfig("/tmp/test.dat")
 
-	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{})
+	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{}, &testutil.MockAnomalyDetector{}, &testutil.MockRuleEngine{}, &testutil.MockWebhooks{}, &testutil.MockStream{})
 	err := s.Persist()
 	assert.Error(t, err)
 }
 
 func TestScheduler_StopNilCron(t *testing.T) {
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	comp := &testutil.MockCompressor{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 	conf := testConfig("/tmp/test.dat")
 
-	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{})
+	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{}, &testutil.MockAnomalyDetector{}, &testutil.MockRuleEngine{}, &testutil.MockWebhooks{}, &testutil.MockStream{})
 	// Should not panic with nil cron
 	s.Stop()
 }
@@ -136,15 +136,195 @@ func TestScheduler_InitAndStop(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "lifecycle.dat")
 
-	svc := services.NewStatisticService()
+	svc := services.NewStatisticService(&structures.Config{})
 	comp := &testutil.MockCompressor{}
 	logger := &testutil.MockLogger{}
-	fm := NewFileManager(comp, svc, logger)
+	fm := NewFileManager(comp, svc, &testutil.MockRuleEngine{}, logger)
 	conf := testConfig(path)
 
-	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{})
+	s := NewScheduler(conf, logger, svc, fm, &testutil.MockMetrics{}, &testutil.MockAnomalyDetector{}, &testutil.MockRuleEngine{}, &testutil.MockWebhooks{}, &testutil.MockStream{})
 	s.Init()
 	// Give the cron a moment to start
 	time.Sleep(50 * time.Millisecond)
 	s.Stop()
 }
+
+func newAdaptiveScheduler(t *testing.T, maxBuffer int, minInterval time.Duration) (*Scheduler, *testutil.MockStatisticService, *testutil.MockMetrics) {
+	svc := &testutil.MockStatisticService{}
+	logger := &testutil.MockLogger{}
+	fm := NewFileManager(&testutil.MockCompressor{}, svc, &testutil.MockRuleEngine{}, logger)
+	conf := testConfig(filepath.Join(t.TempDir(), "adaptive.dat"))
+	conf.Statistic.Interval = time.Hour
+	conf.Persistence.SaveInterval = time.Hour
+	conf.Statistic.MaxBufferSize = maxBuffer
+	conf.Statistic.MinInterval = minInterval
+	metrics := &testutil.MockMetrics{}
+
+	s := NewScheduler(conf, logger, svc, fm, metrics, &testutil.MockAnomalyDetector{}, &testutil.MockRuleEngine{}, &testutil.MockWebhooks{}, &testutil.MockStream{}).(*Scheduler)
+	return s, svc, metrics
+}
+
+func TestScheduler_AdaptiveTrigger_AggregatesEarly(t *testing.T) {
+	s, svc, metrics := newAdaptiveScheduler(t, 3, 10*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		svc.AddStats(&models.InputStats{Views: []string{"1"}})
+	}
+
+	s.Init()
+	defer s.Stop()
+
+	require.Eventually(t, func() bool {
+		triggers, _ := metrics.AggregationCalls()
+		return len(triggers) > 0
+	}, 2*time.Second, 10*time.Millisecond)
+
+	triggers, sizes := metrics.AggregationCalls()
+	assert.Equal(t, TriggerBuffer, triggers[0])
+	assert.Equal(t, 3, sizes[0])
+	assert.Equal(t, 0, svc.GetBufferSize())
+}
+
+func TestScheduler_AdaptiveTrigger_BelowThreshold(t *testing.T) {
+	s, svc, _ := newAdaptiveScheduler(t, 10, 10*time.Millisecond)
+	svc.AddStats(&models.InputStats{Views: []string{"1"}})
+
+	assert.False(t, s.shouldAggregateEarly())
+}
+
+func TestScheduler_AdaptiveTrigger_RespectsMinInterval(t *testing.T) {
+	s, svc, _ := newAdaptiveScheduler(t, 1, time.Hour)
+	svc.AddStats(&models.InputStats{Views: []string{"1"}})
+	assert.True(t, s.shouldAggregateEarly())
+
+	s.doAggregate(TriggerBuffer)
+	svc.AddStats(&models.InputStats{Views: []string{"1"}})
+	assert.False(t, s.shouldAggregateEarly())
+}
+
+func TestScheduler_AdaptiveTrigger_Disabled(t *testing.T) {
+	s, svc, metrics := newAdaptiveScheduler(t, 0, 0)
+	for i := 0; i < 100; i++ {
+		svc.AddStats(&models.InputStats{Views: []string{"1"}})
+	}
+
+	s.Init()
+	time.Sleep(3 * bufferCheckInterval)
+	s.Stop()
+
+	triggers, _ := metrics.AggregationCalls()
+	assert.Empty(t, triggers)
+	assert.Equal(t, 100, svc.GetBufferSize())
+}
+
+func TestScheduler_DoAggregate_RecordsMetrics(t *testing.T) {
+	s, svc, metrics := newAdaptiveScheduler(t, 0, 0)
+	svc.AddStats(&models.InputStats{Views: []string{"1"}})
+	svc.AddStats(&models.InputStats{Views: []string{"2"}})
+
+	s.doAggregate(TriggerInterval)
+
+	triggers, sizes := metrics.AggregationCalls()
+	assert.Equal(t, []string{TriggerInterval}, triggers)
+	assert.Equal(t, []int{2}, sizes)
+	assert.Equal(t, 1, svc.AggregateCalls)
+}
+
+func TestScheduler_DoAggregate_SendsAnomalies(t *testing.T) {
+	s, svc, _ := newAdaptiveScheduler(t, 0, 0)
+	detector := &testutil.MockAnomalyDetector{}
+	webhooks := &testutil.MockWebhooks{}
+	s.anomalies, s.webhooks = detector, webhooks
+	svc.LastBatch = map[string]services.BatchStats{"news": {Events: 5}}
+
+	svc.AddStats(&models.InputStats{Views: []string{"1"}, Channel: "news"})
+	s.doAggregate(TriggerInterval)
+	alert := services.Alert{ID: "news:spike:1", Channel: "news", Kind: services.AlertSpike}
+	detector.Next = []services.Alert{alert}
+	svc.AddStats(&models.InputStats{Views: []string{"1"}, Channel: "news"})
+	s.doAggregate(TriggerInterval)
+
+	require.Len(t, detector.Batches, 2)
+	assert.Equal(t, svc.LastBatch, detector.Batches[0])
+	assert.Zero(t, detector.Elapsed[0], "no previous aggregation")
+	assert.Positive(t, detector.Elapsed[1])
+	assert.Equal(t, []string{services.AlertEvent}, webhooks.Events)
+	assert.Equal(t, []any{alert}, webhooks.Data)
+
+	s.Close()
+	assert.True(t, webhooks.Closed)
+}
+
+func TestScheduler_SendsRuleEvents(t *testing.T) {
+	s, _, _ := newAdaptiveScheduler(t, 0, 0)
+	rules := &testutil.MockRuleEngine{}
+	webhooks := &testutil.MockWebhooks{}
+	s.rules, s.webhooks = rules, webhooks
+
+	event := services.RuleEvent{Rule: "busy", Metric: services.RuleBatchEvents, Value: 9, Threshold: 5}
+	rules.Next = []services.RuleEvent{event}
+	s.doAggregate(TriggerInterval)
+	assert.Equal(t, 1, rules.AggregateCalls)
+
+	s.doPersist()
+	require.Len(t, rules.PersistErrors, 1)
+	assert.NoError(t, rules.PersistErrors[0])
+
+	s.config.Persistence.FilePath = filepath.Join(t.TempDir(), "missing", "dir", "x.dat")
+	failed := services.RuleEvent{Rule: "disk", Metric: services.RulePersistFailures, Value: 1, Threshold: 1}
+	rules.Next = []services.RuleEvent{failed}
+	assert.Error(t, s.Persist())
+	require.Len(t, rules.PersistErrors, 2)
+	assert.Error(t, rules.PersistErrors[1])
+
+	assert.Equal(t, []string{services.RuleEventName, services.RuleEventName}, webhooks.Events)
+	assert.Equal(t, []any{event, failed}, webhooks.Data)
+}
+
+func TestScheduler_PollsBufferRules(t *testing.T) {
+	s, _, _ := newAdaptiveScheduler(t, 0, 0)
+	rules := &testutil.MockRuleEngine{}
+	webhooks := &testutil.MockWebhooks{}
+	s.rules, s.webhooks = rules, webhooks
+
+	event := services.RuleEvent{Rule: "backlog", Metric: services.RuleBuffer, Value: 9, Threshold: 5}
+	rules.Next = []services.RuleEvent{event}
+	s.Init()
+	defer s.Stop()
+
+	assert.Eventually(t, func() bool { return rules.BufferChecks() >= 2 }, time.Second, bufferCheckInterval/2,
+		"polled without the adaptive trigger")
+	assert.Equal(t, []any{event}, webhooks.Data)
+}
+
+func TestScheduler_PublishesStream(t *testing.T) {
+	s, svc, _ := newAdaptiveScheduler(t, 0, 0)
+	stream := &testutil.MockStream{}
+	s.stream = stream
+
+	svc.AddStats(&models.InputStats{Views: []string{"1"}})
+	s.doAggregate(TriggerInterval)
+	assert.Equal(t, 1, stream.PublishCalls)
+
+	s.Stop()
+	assert.True(t, stream.Closed)
+}
+
+func TestScheduler_EmptyRunSkipsGaugesAndStreams(t *testing.T) {
+	s, svc, metrics := newAdaptiveScheduler(t, 0, 0)
+	detector := &testutil.MockAnomalyDetector{}
+	rules := &testutil.MockRuleEngine{}
+	stream := &testutil.MockStream{}
+	s.anomalies, s.rules, s.stream = detector, rules, stream
+
+	s.doAggregate(TriggerInterval)
+
+	assert.Equal(t, 1, svc.AggregateCalls, "the service still advances rising ticks and expiry")
+	triggers, sizes := metrics.AggregationCalls()
+	assert.Equal(t, []string{TriggerInterval}, triggers)
+	assert.Equal(t, []int{0}, sizes)
+	require.Len(t, detector.Batches, 1, "an empty batch still reaches the detector")
+	assert.Empty(t, detector.Batches[0])
+	assert.Zero(t, stream.PublishCalls)
+	assert.Equal(t, 1, rules.AggregateCalls, "quiet channels re-arm their rules")
+	assert.False(t, s.lastAggregate.IsZero())
+}
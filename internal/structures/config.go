@@ -3,8 +3,15 @@ package structures
 import "time"
 
 type Server struct {
-	Host string `yaml:"host" validate:"required"`
-	Port int    `yaml:"port" validate:"required|uint|min:1"`
+	Host       string           `yaml:"host" validate:"required"`
+	Port       int              `yaml:"port" validate:"required|uint|min:1"`
+	UnixSocket UnixSocketConfig `yaml:"unixSocket"`
+}
+
+type UnixSocketConfig struct {
+	Path string `yaml:"path" validate:"unixPath"`
+	Mode uint32 `yaml:"mode" validate:"uint"`
+	Only bool   `yaml:"only"` // serve HTTP on the socket only, not on host:port
 }
 
 type Persistence struct {
@@ -19,7 +26,21 @@ type LoggerConfig struct {
 }
 
 type StatisticConfig struct {
-	Interval time.Duration `yaml:"interval" validate:"required|min:1"`
+	Interval      time.Duration `yaml:"interval" validate:"required|min:1"`
+	MaxBufferSize int           `yaml:"maxBufferSize"`
+	MinInterval   time.Duration `yaml:"minInterval"`
+}
+
+type GrpcConfig struct {
+	Enabled bool `yaml:"enabled"`
+	Port    int  `yaml:"port" validate:"uint"`
+}
+
+type UDPConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	Port          int  `yaml:"port" validate:"uint"`
+	RateLimit     int  `yaml:"rateLimit" validate:"uint"` // datagrams per second per source, 0 = unlimited
+	MaxPacketSize int  `yaml:"maxPacketSize" validate:"uint"`
 }
 
 type CacheConfig struct {
@@ -31,14 +52,153 @@ type MetricsConfig struct {
 	Enabled bool `yaml:"enabled"`
 }
 
+type MemoryConfig struct {
+	MaxBytes int64  `yaml:"maxBytes"`
+	Eviction string `yaml:"eviction" validate:"in:items,fingerprints"`
+}
+
+type RelatedConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	MaxNeighbors int  `yaml:"maxNeighbors" validate:"uint"`
+	HistorySize  int  `yaml:"historySize" validate:"uint"`
+}
+
+type FeedConfig struct {
+	SeenWeight   float64 `yaml:"seenWeight"`
+	RelatedBoost float64 `yaml:"relatedBoost"`
+}
+
+type CatalogConfig struct {
+	File string `yaml:"file"`
+}
+
+type DimensionsConfig struct {
+	Allowed   []string            `yaml:"allowed"`
+	MaxValues int                 `yaml:"maxValues" validate:"uint"`
+	Channels  map[string][]string `yaml:"channels"`
+}
+
+type UserAgentConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	RulesFile      string        `yaml:"rulesFile"`
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+	BotAction      string        `yaml:"botAction" validate:"in:drop,segregate"`
+	BotSuffix      string        `yaml:"botSuffix"`
+	Dimensions     bool          `yaml:"dimensions"`
+}
+
+type GeoIPConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Database       string        `yaml:"database"`
+	ReloadInterval time.Duration `yaml:"reloadInterval"`
+	TrustedProxies []string      `yaml:"trustedProxies"`
+	Region         bool          `yaml:"region"`
+}
+
+type ExperimentsConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	MaxExperiments int           `yaml:"maxExperiments" validate:"uint"`
+	MaxVariants    int           `yaml:"maxVariants" validate:"uint"`
+	MaxItems       int           `yaml:"maxItems" validate:"uint"`
+	TTL            time.Duration `yaml:"ttl"`
+	Alpha          float64       `yaml:"alpha"`
+}
+
+type PositionsConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	MaxPositions int  `yaml:"maxPositions" validate:"uint"`
+	MinViews     int  `yaml:"minViews" validate:"uint"`
+}
+
+type RisingConfig struct {
+	Enabled  bool `yaml:"enabled"`
+	Ticks    int  `yaml:"ticks" validate:"uint"`
+	MinViews int  `yaml:"minViews" validate:"uint"`
+}
+
+type AnomaliesConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Alpha     float64       `yaml:"alpha"`
+	Threshold float64       `yaml:"threshold"`
+	Warmup    int           `yaml:"warmup" validate:"uint"`
+	MinVolume int           `yaml:"minVolume" validate:"uint"`
+	TopItems  int           `yaml:"topItems" validate:"uint"`
+	Cooldown  time.Duration `yaml:"cooldown"`
+	MaxAlerts int           `yaml:"maxAlerts" validate:"uint"`
+}
+
+type WebhooksConfig struct {
+	URLs           []string      `yaml:"urls"`
+	Timeout        time.Duration `yaml:"timeout"`
+	MaxRetries     int           `yaml:"maxRetries" validate:"uint"`
+	Backoff        time.Duration `yaml:"backoff"`
+	QueueSize      int           `yaml:"queueSize" validate:"uint"`
+	Secret         string        `yaml:"secret"`
+	DeadLetterFile string        `yaml:"deadLetterFile"`
+}
+
+type RuleConfig struct {
+	Name      string  `yaml:"name"`
+	Metric    string  `yaml:"metric"`
+	Channel   string  `yaml:"channel"`
+	Item      *int    `yaml:"item"`
+	Threshold float64 `yaml:"threshold"`
+}
+
+type StreamConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	MaxSubscribers int           `yaml:"maxSubscribers" validate:"uint"`
+	QueueSize      int           `yaml:"queueSize" validate:"uint"`
+	Heartbeat      time.Duration `yaml:"heartbeat"`
+}
+
+type WebSocketConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	MaxConnections int           `yaml:"maxConnections" validate:"uint"`
+	PingInterval   time.Duration `yaml:"pingInterval"`
+}
+
+type CORSConfig struct {
+	Enabled        bool                `yaml:"enabled"`
+	AllowedOrigins []string            `yaml:"allowedOrigins"`
+	AllowedHeaders []string            `yaml:"allowedHeaders"`
+	MaxAge         time.Duration       `yaml:"maxAge"`
+	Channels       map[string][]string `yaml:"channels"` // channel -> allowed origins, replacing allowedOrigins
+}
+
+type FunnelConfig struct {
+	Name   string        `yaml:"name"`
+	Steps  []string      `yaml:"steps"`
+	Window time.Duration `yaml:"window"`
+}
+
 type Config struct {
 	AppName     string
 	Debug       bool
 	Path        string
-	Statistic   StatisticConfig `yaml:"statistic"`
-	WebServer   Server          `yaml:"webServer"`
-	Persistence Persistence     `yaml:"persistence"`
-	Logger      LoggerConfig    `yaml:"logger"`
-	Cache       CacheConfig     `yaml:"cache"`
-	Metrics     MetricsConfig   `yaml:"metrics"`
+	Statistic   StatisticConfig           `yaml:"statistic"`
+	WebServer   Server                    `yaml:"webServer"`
+	Grpc        GrpcConfig                `yaml:"grpc"`
+	UDP         UDPConfig                 `yaml:"udp"`
+	Persistence Persistence               `yaml:"persistence"`
+	Logger      LoggerConfig              `yaml:"logger"`
+	Cache       CacheConfig               `yaml:"cache"`
+	Metrics     MetricsConfig             `yaml:"metrics"`
+	Memory      MemoryConfig              `yaml:"memory"`
+	Related     RelatedConfig             `yaml:"related"`
+	Feed        FeedConfig                `yaml:"feed"`
+	Catalog     CatalogConfig             `yaml:"catalog"`
+	Dimensions  DimensionsConfig          `yaml:"dimensions"`
+	UserAgent   UserAgentConfig           `yaml:"userAgent"`
+	GeoIP       GeoIPConfig               `yaml:"geoip"`
+	Experiments ExperimentsConfig         `yaml:"experiments"`
+	Positions   PositionsConfig           `yaml:"positions"`
+	Rising      RisingConfig              `yaml:"rising"`
+	Anomalies   AnomaliesConfig           `yaml:"anomalies"`
+	Webhooks    WebhooksConfig            `yaml:"webhooks"`
+	Rules       []RuleConfig              `yaml:"rules"`
+	Stream      StreamConfig              `yaml:"stream"`
+	WebSocket   WebSocketConfig           `yaml:"websocket"`
+	CORS        CORSConfig                `yaml:"cors"`
+	Funnels     map[string][]FunnelConfig `yaml:"funnels"` // channel -> funnels
 }
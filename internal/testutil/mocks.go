@@ -1,8 +1,11 @@
 package testutil
 
 import (
+	json "github.com/goccy/go-json"
 	"ssd/internal/models"
 	"ssd/internal/providers"
+	"ssd/internal/services"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -48,29 +51,44 @@ type MockStatisticService struct {
 	mu              sync.Mutex
 	AddStatsCalls   []*models.InputStats
 	AggregateCalls  int
+	Pending         int // items added since the last AggregateStats call
 	StatisticData   map[string]map[int]*models.StatRecord
 	PersonalData    map[string]map[string]*models.Statistic
 	FingerprintData map[string]map[int]*models.StatRecord // key: "channel:fp"
+	RelatedData     map[string][]models.RelatedItem       // key: "channel:id"
+	FeedData        map[string][]services.FeedItem        // key: "channel:fp"
+	CatalogData     map[string]map[int]*models.CatalogItem
+	DimensionData   map[string]map[models.DimKey]map[int]*models.StatRecord
+	ExperimentData  map[string]*services.ExperimentReport // key: "channel:id"
+	FunnelData      map[string]*services.FunnelReport     // key: "channel:name"
+	RisingData      map[string][]services.RisingItem
+	LastBatch       map[string]services.BatchStats
 	ChannelsList    []string
 	PutCalls        []PutChannelCall
+	MemoryStats     services.MemoryStats
 }
 
 type PutChannelCall struct {
 	Channel  string
 	Trend    map[int]*models.StatRecord
 	Personal map[string]*models.Statistic
+	Data     *models.ChannelData
 }
 
 func (m *MockStatisticService) AddStats(data *models.InputStats) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.AddStatsCalls = append(m.AddStatsCalls, data)
+	m.Pending++
 }
 
-func (m *MockStatisticService) AggregateStats() {
+func (m *MockStatisticService) AggregateStats() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.AggregateCalls++
+	n := m.Pending
+	m.Pending = 0
+	return n
 }
 
 func (m *MockStatisticService) GetStatistic(channel string) map[int]*models.StatRecord {
@@ -82,6 +100,16 @@ func (m *MockStatisticService) GetStatistic(channel string) map[int]*models.Stat
 	return nil
 }
 
+func (m *MockStatisticService) GetStatisticJSON(channel string) []byte {
+	gson, _ := json.Marshal(m.GetStatistic(channel))
+	return gson
+}
+
+func (m *MockStatisticService) GetPersonalStatisticJSON(channel string) []byte {
+	gson, _ := json.Marshal(m.GetPersonalStatistic(channel))
+	return gson
+}
+
 func (m *MockStatisticService) GetPersonalStatistic(channel string) map[string]*models.Statistic {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -100,10 +128,116 @@ func (m *MockStatisticService) GetByFingerprint(channel, fp string) map[int]*mod
 	return nil
 }
 
-func (m *MockStatisticService) PutChannelData(channel string, trend map[int]*models.StatRecord, personal map[string]*models.Statistic) {
+func (m *MockStatisticService) GetRelated(channel string, id, n int) []models.RelatedItem {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.RelatedData != nil {
+		items := m.RelatedData[channel+":"+strconv.Itoa(id)]
+		return items[:min(max(n, 0), len(items))]
+	}
+	return nil
+}
+
+func (m *MockStatisticService) GetFilteredStatistic(channel string, filter models.CatalogFilter) map[int]*models.StatRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[int]*models.StatRecord)
+	for id, rec := range m.StatisticData[channel] {
+		if filter.Match(m.CatalogData[channel][id]) {
+			out[id] = rec
+		}
+	}
+	return out
+}
+
+func (m *MockStatisticService) GetDimensionStatistic(channel string, dim models.DimKey, _ models.CatalogFilter) map[int]*models.StatRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.DimensionData[channel][dim]
+}
+
+func (m *MockStatisticService) GetBreakdown(channel string, id int, dim string) map[string]*models.StatRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make(map[string]*models.StatRecord)
+	for key, records := range m.DimensionData[channel] {
+		if rec, ok := records[id]; ok && key.Name == dim {
+			out[key.Value] = rec
+		}
+	}
+	return out
+}
+
+func (m *MockStatisticService) GetExperiment(channel, id string, _ []int) *services.ExperimentReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ExperimentData[channel+":"+id]
+}
+
+func (m *MockStatisticService) GetFunnel(channel, name string, _ []int) *services.FunnelReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.FunnelData[channel+":"+name]
+}
+
+func (m *MockStatisticService) GetRising(channel string, n int) []services.RisingItem {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	items := m.RisingData[channel]
+	return items[:min(max(n, 0), len(items))]
+}
+
+func (m *MockStatisticService) GetLastBatch() map[string]services.BatchStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.LastBatch
+}
+
+func (m *MockStatisticService) GetExperimentRejects() int64 { return 0 }
+
+func (m *MockStatisticService) GetCatalogItem(channel string, id int) (*models.CatalogItem, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	item, ok := m.CatalogData[channel][id]
+	return item, ok
+}
+
+func (m *MockStatisticService) UpdateCatalog(channel string, upsert map[int]*models.CatalogItem, remove []int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.CatalogData == nil {
+		m.CatalogData = make(map[string]map[int]*models.CatalogItem)
+	}
+	if m.CatalogData[channel] == nil {
+		m.CatalogData[channel] = make(map[int]*models.CatalogItem)
+	}
+	for id, item := range upsert {
+		m.CatalogData[channel][id] = item
+	}
+	for _, id := range remove {
+		delete(m.CatalogData[channel], id)
+	}
+}
+
+func (m *MockStatisticService) GetFeed(channel, fp string, n int, _ models.CatalogFilter) []services.FeedItem {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.FeedData != nil {
+		items := m.FeedData[channel+":"+fp]
+		return items[:min(max(n, 0), len(items))]
+	}
+	return nil
+}
+
+func (m *MockStatisticService) PutChannelData(channel string, data *models.ChannelData) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.PutCalls = append(m.PutCalls, PutChannelCall{Channel: channel, Trend: trend, Personal: personal})
+	m.PutCalls = append(m.PutCalls, PutChannelCall{
+		Channel:  channel,
+		Trend:    data.TrendStats,
+		Personal: data.PersonalStats,
+		Data:     data,
+	})
 }
 
 func (m *MockStatisticService) GetChannels() []string {
@@ -123,13 +257,19 @@ func (m *MockStatisticService) GetSnapshot() *models.Storage {
 func (m *MockStatisticService) GetBufferSize() int {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return len(m.AddStatsCalls)
+	return m.Pending
 }
 
 func (m *MockStatisticService) GetRecordCount(_ string) int {
 	return 0
 }
 
+func (m *MockStatisticService) GetMemoryStats() services.MemoryStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.MemoryStats
+}
+
 // MockCache implements providers.CacheProviderInterface.
 type MockCache struct {
 	mu   sync.Mutex
@@ -161,7 +301,15 @@ type MockMetrics struct {
 	CacheHitsCalls           int
 	CacheMissesCalls         int
 	PersistenceDurationCalls int
+	AggregationTriggers      []string
+	AggregationBatchSizes    []int
 	RecordsTotalCalls        int
+	MemoryBytes              map[string]int64
+	IngestFiltered           map[string]int
+	StreamSubscribers        map[string]int
+	StreamDropped            int
+	UDPParseErrors           int
+	UDPDropped               map[string]int
 }
 
 func (m *MockMetrics) IncRequestsTotal(_ string, _ int) {
@@ -194,12 +342,86 @@ func (m *MockMetrics) ObservePersistenceDuration(_ time.Duration) {
 	m.PersistenceDurationCalls++
 }
 
+func (m *MockMetrics) ObserveAggregationDuration(trigger string, _ time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.AggregationTriggers = append(m.AggregationTriggers, trigger)
+}
+
+func (m *MockMetrics) ObserveAggregationBatchSize(_ string, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.AggregationBatchSizes = append(m.AggregationBatchSizes, size)
+}
+
+// AggregationCalls returns copies of the recorded aggregation triggers and batch sizes.
+func (m *MockMetrics) AggregationCalls() ([]string, []int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.AggregationTriggers...), append([]int(nil), m.AggregationBatchSizes...)
+}
+
 func (m *MockMetrics) SetRecordsTotal(_ string, _ int) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.RecordsTotalCalls++
 }
 
+func (m *MockMetrics) SetMemoryBytes(channel string, bytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.MemoryBytes == nil {
+		m.MemoryBytes = make(map[string]int64)
+	}
+	m.MemoryBytes[channel] = bytes
+}
+
+func (m *MockMetrics) IncIngestFiltered(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.IngestFiltered == nil {
+		m.IngestFiltered = make(map[string]int)
+	}
+	m.IngestFiltered[reason]++
+}
+
+func (m *MockMetrics) SetStreamSubscribers(channel string, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.StreamSubscribers == nil {
+		m.StreamSubscribers = make(map[string]int)
+	}
+	m.StreamSubscribers[channel] = count
+}
+
+func (m *MockMetrics) IncStreamDropped() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.StreamDropped++
+}
+
+func (m *MockMetrics) IncUDPParseErrors() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.UDPParseErrors++
+}
+
+func (m *MockMetrics) IncUDPDropped(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.UDPDropped == nil {
+		m.UDPDropped = make(map[string]int)
+	}
+	m.UDPDropped[reason]++
+}
+
+// FilteredCount returns how often reason was reported.
+func (m *MockMetrics) FilteredCount(reason string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.IngestFiltered[reason]
+}
+
 // MockCompressor implements interfaces.CompressorInterface with injectable behavior.
 type MockCompressor struct {
 	CompressFn   func([]byte) ([]byte, error)
@@ -226,3 +448,178 @@ func (m *MockCompressor) Decompress(val []byte) ([]byte, error) {
 }
 
 func (m *MockCompressor) Close() {}
+
+// MockWebhooks implements providers.WebhookProviderInterface and records
+// the sent events.
+type MockWebhooks struct {
+	mu     sync.Mutex
+	Events []string
+	Data   []any
+	Closed bool
+}
+
+func (m *MockWebhooks) Send(event string, data any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Events = append(m.Events, event)
+	m.Data = append(m.Data, data)
+}
+
+func (m *MockWebhooks) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Closed = true
+}
+
+// MockAnomalyDetector implements services.AnomalyDetectorInterface. Detect
+// records its arguments and returns Next.
+type MockAnomalyDetector struct {
+	mu       sync.Mutex
+	Next     []services.Alert
+	Batches  []map[string]services.BatchStats
+	Elapsed  []time.Duration
+	Recorded []services.Alert
+}
+
+func (m *MockAnomalyDetector) Detect(batches map[string]services.BatchStats, elapsed time.Duration) []services.Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Batches = append(m.Batches, batches)
+	m.Elapsed = append(m.Elapsed, elapsed)
+	alerts := m.Next
+	m.Next = nil
+	m.Recorded = append(m.Recorded, alerts...)
+	return alerts
+}
+
+func (m *MockAnomalyDetector) Alerts(channel string) []services.Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []services.Alert
+	for _, a := range m.Recorded {
+		if channel == "" || a.Channel == channel {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// MockRuleEngine implements services.RuleEngineInterface. The After* methods
+// record their calls and return Next.
+type MockRuleEngine struct {
+	mu             sync.Mutex
+	Next           []services.RuleEvent
+	AggregateCalls int
+	BufferCalls    int
+	PersistErrors  []error
+	RuleList       []services.Rule
+}
+
+func (m *MockRuleEngine) AfterAggregate() []services.RuleEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.AggregateCalls++
+	events := m.Next
+	m.Next = nil
+	return events
+}
+
+func (m *MockRuleEngine) CheckBuffer() []services.RuleEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.BufferCalls++
+	events := m.Next
+	m.Next = nil
+	return events
+}
+
+// BufferChecks returns the number of CheckBuffer calls.
+func (m *MockRuleEngine) BufferChecks() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.BufferCalls
+}
+
+func (m *MockRuleEngine) AfterPersist(err error) []services.RuleEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PersistErrors = append(m.PersistErrors, err)
+	events := m.Next
+	m.Next = nil
+	return events
+}
+
+func (m *MockRuleEngine) Rules() []services.Rule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.RuleList
+}
+
+func (m *MockRuleEngine) SetRule(rule services.Rule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.RuleList = append(m.RuleList, rule)
+	return nil
+}
+
+func (m *MockRuleEngine) DeleteRule(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, r := range m.RuleList {
+		if r.Name == name {
+			m.RuleList = append(m.RuleList[:i], m.RuleList[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MockRuleEngine) Update(set []services.Rule, del []string) error {
+	for i := range set {
+		if err := set[i].Validate(); err != nil {
+			return err
+		}
+	}
+	for _, name := range del {
+		m.DeleteRule(name)
+	}
+	for _, rule := range set {
+		_ = m.SetRule(rule)
+	}
+	return nil
+}
+
+func (m *MockRuleEngine) Overrides() *models.RuleOverrides { return nil }
+
+func (m *MockRuleEngine) Restore(_ *models.RuleOverrides) {}
+
+// MockStream implements providers.StreamProviderInterface and counts the
+// publishes.
+type MockStream struct {
+	mu           sync.Mutex
+	PublishCalls int
+	Closed       bool
+}
+
+func (m *MockStream) Subscribe(string, providers.StreamFilter) (*providers.Subscription, error) {
+	return nil, providers.ErrStreamDisabled
+}
+
+func (m *MockStream) Unsubscribe(*providers.Subscription) {}
+
+func (m *MockStream) Publish() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.PublishCalls++
+}
+
+func (m *MockStream) Heartbeat() time.Duration { return time.Second }
+
+func (m *MockStream) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Closed = true
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package internal
+
+// withUmask runs fn; platforms without a umask ignore socket permissions.
+func withUmask(_ int, fn func() error) error {
+	return fn()
+}
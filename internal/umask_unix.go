@@ -0,0 +1,13 @@
+//go:build unix
+
+package internal
+
+import "syscall"
+
+// withUmask runs fn with the process umask set to mask. The umask is process
+// wide, so files created concurrently get at most the same restriction.
+func withUmask(mask int, fn func() error) error {
+	old := syscall.Umask(mask)
+	defer syscall.Umask(old)
+	return fn()
+}
@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"ssd/internal/structures"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultUnixSocketMode = 0660
+	staleSocketTimeout    = time.Second
+)
+
+// listenUnix creates the HTTP Unix socket with the configured permissions.
+// A socket left behind by a killed process is replaced; a socket another
+// process still listens on and any other file at the path are errors.
+// Closing the listener removes the socket.
+func listenUnix(conf structures.UnixSocketConfig) (net.Listener, error) {
+	if info, err := os.Lstat(conf.Path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", conf.Path)
+		}
+		if err := removeStaleSocket(conf.Path); err != nil {
+			return nil, err
+		}
+	}
+	mode := os.FileMode(conf.Mode)
+	if mode == 0 {
+		mode = defaultUnixSocketMode
+	}
+	// The socket is created with the umask applied, so it is never reachable
+	// with wider permissions than configured, not even briefly.
+	var lis net.Listener
+	err := withUmask(int(^mode&os.ModePerm), func() (err error) {
+		lis, err = net.Listen("unix", conf.Path)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lis, nil
+}
+
+// removeStaleSocket removes the socket at path only if nothing accepts
+// connections on it, so a second instance cannot take over the socket of a
+// running one.
+func removeStaleSocket(path string) error {
+	conn, err := net.DialTimeout("unix", path, staleSocketTimeout)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%s is in use by another process", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return os.Remove(path)
+}
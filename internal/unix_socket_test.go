@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"ssd/internal/structures"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenUnix_ServesAndCleansUp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssd.sock")
+	lis, err := listenUnix(structures.UnixSocketConfig{Path: path, Mode: 0600})
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})}
+	go func() { _ = server.Serve(lis) }()
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/list")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "ok", string(body))
+
+	require.NoError(t, server.Shutdown(context.Background()))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err), "the socket is removed on shutdown")
+}
+
+func TestListenUnix_ReplacesStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssd.sock")
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	lis, err := listenUnix(structures.UnixSocketConfig{Path: path})
+	require.NoError(t, err)
+	defer lis.Close()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(defaultUnixSocketMode), info.Mode().Perm())
+}
+
+func TestListenUnix_RefusesLiveSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssd.sock")
+	live, err := listenUnix(structures.UnixSocketConfig{Path: path})
+	require.NoError(t, err)
+	defer live.Close()
+
+	_, err = listenUnix(structures.UnixSocketConfig{Path: path})
+	assert.ErrorContains(t, err, "in use")
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err, "the running listener keeps its socket")
+	conn.Close()
+}
+
+func TestListenUnix_RefusesOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.bin")
+	require.NoError(t, os.WriteFile(path, []byte("keep"), 0644))
+
+	_, err := listenUnix(structures.UnixSocketConfig{Path: path})
+	assert.Error(t, err)
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "keep", string(data))
+}